@@ -0,0 +1,255 @@
+package BRFL
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// -------------------- 二进制编码 --------------------
+//
+// Sigma 的二进制编码格式如下，整数均为大端序，括号内为字节数：
+//
+//	EncodingVersion (1) || SchemeID (1) || Sigma.Version (1)
+//	|| R_M (PointSize) || n (4) || U_1 ... U_n (各 PointSize)
+//	|| V (ScalarSize) || C (ScalarSize) || T (PointSize) || Pi (ScalarSize)
+//	[ || KeyImage (PointSize) || LC (ScalarSize) || LR_1 ... LR_n (各 ScalarSize) ]
+//	[ || E_1 (PointSize) || E_2 (PointSize) || TC (ScalarSize) || TZ_1 ... TZ_n || TY_1 ... TY_n (各 ScalarSize) ]
+//
+// 方括号内依次为可链接签名与可追踪签名附带的部分，是否存在由剩余数据的长度决定。
+// 其中 G1 点以 48 字节的压缩形式编码，标量为定长 32 字节。
+
+const (
+	// SchemeName 方案名称，与 RingSig 注册表中的名称一致，JSON 文档中用于标识方案
+	SchemeName = "brfl-bls12381"
+	// EncodingVersion 二进制编码格式的版本
+	EncodingVersion byte = 1
+	// SchemeID 二进制编码中标识 BRFL/BLS12-381 的字节
+	SchemeID byte = 0x02
+	// PointSize G1 点编码后的字节数
+	PointSize = 48
+	// ScalarSize 标量编码后的字节数
+	ScalarSize = 32
+)
+
+// headerSize 编码头部（EncodingVersion、SchemeID、Sigma.Version）的字节数
+const headerSize = 3
+
+// EncodedSize 返回环大小为 n 时签名编码后的字节数
+func EncodedSize(n int) int {
+	return headerSize + PointSize + 4 + n*PointSize + 3*ScalarSize + PointSize
+}
+
+// LinkableEncodedSize 返回环大小为 n 时可链接签名编码后的字节数
+func LinkableEncodedSize(n int) int {
+	return EncodedSize(n) + linkableSize(n)
+}
+
+// linkableSize 可链接部分（KeyImage、LC、LR_i）编码后的字节数
+func linkableSize(n int) int {
+	return PointSize + (n+1)*ScalarSize
+}
+
+// TraceableEncodedSize 返回环大小为 n 时可追踪签名编码后的字节数，同时可链接时还需加上 LinkableEncodedSize(n) - EncodedSize(n)
+func TraceableEncodedSize(n int) int {
+	return EncodedSize(n) + traceSize(n)
+}
+
+// traceSize 可追踪部分（E_1、E_2、TC、TZ_i、TY_i）编码后的字节数
+func traceSize(n int) int {
+	return 2*PointSize + (2*n+1)*ScalarSize
+}
+
+// MarshalBinary 将签名编码为二进制格式，实现 encoding.BinaryMarshaler
+func (s *Sigma) MarshalBinary() ([]byte, error) {
+	if err := CheckSigma(s); err != nil {
+		return nil, err
+	}
+	if uint64(len(s.UI)) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: 环过大", ErrMalformedSignature)
+	}
+
+	buf := make([]byte, 0, LinkableEncodedSize(len(s.UI))+traceSize(len(s.UI)))
+	buf = append(buf, EncodingVersion, SchemeID, byte(s.Version))
+	buf = append(buf, encodePoint(s.RM)...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(s.UI)))
+	for _, v := range s.UI {
+		buf = append(buf, encodePoint(v)...)
+	}
+	buf = appendScalar(buf, s.V)
+	buf = appendScalar(buf, s.C)
+	buf = append(buf, encodePoint(s.T)...)
+	buf = appendScalar(buf, s.Pi)
+	if s.KeyImage != nil {
+		buf = append(buf, encodePoint(s.KeyImage)...)
+		buf = appendScalar(buf, s.LC)
+		for _, k := range s.LR {
+			buf = appendScalar(buf, k)
+		}
+	}
+	if s.TE1 != nil {
+		buf = append(buf, encodePoint(s.TE1)...)
+		buf = append(buf, encodePoint(s.TE2)...)
+		buf = appendScalar(buf, s.TC)
+		for _, k := range append(append([]*big.Int(nil), s.TZ...), s.TY...) {
+			buf = appendScalar(buf, k)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary 从二进制格式解码签名，实现 encoding.BinaryUnmarshaler
+// 编码版本、方案标识、长度、点与标量的取值均会被校验，并按 CheckSigma 拒绝无效点，失败时 s 保持不变
+func (s *Sigma) UnmarshalBinary(data []byte) error {
+	// 1. 校验头部
+	version, data, err := readHeader(data)
+	if err != nil {
+		return err
+	}
+	if len(data) < PointSize+4 {
+		return fmt.Errorf("%w: 数据长度不足", ErrInvalidEncoding)
+	}
+
+	// 2. 读取 R_M 与环大小，并在分配内存前核对总长度
+	RM, data, err := readPoint(data)
+	if err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	base := uint64(n)*PointSize + 3*ScalarSize + PointSize
+	link := PointSize + (uint64(n)+1)*ScalarSize
+	trace := 2*PointSize + (2*uint64(n)+1)*ScalarSize
+	var linkable, traceable bool
+	switch uint64(len(data)) {
+	case base:
+	case base + link:
+		linkable = true
+	case base + trace:
+		traceable = true
+	case base + link + trace:
+		linkable, traceable = true, true
+	default:
+		return fmt.Errorf("%w: 数据长度与环大小 %d 不符", ErrInvalidEncoding, n)
+	}
+
+	// 3. 读取 U_i
+	UI := make([]*bls.PointG1, n)
+	for i := range UI {
+		if UI[i], data, err = readPoint(data); err != nil {
+			return err
+		}
+	}
+
+	// 4. 读取 V、C、T、Pi
+	V, data, err := readScalar(data)
+	if err != nil {
+		return err
+	}
+	C, data, err := readScalar(data)
+	if err != nil {
+		return err
+	}
+	T, data, err := readPoint(data)
+	if err != nil {
+		return err
+	}
+	Pi, data, err := readScalar(data)
+	if err != nil {
+		return err
+	}
+	sig := Sigma{RM: RM, UI: UI, V: V, C: C, T: T, Pi: Pi, Version: version}
+
+	// 5. 可链接签名：读取 KeyImage、LC、LR_i
+	if linkable {
+		if sig.KeyImage, data, err = readPoint(data); err != nil {
+			return err
+		}
+		if sig.LC, data, err = readScalar(data); err != nil {
+			return err
+		}
+		sig.LR = make([]*big.Int, n)
+		for i := range sig.LR {
+			if sig.LR[i], data, err = readScalar(data); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 6. 可追踪签名：读取 E_1、E_2、TC、TZ_i、TY_i
+	if traceable {
+		if sig.TE1, data, err = readPoint(data); err != nil {
+			return err
+		}
+		if sig.TE2, data, err = readPoint(data); err != nil {
+			return err
+		}
+		if sig.TC, data, err = readScalar(data); err != nil {
+			return err
+		}
+		sig.TZ = make([]*big.Int, n)
+		sig.TY = make([]*big.Int, n)
+		for _, list := range [][]*big.Int{sig.TZ, sig.TY} {
+			for i := range list {
+				if list[i], data, err = readScalar(data); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := CheckSigma(&sig); err != nil {
+		return err
+	}
+	*s = sig
+	return nil
+}
+
+// readHeader 校验编码头部，返回签名的转录版本与剩余数据
+func readHeader(data []byte) (TranscriptVersion, []byte, error) {
+	if len(data) < headerSize {
+		return 0, nil, fmt.Errorf("%w: 数据长度不足", ErrInvalidEncoding)
+	}
+	if data[0] != EncodingVersion {
+		return 0, nil, fmt.Errorf("%w: 不支持的编码版本 %d", ErrInvalidEncoding, data[0])
+	}
+	if data[1] != SchemeID {
+		return 0, nil, fmt.Errorf("%w: 方案标识 %#x 不是 BRFL/BLS12-381", ErrInvalidEncoding, data[1])
+	}
+	version := TranscriptVersion(data[2])
+	if !version.Supported() {
+		return 0, nil, fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, version)
+	}
+	return version, data[headerSize:], nil
+}
+
+// encodePoint 将 G1 点编码为 PointSize 字节
+func encodePoint(p *bls.PointG1) []byte {
+	g1 := getG1()
+	defer putG1(g1)
+
+	// ToCompressed 会原地修改参数，这里对副本编码
+	return g1.ToCompressed(new(bls.PointG1).Set(p))
+}
+
+// readPoint 从 data 头部读取一个 G1 点，返回剩余数据
+func readPoint(data []byte) (*bls.PointG1, []byte, error) {
+	g1 := getG1()
+	defer putG1(g1)
+	p, err := g1.FromCompressed(data[:PointSize])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return p, data[PointSize:], nil
+}
+
+// readScalar 从 data 头部读取一个定长标量，要求其落在 [0, Order) 内，返回剩余数据
+func readScalar(data []byte) (*big.Int, []byte, error) {
+	k := new(big.Int).SetBytes(data[:ScalarSize])
+	if k.Cmp(Order) >= 0 {
+		return nil, nil, fmt.Errorf("%w: 标量超出 Z_q 范围", ErrInvalidEncoding)
+	}
+	return k, data[ScalarSize:], nil
+}
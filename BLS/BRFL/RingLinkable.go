@@ -0,0 +1,132 @@
+package BRFL
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// -------------------- 可链接模式 --------------------
+//
+// 可链接签名在普通签名之外附带密钥镜像 I = sk_s \cdot H_p(pk_s)，以及证明 I 与环一致的 LSAG 证明：
+//
+//	L_i = r_i \cdot P + c_i \cdot pk_i,  R_i = r_i \cdot H_p(pk_i) + c_i \cdot I,  c_{i+1} = H(context, I, C, L_i, R_i)
+//
+// 验证者从 c_0 出发依次计算 c_1, ..., c_n，要求 c_n = c_0。签名者在自己的位置 s 上以随机数 α 代替，
+// 最后取 r_s = α - c_s \cdot sk_s 闭合整个环。同一私钥的两个签名具有相同的 I，由 Link 判断；除此之外签名者仍然匿名。
+
+// HashToPointG1 将 data 哈希为 G1 上离散对数未知的点，即可链接模式中的 H_p
+// 使用 kilic 实现的 hash-to-curve（SSWU 映射），域标签为 HashDomainV2 + TagHp，结果已转换为仿射坐标
+func HashToPointG1(data []byte) *bls.PointG1 {
+	g1 := getG1()
+	defer putG1(g1)
+	p, err := g1.HashToCurve(data, []byte(HashDomainV2+TagHp))
+	if err != nil {
+		panic(fmt.Sprintf("HashToPointG1: %v", err))
+	}
+	return g1.Affine(p)
+}
+
+// KeyImage 计算签名者的密钥镜像 I = sk \cdot H_p(pk)
+func KeyImage(SignerS *Signer) (*bls.PointG1, error) {
+	if SignerS == nil || SignerS.PrivateKey == nil || SignerS.PublicKey == nil {
+		return nil, ErrNilSigner
+	}
+	return ScalarMulG1(HashToPointG1(marshalG1(SignerS.PublicKey)), SignerS.PrivateKey), nil
+}
+
+// Link 判断两个可链接签名是否由同一私钥生成，任一签名不是可链接签名时返回 false
+// 只比较密钥镜像，调用前应先分别验证两个签名
+func Link(sig1, sig2 *Sigma) bool {
+	if sig1 == nil || sig2 == nil || sig1.KeyImage == nil || sig2.KeyImage == nil {
+		return false
+	}
+	return CompareG1(sig1.KeyImage, sig2.KeyImage)
+}
+
+// keyImageBases 返回环中各公钥的 H_p(pk_i)，首次调用时计算并缓存在 RingContext 中
+func (rc *RingContext) keyImageBases() []*bls.PointG1 {
+	rc.hpOnce.Do(func() {
+		rc.hp = make([]*bls.PointG1, len(rc.pkList))
+		for i, pk := range rc.pkList {
+			rc.hp[i] = HashToPointG1(marshalG1(pk))
+		}
+	})
+	return rc.hp
+}
+
+// signLinkable 为下标 flag 处的签名者生成密钥镜像及其 LSAG 证明，C 为签名的挑战值，随机数从 r 读取
+func signLinkable(r io.Reader, tr *transcript, rc *RingContext, SignerS *Signer, flag int, C *big.Int) (*bls.PointG1, *big.Int, []*big.Int, error) {
+	n := rc.Len()
+	hp := rc.keyImageBases()
+	I := ScalarMulG1(hp[flag], SignerS.PrivateKey)
+
+	// 1. 签名者位置：L_s = α \cdot P，R_s = α \cdot H_p(pk_s)
+	alpha, err := RandomZqFrom(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer zeroizeInt(alpha)
+	c := make([]*big.Int, n)
+	c[(flag+1)%n] = tr.Link(I, C, baseMulG1(alpha), ScalarMulG1(hp[flag], alpha))
+
+	// 2. 其余成员依次取随机响应 r_i，沿环计算挑战值
+	LR := make([]*big.Int, n)
+	for k := 1; k < n; k++ {
+		i := (flag + k) % n
+		if LR[i], err = RandomZqFrom(r); err != nil {
+			return nil, nil, nil, err
+		}
+		L := AddG1(baseMulG1(LR[i]), ScalarMulG1(rc.pkList[i], c[i]))
+		R := AddG1(ScalarMulG1(hp[i], LR[i]), ScalarMulG1(I, c[i]))
+		c[(i+1)%n] = tr.Link(I, C, L, R)
+	}
+
+	// 3. 闭合：r_s = α - c_s \cdot sk_s
+	LR[flag] = SubZq(alpha, MulZq(c[flag], SignerS.PrivateKey))
+	return I, c[0], LR, nil
+}
+
+// verifyLinkable 校验签名中密钥镜像的 LSAG 证明，调用前签名结构已由 CheckSigma 校验
+func verifyLinkable(tr *transcript, rc *RingContext, SignerResult *Sigma) error {
+	hp := rc.keyImageBases()
+	I := SignerResult.KeyImage
+	c := SignerResult.LC
+	for i, pk := range rc.pkList {
+		r := SignerResult.LR[i]
+		L := AddG1(baseMulG1(r), ScalarMulG1(pk, c))
+		R := AddG1(ScalarMulG1(hp[i], r), ScalarMulG1(I, c))
+		c = tr.Link(I, SignerResult.C, L, R)
+	}
+	if !CompareBigInts(c, SignerResult.LC) {
+		return ErrLinkMismatch
+	}
+	return nil
+}
+
+// checkLinkable 校验可链接部分的结构：三个字段须同时存在或同时为空，且只能与 TranscriptV2 一起使用
+func checkLinkable(SignerResult *Sigma) error {
+	if SignerResult.KeyImage == nil {
+		if SignerResult.LC != nil || SignerResult.LR != nil {
+			return fmt.Errorf("%w: 缺少密钥镜像", ErrMalformedSignature)
+		}
+		return nil
+	}
+	if SignerResult.Version != TranscriptV2 {
+		return fmt.Errorf("%w: 可链接签名须使用 v2 转录编码", ErrMalformedSignature)
+	}
+	if err := ValidatePoint(SignerResult.KeyImage); err != nil {
+		return fmt.Errorf("%w: 密钥镜像", err)
+	}
+	if SignerResult.LC == nil || len(SignerResult.LR) != len(SignerResult.UI) {
+		return fmt.Errorf("%w: 密钥镜像的环证明不完整", ErrMalformedSignature)
+	}
+	for _, k := range append([]*big.Int{SignerResult.LC}, SignerResult.LR...) {
+		if k == nil || k.Sign() < 0 || k.Cmp(Order) >= 0 {
+			return fmt.Errorf("%w: 标量超出 Z_q 范围", ErrMalformedSignature)
+		}
+	}
+	return nil
+}
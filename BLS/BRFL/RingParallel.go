@@ -0,0 +1,84 @@
+package BRFL
+
+import (
+	"context"
+	bls "github.com/kilic/bls12-381"
+	"math/big"
+	"sync"
+)
+
+// sumChunk 环求和与公钥环校验时每段的最大成员数，段与段之间检查 ctx 是否已取消
+const sumChunk = 1024
+
+// parallelFor 把 [0, n) 分为至多 workers 段，由各协程依次调用 fn，每个下标处理前检查 ctx
+// ctx 被取消时尽快返回 ctx.Err()；workers 不超过 1 时在当前协程中串行执行
+func parallelFor(ctx context.Context, workers, n int, fn func(i int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	workers = max(1, min(workers, n))
+	size := (n + workers - 1) / workers
+
+	run := func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if ctx.Err() != nil {
+				return
+			}
+			fn(i)
+		}
+	}
+	if workers == 1 {
+		run(0, n)
+		return ctx.Err()
+	}
+
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += size {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			run(lo, hi)
+		}(lo, min(lo+size, n))
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// slice 返回只包含下标 [lo, hi) 的成员的 RingContext 视图，用于分段求和
+func (rc *RingContext) slice(lo, hi int) *RingContext {
+	sub := &RingContext{pkList: rc.pkList[lo:hi]}
+	if rc.tables != nil {
+		sub.tables = rc.tables[lo:hi]
+	}
+	return sub
+}
+
+// computeSumContext 与 computeSum 相同，但把环分为若干段，由 workers 个协程分别求和后再相加
+// 每段不超过 sumChunk 个成员，ctx 被取消时返回 ctx.Err()
+func (rc *RingContext) computeSumContext(ctx context.Context, workers int, H_i []*big.Int, U_i []*bls.PointG1, signer_index int) (*bls.PointG1, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	n := len(H_i)
+	chunks := min(max(workers, (n+sumChunk-1)/sumChunk), n)
+	if chunks <= 1 {
+		return rc.computeSum(H_i, U_i, signer_index), nil
+	}
+	size := (n + chunks - 1) / chunks
+	chunks = (n + size - 1) / size
+
+	partial := make([]*bls.PointG1, chunks)
+	err := parallelFor(ctx, workers, chunks, func(c int) {
+		lo, hi := c*size, min(c*size+size, n)
+		partial[c] = rc.slice(lo, hi).computeSum(H_i[lo:hi], U_i[lo:hi], signer_index-lo)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sum := partial[0]
+	for _, p := range partial[1:] {
+		sum = AddG1(sum, p)
+	}
+	return sum, nil
+}
@@ -1,14 +1,43 @@
 package BRFL
 
 import (
+	"BRFL/KeyPEM"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	bls "github.com/kilic/bls12-381"
+	"io"
+	"math/big"
+	mrand "math/rand"
+	"runtime"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 var MessageTrue = []byte("这是用来正确签名的信息。")
 var MessageFalse = []byte("这是用来错误验证的信息。")
 
+// newRing 生成 n 个签名者及对应的公钥环
+func newRing(t testing.TB, n int, opts ...Option) ([]*Signer, []*bls.PointG1) {
+	t.Helper()
+	L := make([]*Signer, n)
+	List := make([]*bls.PointG1, n)
+	for i := range L {
+		signer, err := NewSigner(opts...)
+		if err != nil {
+			t.Fatalf("生成签名者失败: %v", err)
+		}
+		L[i] = signer
+		List[i] = signer.PublicKey
+	}
+	return L, List
+}
+
 // 测试 BRFL 环签名的签名与验证
 func TestRingSignature(t *testing.T) {
 	fmt.Println("=== 开始测试 BRFL 环签名方案 ===")
@@ -19,14 +48,20 @@ func TestRingSignature(t *testing.T) {
 	var List []*bls.PointG1 // 环签名的公钥列表
 	SignerS := 2
 	for i := 0; i < n; i++ {
-		signer := NewSigner()
+		signer, err := NewSigner()
+		if err != nil {
+			t.Fatalf("生成签名者失败: %v", err)
+		}
 		L = append(L, signer)
 		List = append(List, signer.PublicKey)
 	}
 	fmt.Printf("已生成 %d 个签名者\n", n)
 
 	// 开始签名
-	SignerResult := Sign(MessageTrue, List, L[SignerS])
+	SignerResult, err := Sign(MessageTrue, List, L[SignerS])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
 
 	//fmt.Println("签名结果 Sigma 为：")
 	//fmt.Println("RM 为：", SignerResult.RM)
@@ -42,3 +77,1525 @@ func TestRingSignature(t *testing.T) {
 	Verify2 := Verify(MessageFalse, List, SignerResult)
 	fmt.Println(Verify2)
 }
+
+// sameSigma 判断两个签名是否完全相同
+func sameSigma(a, b *Sigma) bool {
+	for i := range a.UI {
+		if !CompareG1(a.UI[i], b.UI[i]) {
+			return false
+		}
+	}
+	if (a.KeyImage == nil) != (b.KeyImage == nil) || len(a.LR) != len(b.LR) {
+		return false
+	}
+	if a.KeyImage != nil {
+		if !CompareG1(a.KeyImage, b.KeyImage) || !CompareBigInts(a.LC, b.LC) {
+			return false
+		}
+		for i := range a.LR {
+			if !CompareBigInts(a.LR[i], b.LR[i]) {
+				return false
+			}
+		}
+	}
+	if (a.TE1 == nil) != (b.TE1 == nil) || len(a.TZ) != len(b.TZ) || len(a.TY) != len(b.TY) {
+		return false
+	}
+	if a.TE1 != nil {
+		if !CompareG1(a.TE1, b.TE1) || !CompareG1(a.TE2, b.TE2) || !CompareBigInts(a.TC, b.TC) {
+			return false
+		}
+		for i := range a.TZ {
+			if !CompareBigInts(a.TZ[i], b.TZ[i]) || !CompareBigInts(a.TY[i], b.TY[i]) {
+				return false
+			}
+		}
+	}
+	return CompareG1(a.RM, b.RM) && CompareG1(a.T, b.T) &&
+		CompareBigInts(a.V, b.V) && CompareBigInts(a.C, b.C) && CompareBigInts(a.Pi, b.Pi)
+}
+
+// 测试签名时对非法公钥环的检查
+func TestSignInvalidRing(t *testing.T) {
+	_, List := newRing(t, 4)
+	others, _ := newRing(t, 1)
+	outsider := others[0]
+	member := &Signer{PrivateKey: nil, PublicKey: List[0]}
+
+	cases := []struct {
+		name   string
+		ring   []*bls.PointG1
+		signer *Signer
+		want   error
+	}{
+		{"空环", nil, outsider, ErrEmptyRing},
+		{"签名者不在环中", List, outsider, ErrSignerNotInRing},
+		{"环中存在 nil 公钥", append([]*bls.PointG1{outsider.PublicKey, nil}, List...), outsider, ErrNilPublicKey},
+		{"环中存在重复公钥", append([]*bls.PointG1{outsider.PublicKey}, append(List, List[1])...), outsider, ErrDuplicatePublicKey},
+		{"签名者为 nil", List, nil, ErrNilSigner},
+		{"签名者私钥为 nil", List, member, ErrNilSigner},
+		{"私钥与公钥不匹配", List, &Signer{PrivateKey: outsider.PrivateKey, PublicKey: List[0]}, ErrKeyMismatch},
+	}
+
+	for _, c := range cases {
+		sigma, err := Sign(MessageTrue, c.ring, c.signer)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if sigma != nil {
+			t.Errorf("%s: 出错时不应返回签名", c.name)
+		}
+	}
+}
+
+// 测试 VerifyDetailed 返回的拒绝原因
+func TestVerifyDetailed(t *testing.T) {
+	n := 4
+	L, List := newRing(t, n)
+	sigma, err := Sign(MessageTrue, List, L[1])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	short := *sigma
+	short.UI = sigma.UI[:n-1]
+	nilUI := *sigma
+	nilUI.UI = append([]*bls.PointG1(nil), sigma.UI...)
+	nilUI.UI[0] = nil
+	noPi := *sigma
+	noPi.Pi = nil
+	bigC := *sigma
+	bigC.C = new(big.Int).Add(sigma.C, Order)
+
+	cases := []struct {
+		name    string
+		message []byte
+		ring    []*bls.PointG1
+		sigma   *Sigma
+		want    error
+	}{
+		{"合法签名", MessageTrue, List, sigma, nil},
+		{"消息不匹配", MessageFalse, List, sigma, ErrChallengeMismatch},
+		{"空环", MessageTrue, nil, sigma, ErrEmptyRing},
+		{"环大小不一致", MessageTrue, List, &short, ErrRingSizeMismatch},
+		{"签名为 nil", MessageTrue, List, nil, ErrMalformedSignature},
+		{"U_i 为 nil", MessageTrue, List, &nilUI, ErrMalformedSignature},
+		{"缺少 Pi", MessageTrue, List, &noPi, ErrMalformedSignature},
+		{"标量超出范围", MessageTrue, List, &bigC, ErrMalformedSignature},
+	}
+
+	for _, c := range cases {
+		err := VerifyDetailed(c.message, c.ring, c.sigma)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if Verify(c.message, c.ring, c.sigma) != (c.want == nil) {
+			t.Errorf("%s: Verify 与 VerifyDetailed 结果不一致", c.name)
+		}
+	}
+}
+
+// 测试通过 WithRandom 注入随机数来源
+func TestWithRandom(t *testing.T) {
+	// 相同种子的随机数来源应得到完全相同的密钥与签名
+	L1, List1 := newRing(t, 3, WithRandom(mrand.New(mrand.NewSource(1))))
+	L2, List2 := newRing(t, 3, WithRandom(mrand.New(mrand.NewSource(1))))
+	for i := range List1 {
+		if !CompareG1(List1[i], List2[i]) {
+			t.Fatalf("相同随机数来源生成的公钥 %d 不一致", i)
+		}
+	}
+
+	sigma1, err := Sign(MessageTrue, List1, L1[0], WithRandom(mrand.New(mrand.NewSource(2))))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma2, err := Sign(MessageTrue, List2, L2[0], WithRandom(mrand.New(mrand.NewSource(2))))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !sameSigma(sigma1, sigma2) {
+		t.Errorf("相同随机数来源生成的签名不一致")
+	}
+	if !Verify(MessageTrue, List1, sigma1) {
+		t.Errorf("注入随机数来源生成的签名验证失败")
+	}
+
+	// 随机数来源读取失败时应返回错误而不是 panic
+	broken := WithRandom(iotest.ErrReader(errors.New("熵源不可用")))
+	if _, err := NewSigner(broken); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("NewSigner: 期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+	if _, err := Sign(MessageTrue, List1, L1[0], broken); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("Sign: 期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+}
+
+// 测试确定性签名模式：相同输入两次签名的结果逐字节相同
+func TestDeterministicSign(t *testing.T) {
+	L, List := newRing(t, 4, WithRandom(mrand.New(mrand.NewSource(3))))
+
+	sigma1, err := Sign(MessageTrue, List, L[2], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	// 确定性模式下不读取 Random，即使来源已损坏也应得到相同结果
+	broken := WithRandom(iotest.ErrReader(errors.New("熵源不可用")))
+	sigma2, err := Sign(MessageTrue, List, L[2], broken, WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !sameSigma(sigma1, sigma2) {
+		t.Errorf("确定性模式下两次签名结果不一致")
+	}
+	if !Verify(MessageTrue, List, sigma1) {
+		t.Errorf("确定性签名验证失败")
+	}
+
+	// 消息或额外熵不同时，派生出的签名应不同
+	sigma3, err := Sign(MessageFalse, List, L[2], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma4, err := Sign(MessageTrue, List, L[2], WithDeterministic([]byte("额外熵")))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if sameSigma(sigma1, sigma3) || sameSigma(sigma1, sigma4) {
+		t.Errorf("不同输入派生出了相同的签名")
+	}
+	if !Verify(MessageTrue, List, sigma4) {
+		t.Errorf("混入额外熵的确定性签名验证失败")
+	}
+
+	// 转录版本不同时派生出的随机数也不同，否则同一消息的两个签名会泄露私钥
+	seen := make(map[string]TranscriptVersion)
+	for _, v := range []TranscriptVersion{TranscriptLegacy, TranscriptV1, TranscriptV2} {
+		nonce := make([]byte, 32)
+		if _, err := io.ReadFull(NewNonceReader(L[2].PrivateKey, MessageTrue, List, NewConfig(WithTranscript(v))), nonce); err != nil {
+			t.Fatalf("读取随机数失败: %v", err)
+		}
+		if u, ok := seen[string(nonce)]; ok {
+			t.Errorf("版本 %d 与 %d 派生出了相同的随机数", v, u)
+		}
+		seen[string(nonce)] = v
+	}
+
+	// 已知答案测试：固定的密钥环与消息在各转录版本下的确定性签名编码，随机数的派生方式改变时此处会失败
+	for _, c := range []struct {
+		version TranscriptVersion
+		want    string
+	}{
+		{
+			version: TranscriptLegacy,
+			want: "010200a811d2b0c81857d81988eb7d2cdb2f47ab8e6840e50344dfbc7f488c4efe1f915b534dce560d03cba609f50e61" +
+				"30e07500000004951971d9987ffb32afb1fbf8c125791ed2f928ed930c56bc29ac80653a4c73fe17eac6c3cd23e27498" +
+				"de8639816766e7a25fd89563259fc6540589859d9b4e21d698cc27325ef32144466f8245cc256f300978c8e13b7ad472" +
+				"e7088ecbb92b55a451311b8b1fcfb8409af2c2575e8bf47c47bdf616ee548bfbcb91f15575dc8e5d49974746fac977b0" +
+				"f79882dbb1a564a967757310377235bddfb39e13f792e49d813ca4ccd35cc027f3c4b4a68e96da78bf5c60b37e85245e" +
+				"aaea6bb73677c82570d5211e18448271c411d58cb8431d9d3b8809f0345aa992cb37858c07ee186afbe6441599f0cd6d" +
+				"299721c07d99ccb29ed606cb0ff8511c386cd45d84620f85287080226fea4eed13c60dd113fa1960592e3bda452a511d" +
+				"c7323485e651571b7f48c13cdf03c4ea0ec022b42bc4323d5ab53930812618ffa4f06288aea099b0623310884bff22f7" +
+				"3eef866e7294f1",
+		},
+		{
+			version: TranscriptV1,
+			want: "01020186e62d6f22a2eb4784b19ee52a3e2878ebbb3f247b395b18a0f2a5bcef9cd200b6bdfd2b188f26b72a8577dc17" +
+				"ea972800000004870456d492314c2e8c50956c5134cd5fa456e47995a127cd3aa38a743ebbf90a4a6e1d1e0b1759d650" +
+				"a4b2568fc5fdfb88a97d9232bd67294fa8f22aac827daa6124caee2918fe6f7324c34c3015f84fe64c225530bb4ac0cf" +
+				"1a32913e672b83a31833906287ff6d9e469636fd2258c046e0aec36fa57a8688ba56ebf2de1862340583715bb2872c6d" +
+				"0b1d2b44466b17b5295b61e021234a5c441fcd2148cef65d9d7d5cc581063802a52a300e08bc64e420912835746d42ba" +
+				"3f1fe813f0157266c0fbfbffdb68bd4b0393f9a568f3fd658639ef4a6cdf4a641fec1228d0e96b44d4b27b1c777f41c4" +
+				"db4f89e0ecbe078866f4ddafd19ecd9bf2357d979e3f87b99619b4ee89ac08ac619267c35a5ee32a0186d05f0b4a27af" +
+				"ba67be268b8ca92d5c8bda93985cc07ac866b7e39879ee557ec7b6654057733848db83b36e262f20a97fe48323861cb6" +
+				"53b10944201101",
+		},
+		{
+			version: TranscriptV2,
+			want: "010202af57f543717920539b0f1c95159cb90936735a368feaa03432ded2ca86ad4ea03c2f9f29c6a499d28e65088e83" +
+				"8a03e800000004a57e00278d61c83fc28a62b875a64d676e7d49cb454efc9bb8ac13f5debb52d6c980811902d4a01b6b" +
+				"f8898bfd1b5e6aabc51400f4255bf6070d5fe5c84a9c9e1d2b20a9121a1cb748807e33021deab86832b5aad3de8dcc66" +
+				"f06a97650ad4d0b1243a15a2df5ea99690fff86330065f066cf53d28e7fcd8e3fe004934ce71cd002615c3540113645c" +
+				"2067193e0dc786a93b588a22c1ac686d66bbe4a82ad41bc9310d5a7f3de14980f461f5cabc55edc1add538c7c6655ddb" +
+				"ead1997ac25e2241ac06eb9d16d33ec54114cd2086ec98f35648acd4074ebd3a5679f49d47f5b40085f039536ffbfcfc" +
+				"e8b4119b7a5bb8041a7c3ea855041fd8d7d03b9c3e91f3afa9f86e06e1fae35b8600cd7f3de5b35a52d0ee8915de97d5" +
+				"55fed8d9788fa6bd999abb8865d356a6e3ebed69bac4697357eaceae76accb72edcc89b2288fc471fdb6890fb42a5f68" +
+				"6a816c0381cce4",
+		},
+	} {
+		sigma, err := Sign(MessageTrue, List, L[2], WithTranscript(c.version), WithDeterministic(nil))
+		if err != nil {
+			t.Fatalf("版本 %d: 签名失败: %v", c.version, err)
+		}
+		data, err := sigma.MarshalBinary()
+		if err != nil {
+			t.Fatalf("版本 %d: 编码失败: %v", c.version, err)
+		}
+		if got := hex.EncodeToString(data); got != c.want {
+			t.Errorf("版本 %d: 签名编码与已知答案不同: %s", c.version, got)
+		}
+	}
+}
+
+// 测试哈希转录编码的版本选择、域分离与旧版兼容
+func TestTranscriptVersions(t *testing.T) {
+	L, List := newRing(t, 3)
+
+	// 旧版编码的签名需要显式允许才能通过验证
+	legacy, err := Sign(MessageTrue, List, L[0], WithTranscript(TranscriptLegacy))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, legacy); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, legacy, WithLegacyTranscript()); err != nil {
+		t.Errorf("旧版签名验证失败: %v", err)
+	}
+
+	// v1 编码的签名无需额外选项即可通过验证，但改写为 v2 后不再合法
+	v1, err := Sign(MessageTrue, List, L[0], WithTranscript(TranscriptV1))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, v1); err != nil {
+		t.Errorf("v1 签名验证失败: %v", err)
+	}
+	upgraded := *v1
+	upgraded.Version = TranscriptV2
+	if Verify(MessageTrue, List, &upgraded) {
+		t.Errorf("篡改版本号的签名通过了验证")
+	}
+
+	// 篡改版本号后签名不再合法
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if sigma.Version != TranscriptV2 {
+		t.Errorf("默认转录版本为 %d，期望 %d", sigma.Version, TranscriptV2)
+	}
+	downgraded := *sigma
+	downgraded.Version = TranscriptLegacy
+	if Verify(MessageTrue, List, &downgraded, WithLegacyTranscript()) {
+		t.Errorf("篡改版本号的签名通过了验证")
+	}
+
+	// 未知版本
+	if _, err := Sign(MessageTrue, List, L[0], WithTranscript(9)); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("Sign: 期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+	unknown := *sigma
+	unknown.Version = 9
+	if err := VerifyDetailed(MessageTrue, List, &unknown); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("VerifyDetailed: 期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+
+	// v1 编码带长度前缀与域分离标签，旧版编码则会发生碰撞
+	if !CompareBigInts(HashToZq([]byte("ab"), []byte("c")), HashToZq([]byte("a"), []byte("bc"))) {
+		t.Errorf("旧版编码应当对拼接结果相同的输入给出相同哈希")
+	}
+	if CompareBigInts(HashToZqV1(TagHi, []byte("ab"), []byte("c")), HashToZqV1(TagHi, []byte("a"), []byte("bc"))) {
+		t.Errorf("v1 编码对不同输入给出了相同哈希")
+	}
+	if CompareBigInts(HashToZqV1(TagHi, MessageTrue), HashToZqV1(TagE, MessageTrue)) {
+		t.Errorf("不同标签给出了相同哈希")
+	}
+
+	// v2 与 v1 的域分离前缀不同；环摘要依赖公钥顺序
+	if CompareBigInts(HashToZqV1(TagHi, MessageTrue), HashToZqV2(TagHi, MessageTrue)) {
+		t.Errorf("v1 与 v2 编码给出了相同哈希")
+	}
+	swapped := append([]*bls.PointG1{List[1], List[0]}, List[2:]...)
+	if bytes.Equal(RingDigest(List), RingDigest(swapped)) {
+		t.Errorf("交换公钥顺序后环摘要不变")
+	}
+}
+
+// 测试签名二进制编码的往返与非法输入
+func TestMarshalBinary(t *testing.T) {
+	L, List := newRing(t, 5)
+	sigma, err := Sign(MessageTrue, List, L[3])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	data, err := sigma.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if len(data) != EncodedSize(len(List)) {
+		t.Errorf("编码长度为 %d，期望 %d", len(data), EncodedSize(len(List)))
+	}
+
+	decoded := new(Sigma)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if !sameSigma(sigma, decoded) || decoded.Version != sigma.Version {
+		t.Errorf("解码结果与原签名不一致")
+	}
+	if err := VerifyDetailed(MessageTrue, List, decoded); err != nil {
+		t.Errorf("解码后的签名验证失败: %v", err)
+	}
+	again, _ := decoded.MarshalBinary()
+	if !bytes.Equal(data, again) {
+		t.Errorf("重新编码的结果不一致")
+	}
+
+	// 非法输入
+	badPoint := append([]byte(nil), data...)
+	badPoint[headerSize+PointSize+4+PointSize-1] ^= 0x01
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"空数据", nil},
+		{"截断", data[:len(data)-1]},
+		{"多余字节", append(append([]byte(nil), data...), 0x00)},
+		{"编码版本错误", append([]byte{EncodingVersion + 1}, data[1:]...)},
+		{"方案标识错误", append([]byte{EncodingVersion, SchemeID + 1}, data[2:]...)},
+		{"转录版本未知", append([]byte{EncodingVersion, SchemeID, 9}, data[3:]...)},
+		{"点不在曲线上", badPoint},
+	}
+
+	// 标量超出 Z_q 范围：将 Pi 改写为全 0xff
+	bigScalar := append([]byte(nil), data...)
+	for i := len(bigScalar) - ScalarSize; i < len(bigScalar); i++ {
+		bigScalar[i] = 0xff
+	}
+	cases = append(cases, struct {
+		name string
+		data []byte
+	}{"标量超出范围", bigScalar})
+
+	for _, c := range cases {
+		out := new(Sigma)
+		if err := out.UnmarshalBinary(c.data); err == nil {
+			t.Errorf("%s: 非法编码被成功解码", c.name)
+		}
+		if out.UI != nil {
+			t.Errorf("%s: 解码失败时修改了签名", c.name)
+		}
+	}
+
+	if _, err := (&Sigma{}).MarshalBinary(); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+}
+
+// 测试签名、公钥与公钥环文档的 JSON 编码
+func TestJSON(t *testing.T) {
+	L, List := newRing(t, 3)
+	sigma, err := Sign(MessageTrue, List, L[1])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	// 签名：字段名固定，不依赖 Go 结构体即可读取
+	data, err := json.Marshal(sigma)
+	if err != nil {
+		t.Fatalf("编码签名失败: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("解析 JSON 失败: %v", err)
+	}
+	for _, name := range []string{"scheme", "version", "rm", "ui", "v", "c", "t", "pi"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("签名 JSON 缺少字段 %q", name)
+		}
+	}
+	if fields["scheme"] != SchemeName {
+		t.Errorf("签名 JSON 的方案为 %v，期望 %s", fields["scheme"], SchemeName)
+	}
+
+	var decoded Sigma
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("解码签名失败: %v", err)
+	}
+	if !sameSigma(sigma, &decoded) || !Verify(MessageTrue, List, &decoded) {
+		t.Errorf("解码后的签名与原签名不一致")
+	}
+
+	// 公钥：私钥不得写入 JSON
+	data, err = json.Marshal(L[0])
+	if err != nil {
+		t.Fatalf("编码公钥失败: %v", err)
+	}
+	if bytes.Contains(data, []byte(L[0].PrivateKey.Text(16))) {
+		t.Errorf("公钥 JSON 中包含私钥")
+	}
+	var pub Signer
+	if err := json.Unmarshal(data, &pub); err != nil {
+		t.Fatalf("解码公钥失败: %v", err)
+	}
+	if pub.PrivateKey != nil || !CompareG1(pub.PublicKey, L[0].PublicKey) {
+		t.Errorf("解码后的公钥不一致")
+	}
+
+	// 公钥环文档
+	ring := &Ring{Members: []RingMember{
+		{Label: "alice", PublicKey: List[0]},
+		{PublicKey: List[1]},
+		{Label: "carol", PublicKey: List[2]},
+	}}
+	data, err = json.Marshal(ring)
+	if err != nil {
+		t.Fatalf("编码公钥环失败: %v", err)
+	}
+	var decodedRing Ring
+	if err := json.Unmarshal(data, &decodedRing); err != nil {
+		t.Fatalf("解码公钥环失败: %v", err)
+	}
+	if decodedRing.Members[0].Label != "alice" || decodedRing.Members[1].Label != "" {
+		t.Errorf("公钥环标签不一致")
+	}
+	if !Verify(MessageTrue, decodedRing.PKList(), sigma) {
+		t.Errorf("使用解码后的公钥环验证失败")
+	}
+
+	// 非法输入
+	pk := hex.EncodeToString(encodePoint(List[0]))
+	cases := []struct {
+		name string
+		data string
+		into interface{}
+	}{
+		{"方案不符", `{"scheme":"other","pk":"` + pk + `"}`, new(Signer)},
+		{"未知字段", `{"scheme":"` + SchemeName + `","pk":"` + pk + `","sk":"00"}`, new(Signer)},
+		{"非十六进制", `{"scheme":"` + SchemeName + `","pk":"zz"}`, new(Signer)},
+		{"点长度错误", `{"scheme":"` + SchemeName + `","pk":"` + pk[2:] + `"}`, new(Signer)},
+		{"空环", `{"scheme":"` + SchemeName + `","members":[]}`, new(Ring)},
+		{"重复公钥", `{"scheme":"` + SchemeName + `","members":[{"pk":"` + pk + `"},{"pk":"` + pk + `"}]}`, new(Ring)},
+		{"签名缺少 ui", `{"scheme":"` + SchemeName + `","version":1}`, new(Sigma)},
+	}
+	for _, c := range cases {
+		if err := json.Unmarshal([]byte(c.data), c.into); err == nil {
+			t.Errorf("%s: 非法 JSON 被成功解码", c.name)
+		}
+	}
+}
+
+// 测试密钥的 PEM 导出与导入
+func TestPEM(t *testing.T) {
+	L, List := newRing(t, 3)
+	passphrase := []byte("口令")
+
+	data, err := L[0].MarshalPrivateKeyPEM(passphrase, WithKDFIterations(1000))
+	if err != nil {
+		t.Fatalf("导出私钥失败: %v", err)
+	}
+	signer, err := ParsePrivateKeyPEM(data, passphrase)
+	if err != nil {
+		t.Fatalf("导入私钥失败: %v", err)
+	}
+	if !CompareBigInts(signer.PrivateKey, L[0].PrivateKey) || !CompareG1(signer.PublicKey, L[0].PublicKey) {
+		t.Errorf("导入的密钥与原密钥不一致")
+	}
+	sigma, err := Sign(MessageTrue, List, signer)
+	if err != nil || !Verify(MessageTrue, List, sigma) {
+		t.Errorf("使用导入的私钥签名失败: %v", err)
+	}
+	if _, err := ParsePrivateKeyPEM(data, []byte("错误口令")); !errors.Is(err, KeyPEM.ErrDecryption) {
+		t.Errorf("期望错误 %v，实际为 %v", KeyPEM.ErrDecryption, err)
+	}
+
+	data, err = L[1].MarshalPublicKeyPEM()
+	if err != nil {
+		t.Fatalf("导出公钥失败: %v", err)
+	}
+	pk, err := ParsePublicKeyPEM(data)
+	if err != nil || !CompareG1(pk, L[1].PublicKey) {
+		t.Errorf("导入的公钥与原公钥不一致: %v", err)
+	}
+	if _, err := ParsePrivateKeyPEM(data, passphrase); !errors.Is(err, KeyPEM.ErrInvalidPEM) {
+		t.Errorf("期望错误 %v，实际为 %v", KeyPEM.ErrInvalidPEM, err)
+	}
+}
+
+// nonSubgroupG1 构造一个在曲线 y^2 = x^3 + 4 上、但不属于素数阶子群的点
+func nonSubgroupG1(t *testing.T) *bls.PointG1 {
+	g1 := getG1()
+	defer putG1(g1)
+	t.Helper()
+	p, _ := new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+	exp := new(big.Int).Rsh(new(big.Int).Add(p, big.NewInt(1)), 2)
+	for x := int64(1); ; x++ {
+		X := big.NewInt(x)
+		rhs := new(big.Int).Exp(X, big.NewInt(3), p)
+		rhs.Add(rhs, big.NewInt(4)).Mod(rhs, p)
+		Y := new(big.Int).Exp(rhs, exp, p)
+		if new(big.Int).Exp(Y, big.NewInt(2), p).Cmp(rhs) != 0 {
+			continue
+		}
+		point, err := g1.FromBytes(append(X.FillBytes(make([]byte, 48)), Y.FillBytes(make([]byte, 48))...))
+		if err != nil {
+			t.Fatalf("构造曲线点失败: %v", err)
+		}
+		if !g1.InCorrectSubgroup(point) {
+			return point
+		}
+	}
+}
+
+// 测试对无效点与无穷远点的拒绝
+func TestPointValidation(t *testing.T) {
+	g1 := getG1()
+	defer putG1(g1)
+	L, List := newRing(t, 3)
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	bad := nonSubgroupG1(t)
+
+	if err := ValidatePoint(List[0]); err != nil {
+		t.Errorf("有效公钥未通过校验: %v", err)
+	}
+	if err := ValidatePoint(bad); !errors.Is(err, ErrInvalidPoint) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrInvalidPoint, err)
+	}
+
+	// 1. 公钥环中的无穷远点与子群外的点
+	for _, c := range []struct {
+		name string
+		pk   *bls.PointG1
+		want error
+	}{
+		{"无穷远点", g1.Zero(), ErrIdentityPoint},
+		{"子群外的点", bad, ErrInvalidPoint},
+	} {
+		ring := []*bls.PointG1{List[0], List[1], c.pk}
+		if _, err := Sign(MessageTrue, ring, L[0]); !errors.Is(err, c.want) {
+			t.Errorf("签名 %s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if err := VerifyDetailed(MessageTrue, ring, sigma); !errors.Is(err, c.want) {
+			t.Errorf("验证 %s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+	}
+
+	// 2. 签名中的无穷远点与子群外的点
+	for _, c := range []struct {
+		name   string
+		mutate func(s *Sigma)
+		want   error
+	}{
+		{"R_M 为无穷远点", func(s *Sigma) { s.RM = g1.Zero() }, ErrIdentityPoint},
+		{"T 为无穷远点", func(s *Sigma) { s.T = g1.Zero() }, ErrIdentityPoint},
+		{"R_M 不在子群中", func(s *Sigma) { s.RM = bad }, ErrInvalidPoint},
+		{"U_i 不在子群中", func(s *Sigma) { s.UI = []*bls.PointG1{sigma.UI[0], bad, sigma.UI[2]} }, ErrInvalidPoint},
+	} {
+		forged := *sigma
+		c.mutate(&forged)
+		if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+	}
+
+	// 3. 解码器拒绝无穷远点
+	data, err := sigma.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	copy(data[headerSize:], encodePoint(g1.Zero()))
+	if err := new(Sigma).UnmarshalBinary(data); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("二进制解码: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+
+	data, err = json.Marshal(sigma)
+	if err != nil {
+		t.Fatalf("JSON 编码失败: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("JSON 解码失败: %v", err)
+	}
+	fields["t"] = hex.EncodeToString(encodePoint(g1.Zero()))
+	data, _ = json.Marshal(fields)
+	if err := json.Unmarshal(data, new(Sigma)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码签名: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+
+	identity := hex.EncodeToString(encodePoint(g1.Zero()))
+	data = []byte(fmt.Sprintf(`{"scheme":%q,"pk":%q}`, SchemeName, identity))
+	if err := json.Unmarshal(data, new(Signer)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码公钥: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+	data = []byte(fmt.Sprintf(`{"scheme":%q,"members":[{"label":"a","pk":%q}]}`, SchemeName, identity))
+	if err := json.Unmarshal(data, new(Ring)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码公钥环: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+
+	data = KeyPEM.EncodePublicKey(SchemeName, CurveName, encodePoint(g1.Zero()))
+	if _, err := ParsePublicKeyPEM(data); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("PEM 解码公钥: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+}
+
+// naiveSumG1 逐个标量乘后累加，作为 MultiScalarMulG1 的对照
+func naiveSumG1(points []*bls.PointG1, scalars []*big.Int) *bls.PointG1 {
+	g1 := getG1()
+	defer putG1(g1)
+	sum := g1.Zero()
+	for i, p := range points {
+		sum = AddG1(sum, ScalarMulG1(p, new(big.Int).Mod(scalars[i], Order)))
+	}
+	return sum
+}
+
+// randomMSMInput 生成 n 个随机点与随机标量
+func randomMSMInput(n int) ([]*bls.PointG1, []*big.Int) {
+	points := make([]*bls.PointG1, n)
+	scalars := make([]*big.Int, n)
+	for i := range points {
+		points[i] = RandomPointG1()
+		scalars[i] = RandomZq()
+	}
+	return points, scalars
+}
+
+// 测试多标量乘法与逐个标量乘的结果一致
+func TestMultiScalarMulG1(t *testing.T) {
+	g1 := getG1()
+	defer putG1(g1)
+	for _, n := range []int{0, 1, 5, 31, 32, 100} {
+		points, scalars := randomMSMInput(n)
+		if n >= 5 {
+			// 相同的点与标量落入同一个桶、零标量、负标量、超出 Order 的标量、无穷远点
+			points[1], scalars[1] = points[0], new(big.Int).Set(scalars[0])
+			scalars[2] = big.NewInt(0)
+			scalars[3] = big.NewInt(-5)
+			scalars[4] = new(big.Int).Add(Order, big.NewInt(7))
+			points[n-1] = g1.Zero()
+		}
+		if !CompareG1(MultiScalarMulG1(points, scalars), naiveSumG1(points, scalars)) {
+			t.Errorf("n = %d: 多标量乘法结果与逐个计算不一致", n)
+		}
+	}
+}
+
+// 对比逐个标量乘与多标量乘法在不同环大小下的耗时
+func BenchmarkMultiScalarMulG1(b *testing.B) {
+	for _, n := range []int{16, 256, 4096} {
+		points, scalars := randomMSMInput(n)
+		b.Run(fmt.Sprintf("naive/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				naiveSumG1(points, scalars)
+			}
+		})
+		b.Run(fmt.Sprintf("msm/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiScalarMulG1(points, scalars)
+			}
+		})
+	}
+}
+
+// batchInput 在两个公钥环上生成 n 个签名，前一半共用第一个环，后一半共用第二个环
+func batchInput(t testing.TB, ringSize, n int) ([][]byte, [][]*bls.PointG1, []*Sigma) {
+	var Messages [][]byte
+	var PKLists [][]*bls.PointG1
+	var Sigmas []*Sigma
+	for r := 0; r < 2; r++ {
+		L := make([]*Signer, ringSize)
+		ring := make([]*bls.PointG1, ringSize)
+		for i := range L {
+			L[i], _ = NewSigner()
+			ring[i] = L[i].PublicKey
+		}
+		for j := 0; j < n/2; j++ {
+			msg := []byte(fmt.Sprintf("批量消息 %d-%d", r, j))
+			sigma, err := Sign(msg, ring, L[j%ringSize])
+			if err != nil {
+				t.Fatalf("签名失败: %v", err)
+			}
+			Messages = append(Messages, msg)
+			PKLists = append(PKLists, ring)
+			Sigmas = append(Sigmas, sigma)
+		}
+	}
+	return Messages, PKLists, Sigmas
+}
+
+// 测试批量验证的逐签名结果与单独验证一致
+func TestVerifyBatch(t *testing.T) {
+	Messages, PKLists, Sigmas := batchInput(t, 4, 8)
+
+	results, err := VerifyBatch(Messages, PKLists, Sigmas)
+	if err != nil {
+		t.Fatalf("批量验证出错: %v", err)
+	}
+	for j, r := range results {
+		if r != nil {
+			t.Errorf("签名 %d 验证失败: %v", j, r)
+		}
+	}
+
+	// 篡改消息、篡改挑战值、签名缺失、公钥环为空
+	Messages[1] = MessageFalse
+	forged := *Sigmas[2]
+	forged.C = AddZq(forged.C, big.NewInt(1))
+	Sigmas[2] = &forged
+	Sigmas[5] = nil
+	PKLists[6] = nil
+	want := map[int]error{1: ErrChallengeMismatch, 2: ErrChallengeMismatch, 5: ErrMalformedSignature, 6: ErrEmptyRing}
+	// 结果与 WithWorkers 指定的协程数无关
+	for _, workers := range []int{1, 3, 0} {
+		results, err = VerifyBatch(Messages, PKLists, Sigmas, WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("批量验证出错: %v", err)
+		}
+		for j, r := range results {
+			if !errors.Is(r, want[j]) {
+				t.Errorf("workers = %d: 签名 %d: 期望错误 %v，实际为 %v", workers, j, want[j], r)
+			}
+			if single := VerifyDetailed(Messages[j], PKLists[j], Sigmas[j]); fmt.Sprint(single) != fmt.Sprint(r) {
+				t.Errorf("workers = %d: 签名 %d: 批量结果 %v 与单独验证结果 %v 不一致", workers, j, r, single)
+			}
+		}
+	}
+
+	if _, err := VerifyBatch(Messages[:2], PKLists, Sigmas); !errors.Is(err, ErrBatchLengthMismatch) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrBatchLengthMismatch, err)
+	}
+}
+
+// 对比循环调用 Verify 与 VerifyBatch 的每核吞吐量
+func BenchmarkVerifyBatch(b *testing.B) {
+	Messages, PKLists, Sigmas := batchInput(b, 16, 32)
+	report := func(b *testing.B) {
+		perCore := float64(b.N*len(Sigmas)) / b.Elapsed().Seconds() / float64(runtime.GOMAXPROCS(0))
+		b.ReportMetric(perCore, "sig/s/core")
+	}
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range Sigmas {
+				Verify(Messages[j], PKLists[j], Sigmas[j])
+			}
+		}
+		report(b)
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			VerifyBatch(Messages, PKLists, Sigmas, WithWorkers(0))
+		}
+		report(b)
+	})
+}
+
+// 测试 RingContext 的构造与签名者查找，以及带预计算表时与 Sign/Verify 的互通
+func TestRingContext(t *testing.T) {
+	L, List := newRing(t, 5)
+	others, _ := newRing(t, 1)
+
+	if _, err := NewRingContext(nil); !errors.Is(err, ErrEmptyRing) {
+		t.Errorf("空环: 期望错误 %v，实际为 %v", ErrEmptyRing, err)
+	}
+	if _, err := NewRingContext(append(List, List[0])); !errors.Is(err, ErrDuplicatePublicKey) {
+		t.Errorf("重复公钥: 期望错误 %v，实际为 %v", ErrDuplicatePublicKey, err)
+	}
+
+	for _, precompute := range []bool{false, true} {
+		var opts []Option
+		if precompute {
+			opts = append(opts, WithPrecompute())
+		}
+		rc, err := NewRingContext(List, opts...)
+		if err != nil {
+			t.Fatalf("构造 RingContext 失败: %v", err)
+		}
+		if rc.Len() != len(List) || rc.Precomputed() != precompute {
+			t.Errorf("环大小为 %d，预计算状态为 %v", rc.Len(), rc.Precomputed())
+		}
+		if !bytes.Equal(rc.Digest(), RingDigest(List)) {
+			t.Errorf("环摘要与 RingDigest 不一致")
+		}
+		for i, pk := range List {
+			if j, ok := rc.Index(pk); !ok || j != i {
+				t.Errorf("公钥 %d 的下标为 %d", i, j)
+			}
+		}
+		if _, err := SignWithRing(MessageTrue, rc, others[0]); !errors.Is(err, ErrSignerNotInRing) {
+			t.Errorf("期望错误 %v，实际为 %v", ErrSignerNotInRing, err)
+		}
+
+		// 使用 RingContext 的签名与普通签名可以互相验证
+		sigma, err := SignWithRing(MessageTrue, rc, L[2])
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		if err := VerifyDetailed(MessageTrue, List, sigma); err != nil {
+			t.Errorf("SignWithRing 的签名验证失败: %v", err)
+		}
+		plain, err := Sign(MessageTrue, List, L[4], WithTranscript(TranscriptV1))
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		if err := VerifyDetailedWithRing(MessageTrue, rc, plain); err != nil {
+			t.Errorf("VerifyDetailedWithRing 验证失败: %v", err)
+		}
+		if VerifyWithRing(MessageFalse, rc, plain) {
+			t.Errorf("错误消息通过了验证")
+		}
+
+		// 环求和与 ComputeSum 一致
+		HiList := make([]*big.Int, len(List))
+		UiList := make([]*bls.PointG1, len(List))
+		for i := range List {
+			HiList[i] = RandomZq()
+			UiList[i] = RandomPointG1()
+		}
+		for _, skip := range []int{-1, 3} {
+			if !CompareG1(rc.computeSum(HiList, UiList, skip), ComputeSum(HiList, List, UiList, skip)) {
+				t.Errorf("预计算状态为 %v 时环求和结果不一致，排除下标 %d", precompute, skip)
+			}
+		}
+	}
+}
+
+// 比较 Verify 与复用 RingContext（含预计算表）的验证开销
+func BenchmarkVerifyWithRing(b *testing.B) {
+	L := make([]*Signer, 256)
+	List := make([]*bls.PointG1, len(L))
+	for i := range L {
+		L[i], _ = NewSigner()
+		List[i] = L[i].PublicKey
+	}
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		b.Fatalf("签名失败: %v", err)
+	}
+
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Verify(MessageTrue, List, sigma)
+		}
+	})
+	for _, precompute := range []bool{false, true} {
+		var opts []Option
+		if precompute {
+			opts = append(opts, WithPrecompute())
+		}
+		rc, _ := NewRingContext(List, opts...)
+		b.Run(fmt.Sprintf("RingContext/precompute=%v", precompute), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				VerifyWithRing(MessageTrue, rc, sigma)
+			}
+		})
+	}
+}
+
+// 多个协程共享同一个公钥环、签名与 RingContext 并发签名、验证与编码，配合 go test -race 检查数据竞争
+func TestConcurrentSignVerify(t *testing.T) {
+	L, List := newRing(t, 4)
+	shared, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	rc, err := NewRingContext(List, WithPrecompute())
+	if err != nil {
+		t.Fatalf("构造 RingContext 失败: %v", err)
+	}
+
+	workers, rounds := 8, 3
+	errs := make(chan error, workers*rounds)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				msg := []byte(fmt.Sprintf("并发消息 %d-%d", w, r))
+				sigma, err := SignWithRing(msg, rc, L[(w+r)%len(L)])
+				if err != nil {
+					errs <- fmt.Errorf("协程 %d 签名失败: %w", w, err)
+					return
+				}
+				if err := VerifyDetailed(msg, List, sigma); err != nil {
+					errs <- fmt.Errorf("协程 %d 验证失败: %w", w, err)
+				}
+				if err := VerifyDetailedWithRing(MessageTrue, rc, shared); err != nil {
+					errs <- fmt.Errorf("协程 %d 验证共享签名失败: %w", w, err)
+				}
+				if _, err := shared.MarshalBinary(); err != nil {
+					errs <- fmt.Errorf("协程 %d 编码失败: %w", w, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// 测试不同协程数下 SignContext 与 VerifyContext 的结果一致，以及 ctx 取消后的返回值
+func TestSignContext(t *testing.T) {
+	L, List := newRing(t, 9)
+
+	// 协程数不影响确定性签名的结果
+	want, err := SignContext(context.Background(), MessageTrue, List, L[4], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	for _, workers := range []int{2, 4, 16, 0} {
+		for _, precompute := range []bool{false, true} {
+			opts := []Option{WithDeterministic(nil), WithWorkers(workers)}
+			if precompute {
+				opts = append(opts, WithPrecompute())
+			}
+			rc, err := NewRingContext(List, opts...)
+			if err != nil {
+				t.Fatalf("构造 RingContext 失败: %v", err)
+			}
+			sigma, err := SignWithRing(MessageTrue, rc, L[4], opts...)
+			if err != nil {
+				t.Fatalf("workers=%d: 签名失败: %v", workers, err)
+			}
+			if !sameSigma(want, sigma) {
+				t.Errorf("workers=%d, precompute=%v: 签名与单协程结果不同", workers, precompute)
+			}
+			if err := VerifyContext(context.Background(), MessageTrue, List, sigma, WithWorkers(workers)); err != nil {
+				t.Errorf("workers=%d: 验证失败: %v", workers, err)
+			}
+			if err := VerifyContext(context.Background(), MessageFalse, List, sigma, WithWorkers(workers)); err == nil {
+				t.Errorf("workers=%d: 错误消息验证通过", workers)
+			}
+		}
+	}
+
+	// 已取消的 ctx 直接返回 ctx.Err()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, workers := range []int{1, 4} {
+		if _, err := SignContext(ctx, MessageTrue, List, L[4], WithWorkers(workers)); !errors.Is(err, context.Canceled) {
+			t.Errorf("workers=%d: 签名期望错误 %v，实际为 %v", workers, context.Canceled, err)
+		}
+		if err := VerifyContext(ctx, MessageTrue, List, want, WithWorkers(workers)); !errors.Is(err, context.Canceled) {
+			t.Errorf("workers=%d: 验证期望错误 %v，实际为 %v", workers, context.Canceled, err)
+		}
+	}
+}
+
+// newLargeRing 以 pk_i = (i+1)·P 快速构造大小为 n 的环，返回下标为 index 的签名者，仅用于测试取消
+func newLargeRing(n, index int) (*Signer, []*bls.PointG1) {
+	P := baseMulG1(big.NewInt(1))
+	List := make([]*bls.PointG1, n)
+	List[0] = P
+	for i := 1; i < n; i++ {
+		List[i] = AddG1(List[i-1], P)
+	}
+	return &Signer{PrivateKey: big.NewInt(int64(index + 1)), PublicKey: List[index]}, List
+}
+
+// 测试签名与验证进行中 ctx 超时：应返回 context.DeadlineExceeded，且远早于未取消时完成
+func TestContextCancelMidway(t *testing.T) {
+	if testing.Short() {
+		t.Skip("大环测试耗时较长")
+	}
+	signer, List := newLargeRing(4096, 100)
+
+	// 签名逐个生成 U_i，每个成员处理前检查 ctx
+	for _, workers := range []int{1, 4} {
+		start := time.Now()
+		if _, err := SignContext(context.Background(), MessageTrue, List[:2048], signer, WithWorkers(workers)); err != nil {
+			t.Fatalf("workers=%d: 签名失败: %v", workers, err)
+		}
+		full := time.Since(start)
+
+		ctx, cancel := context.WithTimeout(context.Background(), full/20)
+		start = time.Now()
+		_, err := SignContext(ctx, MessageTrue, List[:2048], signer, WithWorkers(workers))
+		elapsed := time.Since(start)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("workers=%d: 签名期望错误 %v，实际为 %v", workers, context.DeadlineExceeded, err)
+		}
+		if elapsed > full/2 {
+			t.Errorf("workers=%d: 取消后签名耗时 %v，未取消时为 %v", workers, elapsed, full)
+		}
+	}
+
+	// 验证的环求和按 sumChunk 分段，段与段之间检查 ctx；大环签名的 U_i 直接取环中的点，
+	// 结构合法但挑战值不符，未取消时完整计算后返回 ErrChallengeMismatch
+	small, err := Sign(MessageTrue, List[100:102], signer)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma := *small
+	sigma.UI = List
+	start := time.Now()
+	if err := VerifyContext(context.Background(), MessageTrue, List, &sigma); !errors.Is(err, ErrChallengeMismatch) {
+		t.Fatalf("期望错误 %v，实际为 %v", ErrChallengeMismatch, err)
+	}
+	full := time.Since(start)
+
+	ctx, cancel := context.WithTimeout(context.Background(), full/20)
+	defer cancel()
+	start = time.Now()
+	err = VerifyContext(ctx, MessageTrue, List, &sigma)
+	elapsed := time.Since(start)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("验证期望错误 %v，实际为 %v", context.DeadlineExceeded, err)
+	}
+	if elapsed > full/2 {
+		t.Errorf("取消后验证耗时 %v，未取消时为 %v", elapsed, full)
+	}
+}
+
+// benchRingSizes 基准测试中扫描的环大小
+var benchRingSizes = []int{2, 8, 32, 128}
+
+// 不同环大小下的签名开销，sig-bytes 为签名编码后的字节数
+func BenchmarkSign(b *testing.B) {
+	for _, n := range benchRingSizes {
+		L, List := newRing(b, n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Sign(MessageTrue, List, L[i%n]); err != nil {
+					b.Fatalf("签名失败: %v", err)
+				}
+			}
+			b.ReportMetric(float64(EncodedSize(n)), "sig-bytes")
+		})
+	}
+}
+
+// 不同环大小下的验证开销
+func BenchmarkVerify(b *testing.B) {
+	for _, n := range benchRingSizes {
+		L, List := newRing(b, n)
+		sigma, err := Sign(MessageTrue, List, L[0])
+		if err != nil {
+			b.Fatalf("签名失败: %v", err)
+		}
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := VerifyDetailed(MessageTrue, List, sigma); err != nil {
+					b.Fatalf("验证失败: %v", err)
+				}
+			}
+			b.ReportMetric(float64(EncodedSize(n)), "sig-bytes")
+		})
+	}
+}
+
+// 测试预计算条目与预计算池：结果与直接签名一致、条目只能使用一次且使用后被清零
+func TestPool(t *testing.T) {
+	L, List := newRing(t, 6)
+	rc, err := NewRingContext(List)
+	if err != nil {
+		t.Fatalf("构造 RingContext 失败: %v", err)
+	}
+
+	// 离线与在线阶段依次读取同一随机数来源时，签名与直接签名完全相同
+	r := mrand.New(mrand.NewSource(3))
+	entry, err := NewPoolEntry(len(List)-1, WithRandom(r))
+	if err != nil {
+		t.Fatalf("预计算失败: %v", err)
+	}
+	rM := entry.rM
+	got, err := SignOnline(context.Background(), MessageTrue, rc, L[2], entry, WithRandom(r))
+	if err != nil {
+		t.Fatalf("在线签名失败: %v", err)
+	}
+	want, err := Sign(MessageTrue, List, L[2], WithRandom(mrand.New(mrand.NewSource(3))))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !sameSigma(got, want) {
+		t.Errorf("在线签名与直接签名的结果不同")
+	}
+	if err := VerifyDetailed(MessageTrue, List, got); err != nil {
+		t.Errorf("在线签名验证失败: %v", err)
+	}
+	if rM.Sign() != 0 || entry.rM != nil || entry.ks != nil {
+		t.Errorf("使用后的条目未被清零")
+	}
+	if _, err := SignOnline(context.Background(), MessageTrue, rc, L[2], entry); !errors.Is(err, ErrPoolEntryUsed) {
+		t.Errorf("重复使用: 期望错误 %v，实际为 %v", ErrPoolEntryUsed, err)
+	}
+	if _, err := SignOnline(context.Background(), MessageTrue, rc, L[2], nil); !errors.Is(err, ErrPoolEntryUsed) {
+		t.Errorf("nil 条目: 期望错误 %v，实际为 %v", ErrPoolEntryUsed, err)
+	}
+
+	// 诱饵个数少于或多于所需时仍能得到合法签名
+	for _, decoys := range []int{0, 2, len(List) + 3} {
+		entry, err := NewPoolEntry(decoys, WithWorkers(2))
+		if err != nil {
+			t.Fatalf("预计算失败: %v", err)
+		}
+		sigma, err := SignOnline(context.Background(), MessageTrue, rc, L[0], entry)
+		if err != nil {
+			t.Fatalf("decoys=%d: 在线签名失败: %v", decoys, err)
+		}
+		if err := VerifyDetailed(MessageTrue, List, sigma); err != nil {
+			t.Errorf("decoys=%d: 验证失败: %v", decoys, err)
+		}
+	}
+
+	// 确定性签名与非法参数
+	if _, err := NewPool(4, 1, WithDeterministic(nil)); !errors.Is(err, ErrPoolDeterministic) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrPoolDeterministic, err)
+	}
+	entry, _ = NewPoolEntry(len(List) - 1)
+	if _, err := SignOnline(context.Background(), MessageTrue, rc, L[0], entry, WithDeterministic(nil)); !errors.Is(err, ErrPoolDeterministic) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrPoolDeterministic, err)
+	}
+	if _, err := NewPool(0, 1); !errors.Is(err, ErrInvalidPoolSize) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrInvalidPoolSize, err)
+	}
+
+	// 多个协程同时从池中取条目签名
+	pool, err := NewPool(4, len(List)-1)
+	if err != nil {
+		t.Fatalf("创建预计算池失败: %v", err)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, 12)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sigma, err := pool.Sign(context.Background(), MessageTrue, rc, L[i%len(L)])
+			if err == nil {
+				err = VerifyDetailed(MessageTrue, List, sigma)
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("签名 %d: %v", i, err)
+		}
+	}
+	pool.Close()
+	pool.Close()
+	if _, err := pool.Get(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrPoolClosed, err)
+	}
+
+	// 后台协程读取随机数失败时，Get 返回该错误
+	broken, err := NewPool(1, 1, WithRandom(iotest.ErrReader(errors.New("熵源不可用"))))
+	if err != nil {
+		t.Fatalf("创建预计算池失败: %v", err)
+	}
+	defer broken.Close()
+	if _, err := broken.Get(context.Background()); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+}
+
+// 对比直接签名与从预计算池取条目的在线签名延迟
+func BenchmarkSignOnline(b *testing.B) {
+	L, List := newRing(b, 32)
+	rc, _ := NewRingContext(List)
+	b.Run("Sign", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			SignWithRing(MessageTrue, rc, L[0])
+		}
+	})
+	b.Run("SignOnline", func(b *testing.B) {
+		entries := make([]*PoolEntry, b.N)
+		for i := range entries {
+			entries[i], _ = NewPoolEntry(len(List) - 1)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			SignOnline(context.Background(), MessageTrue, rc, L[0], entries[i])
+		}
+	})
+}
+
+// 测试可链接模式：同一私钥的签名可被链接，密钥镜像与环证明须一致，编码往返后仍可验证
+func TestLinkable(t *testing.T) {
+	L, List := newRing(t, 5)
+
+	sig1, err := Sign(MessageTrue, List, L[1], WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sig2, err := Sign(MessageFalse, List, L[1], WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sig3, err := Sign(MessageTrue, List, L[3], WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	plain, err := Sign(MessageTrue, List, L[1])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	for _, c := range []struct {
+		msg   []byte
+		sigma *Sigma
+	}{{MessageTrue, sig1}, {MessageFalse, sig2}, {MessageTrue, sig3}} {
+		if err := VerifyDetailed(c.msg, List, c.sigma, WithLinkable()); err != nil {
+			t.Errorf("可链接签名验证失败: %v", err)
+		}
+	}
+	if !Link(sig1, sig2) {
+		t.Errorf("同一签名者的两个签名未被链接")
+	}
+	if Link(sig1, sig3) || Link(sig1, plain) || Link(nil, sig1) {
+		t.Errorf("不同签名者或非可链接签名被链接")
+	}
+	if I, err := KeyImage(L[1]); err != nil || !CompareG1(I, sig1.KeyImage) {
+		t.Errorf("KeyImage 与签名中的密钥镜像不同: %v", err)
+	}
+
+	// 普通验证接受可链接签名，可链接验证拒绝普通签名
+	if err := VerifyDetailed(MessageTrue, List, plain); err != nil {
+		t.Errorf("普通签名验证失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, plain, WithLinkable()); !errors.Is(err, ErrNotLinkable) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNotLinkable, err)
+	}
+	if _, err := Sign(MessageTrue, List, L[1], WithLinkable(), WithTranscript(TranscriptV1)); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+
+	// 替换密钥镜像或篡改环证明后验证失败
+	forged := *sig1
+	forged.KeyImage = sig3.KeyImage
+	if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, ErrLinkMismatch) {
+		t.Errorf("替换密钥镜像: 期望错误 %v，实际为 %v", ErrLinkMismatch, err)
+	}
+	forged = *sig1
+	forged.LR = append([]*big.Int{AddZq(sig1.LR[0], big.NewInt(1))}, sig1.LR[1:]...)
+	if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, ErrLinkMismatch) {
+		t.Errorf("篡改环证明: 期望错误 %v，实际为 %v", ErrLinkMismatch, err)
+	}
+	forged = *sig1
+	forged.LR = sig1.LR[1:]
+	if err := CheckSigma(&forged); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("环证明长度错误: 期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+	forged = *plain
+	forged.LC = sig1.LC
+	if err := CheckSigma(&forged); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("缺少密钥镜像: 期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+
+	// 二进制与 JSON 编码往返
+	data, err := sig1.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if len(data) != LinkableEncodedSize(len(List)) {
+		t.Errorf("编码长度为 %d，期望 %d", len(data), LinkableEncodedSize(len(List)))
+	}
+	var decoded Sigma
+	if err := decoded.UnmarshalBinary(data); err != nil || !sameSigma(&decoded, sig1) {
+		t.Errorf("二进制解码结果与原签名不同: %v", err)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("截断的编码: 期望错误 %v，实际为 %v", ErrInvalidEncoding, err)
+	}
+	js, err := json.Marshal(sig1)
+	if err != nil {
+		t.Fatalf("JSON 编码失败: %v", err)
+	}
+	decoded = Sigma{}
+	if err := json.Unmarshal(js, &decoded); err != nil || !sameSigma(&decoded, sig1) {
+		t.Errorf("JSON 解码结果与原签名不同: %v", err)
+	}
+	if js, _ := json.Marshal(plain); bytes.Contains(js, []byte("key_image")) {
+		t.Errorf("普通签名的 JSON 中出现了 key_image")
+	}
+
+	// 批量验证与单成员环
+	results, err := VerifyBatch([][]byte{MessageTrue, MessageTrue}, [][]*bls.PointG1{List, List}, []*Sigma{sig1, plain}, WithLinkable())
+	if err != nil || results[0] != nil || !errors.Is(results[1], ErrNotLinkable) {
+		t.Errorf("批量验证结果为 %v, %v", results, err)
+	}
+	single := []*bls.PointG1{L[0].PublicKey}
+	sigma, err := Sign(MessageTrue, single, L[0], WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, single, sigma); err != nil {
+		t.Errorf("单成员环验证失败: %v", err)
+	}
+}
+
+// 测试可追踪模式：管理者打开签名得到签名者下标，任何人都可以验证打开结果
+func TestTracing(t *testing.T) {
+	L, List := newRing(t, 5)
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("生成管理者密钥失败: %v", err)
+	}
+	other, err := NewManager()
+	if err != nil {
+		t.Fatalf("生成管理者密钥失败: %v", err)
+	}
+
+	sig, err := Sign(MessageTrue, List, L[2], WithTracing(m.PublicKey))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	both, err := Sign(MessageTrue, List, L[4], WithTracing(m.PublicKey), WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	plain, err := Sign(MessageTrue, List, L[2])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	for _, sigma := range []*Sigma{sig, both} {
+		if err := VerifyDetailed(MessageTrue, List, sigma, WithTracing(m.PublicKey)); err != nil {
+			t.Errorf("可追踪签名验证失败: %v", err)
+		}
+	}
+
+	// 确定性模式下，同一消息为不同管理者签名时使用不同的随机数
+	det1, err := Sign(MessageTrue, List, L[2], WithTracing(m.PublicKey), WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	det2, err := Sign(MessageTrue, List, L[2], WithTracing(other.PublicKey), WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, det2, WithTracing(other.PublicKey)); err != nil {
+		t.Errorf("确定性可追踪签名验证失败: %v", err)
+	}
+	if CompareG1(det1.TE1, det2.TE1) {
+		t.Errorf("不同管理者的确定性签名复用了随机数")
+	}
+
+	// 批量验证只校验一次管理者公钥，非法时整批返回错误
+	if _, err := VerifyBatch([][]byte{MessageTrue}, [][]*bls.PointG1{List}, []*Sigma{sig}, WithTracing(bls.NewG1().Zero())); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("非法管理者公钥: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, both, WithTracing(m.PublicKey), WithLinkable()); err != nil {
+		t.Errorf("可链接且可追踪的签名验证失败: %v", err)
+	}
+
+	// 打开签名并验证打开结果
+	for _, c := range []struct {
+		sigma *Sigma
+		index int
+	}{{sig, 2}, {both, 4}} {
+		proof, err := Open(m, c.sigma, List)
+		if err != nil {
+			t.Fatalf("打开签名失败: %v", err)
+		}
+		if proof.Index != c.index {
+			t.Errorf("打开得到的下标为 %d，期望 %d", proof.Index, c.index)
+		}
+		if err := VerifyOpen(m.PublicKey, c.sigma, List, proof); err != nil {
+			t.Errorf("打开证明验证失败: %v", err)
+		}
+
+		// 声称其他成员、换用其他管理者公钥或篡改响应后验证失败
+		forged := *proof
+		forged.Index = (c.index + 1) % len(List)
+		if err := VerifyOpen(m.PublicKey, c.sigma, List, &forged); !errors.Is(err, ErrInvalidOpenProof) {
+			t.Errorf("冒名的打开结果: 期望错误 %v，实际为 %v", ErrInvalidOpenProof, err)
+		}
+		if err := VerifyOpen(other.PublicKey, c.sigma, List, proof); !errors.Is(err, ErrInvalidOpenProof) {
+			t.Errorf("其他管理者公钥: 期望错误 %v，实际为 %v", ErrInvalidOpenProof, err)
+		}
+		forged = *proof
+		forged.Z = AddZq(proof.Z, big.NewInt(1))
+		if err := VerifyOpen(m.PublicKey, c.sigma, List, &forged); !errors.Is(err, ErrInvalidOpenProof) {
+			t.Errorf("篡改响应: 期望错误 %v，实际为 %v", ErrInvalidOpenProof, err)
+		}
+	}
+
+	// 其他管理者无法打开，普通签名无法打开
+	if _, err := Open(other, sig, List); !errors.Is(err, ErrOpenFailed) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrOpenFailed, err)
+	}
+	if _, err := Open(m, plain, List); !errors.Is(err, ErrNotTraceable) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNotTraceable, err)
+	}
+	if _, err := Open(nil, sig, List); !errors.Is(err, ErrNilManager) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNilManager, err)
+	}
+
+	// 验证时的管理者公钥须与签名一致，且可追踪验证拒绝普通签名
+	if err := VerifyDetailed(MessageTrue, List, sig, WithTracing(other.PublicKey)); !errors.Is(err, ErrTraceMismatch) {
+		t.Errorf("其他管理者公钥: 期望错误 %v，实际为 %v", ErrTraceMismatch, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, sig); !errors.Is(err, ErrTracingKeyRequired) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrTracingKeyRequired, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, plain, WithTracing(m.PublicKey)); !errors.Is(err, ErrNotTraceable) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNotTraceable, err)
+	}
+	if _, err := Sign(MessageTrue, List, L[1], WithTracing(m.PublicKey), WithTranscript(TranscriptV1)); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+
+	// 把密文换成另一个成员公钥的加密后验证失败：签名者无法冒充他人
+	forged := *sig
+	rho := big.NewInt(7)
+	forged.TE1 = baseMulG1(rho)
+	forged.TE2 = AddG1(List[0], ScalarMulG1(m.PublicKey, rho))
+	if err := VerifyDetailed(MessageTrue, List, &forged, WithTracing(m.PublicKey)); !errors.Is(err, ErrTraceMismatch) {
+		t.Errorf("替换密文: 期望错误 %v，实际为 %v", ErrTraceMismatch, err)
+	}
+	forged = *sig
+	forged.TY = sig.TY[1:]
+	if err := CheckSigma(&forged); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("环证明长度错误: 期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+
+	// 二进制与 JSON 编码往返
+	for _, c := range []struct {
+		sigma *Sigma
+		size  int
+	}{
+		{sig, TraceableEncodedSize(len(List))},
+		{both, TraceableEncodedSize(len(List)) + LinkableEncodedSize(len(List)) - EncodedSize(len(List))},
+	} {
+		data, err := c.sigma.MarshalBinary()
+		if err != nil {
+			t.Fatalf("编码失败: %v", err)
+		}
+		if len(data) != c.size {
+			t.Errorf("编码长度为 %d，期望 %d", len(data), c.size)
+		}
+		var decoded Sigma
+		if err := decoded.UnmarshalBinary(data); err != nil || !sameSigma(&decoded, c.sigma) {
+			t.Errorf("二进制解码结果与原签名不同: %v", err)
+		}
+		js, err := json.Marshal(c.sigma)
+		if err != nil {
+			t.Fatalf("JSON 编码失败: %v", err)
+		}
+		decoded = Sigma{}
+		if err := json.Unmarshal(js, &decoded); err != nil || !sameSigma(&decoded, c.sigma) {
+			t.Errorf("JSON 解码结果与原签名不同: %v", err)
+		}
+	}
+
+	// 批量验证与单成员环
+	results, err := VerifyBatch([][]byte{MessageTrue, MessageTrue, MessageTrue}, [][]*bls.PointG1{List, List, List},
+		[]*Sigma{sig, both, plain}, WithTracing(m.PublicKey))
+	if err != nil || results[0] != nil || results[1] != nil || !errors.Is(results[2], ErrNotTraceable) {
+		t.Errorf("批量验证结果为 %v, %v", results, err)
+	}
+	single := []*bls.PointG1{L[0].PublicKey}
+	sigma, err := Sign(MessageTrue, single, L[0], WithTracing(m.PublicKey))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, single, sigma, WithTracing(m.PublicKey)); err != nil {
+		t.Errorf("单成员环验证失败: %v", err)
+	}
+	if proof, err := Open(m, sigma, single); err != nil || proof.Index != 0 {
+		t.Errorf("单成员环打开失败: %v", err)
+	}
+}
@@ -1,22 +1,93 @@
 package BRFL
 
 import (
+	"BRFL/KeyPEM"
 	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"runtime"
+	"sync"
 
 	bls "github.com/kilic/bls12-381"
 )
 
 // -------------------- 全局参数 --------------------
 var (
-	// G1 group instance and group order
-	g1    = bls.NewG1()
-	Order = g1.Q()
+	// g1Pool 复用 G1 实例：kilic 的 G1 带有内部临时变量，不能在多个协程间共享，每次调用各自从池中取出
+	g1Pool = sync.Pool{New: func() interface{} { return bls.NewG1() }}
+	// Order 群阶
+	Order = bls.NewG1().Q()
 )
 
+// getG1 从池中取出一个 G1 实例，用完后须通过 putG1 归还
+func getG1() *bls.G1 {
+	return g1Pool.Get().(*bls.G1)
+}
+
+// putG1 把 G1 实例归还到池中
+func putG1(g *bls.G1) {
+	g1Pool.Put(g)
+}
+
+// marshalG1 返回 p 的非压缩编码
+// ToBytes 会把参数原地转换为仿射坐标，这里对副本编码，使多个协程共享的公钥与签名中的点只被读取
+func marshalG1(p *bls.PointG1) []byte {
+	g1 := getG1()
+	defer putG1(g1)
+	return g1.ToBytes(new(bls.PointG1).Set(p))
+}
+
+// NonceDomain 确定性签名模式下 HMAC-DRBG 的个性化字符串
+const NonceDomain = "BRFL/BLS12-381/nonce/v1"
+
+// HashDomain v1 转录编码的域分离前缀，与具体哈希用途的标签拼接后作为完整的域标签
+const HashDomain = "BRFL/BLS12-381/hash/v1/"
+
+// HashDomainV2 v2 转录编码的域分离前缀
+const HashDomainV2 = "BRFL/BLS12-381/hash/v2/"
+
+// 各哈希用途的域分离标签
+const (
+	// TagHi 计算 H_i 时使用的标签
+	TagHi = "H_i"
+	// TagE 计算 e 时使用的标签
+	TagE = "e"
+	// TagC 计算 C 与 C_s 时使用的标签
+	// 由于 C_s·R_M + S_s·P = R_s，C 正是对 C_s 的重新计算，两者必须使用同一个哈希
+	TagC = "C"
+	// TagHp 可链接模式下把公钥哈希到 G1 时使用的标签
+	TagHp = "H_p"
+	// TagLink 可链接模式下计算密钥镜像环证明挑战值时使用的标签
+	TagLink = "link"
+	// TagTrace 可追踪模式下计算密文环证明挑战值时使用的标签
+	TagTrace = "trace"
+	// TagOpen 计算打开证明挑战值时使用的标签
+	TagOpen = "open"
+)
+
+// TranscriptVersion 哈希转录编码的版本
+type TranscriptVersion uint8
+
+const (
+	// TranscriptLegacy 旧版编码：直接拼接原始字节，仅用于兼容已有签名
+	TranscriptLegacy TranscriptVersion = 0
+	// TranscriptV1 带域分离标签、类型与长度前缀、定长标量的编码
+	TranscriptV1 TranscriptVersion = 1
+	// TranscriptV2 与 v1 编码规则相同，但先把消息与公钥环压缩为定长的上下文摘要，使每个 H_i 的计算与环大小无关
+	TranscriptV2 TranscriptVersion = 2
+)
+
+// Supported 判断当前实现是否支持该转录编码版本
+func (v TranscriptVersion) Supported() bool {
+	return v == TranscriptLegacy || v == TranscriptV1 || v == TranscriptV2
+}
+
 // Sigma 签名结果结构体
 type Sigma struct {
 	RM *bls.PointG1
@@ -25,6 +96,20 @@ type Sigma struct {
 	C  *big.Int
 	T  *bls.PointG1
 	Pi *big.Int
+	// Version 计算签名时使用的哈希转录编码版本
+	Version TranscriptVersion
+
+	// KeyImage 可链接模式下的密钥镜像 I = sk \cdot H_p(pk)，同一私钥的签名具有相同的密钥镜像；非可链接签名为 nil
+	KeyImage *bls.PointG1
+	// LC、LR 证明 KeyImage 与环一致的 LSAG 证明：起始挑战值 c_0 与各成员的响应 r_i
+	LC *big.Int
+	LR []*big.Int
+
+	// TE1、TE2 可追踪模式下签名者公钥在管理者公钥下的 ElGamal 密文 (ρ \cdot P, pk_s + ρ \cdot mpk)；非可追踪签名为 nil
+	TE1, TE2 *bls.PointG1
+	// TC、TZ、TY 证明密文中是签名者自身公钥的环证明：起始挑战值 c_0 与各成员的响应 z_i、y_i
+	TC     *big.Int
+	TZ, TY []*big.Int
 }
 
 // Signer 签名者结构体
@@ -33,6 +118,180 @@ type Signer struct {
 	PublicKey  *bls.PointG1
 }
 
+// -------------------- 错误定义 --------------------
+
+var (
+	// ErrEmptyRing 环中没有任何公钥
+	ErrEmptyRing = errors.New("环为空")
+	// ErrNilPublicKey 环中存在 nil 公钥
+	ErrNilPublicKey = errors.New("环中存在 nil 公钥")
+	// ErrDuplicatePublicKey 环中存在重复的公钥
+	ErrDuplicatePublicKey = errors.New("环中存在重复的公钥")
+	// ErrNilSigner 签名者或其密钥为 nil
+	ErrNilSigner = errors.New("签名者密钥为空")
+	// ErrSignerNotInRing 签名者公钥不在环中
+	ErrSignerNotInRing = errors.New("签名者公钥不在环中")
+	// ErrKeyMismatch 签名者的公钥不等于 PrivateKey \cdot P
+	ErrKeyMismatch = errors.New("签名者私钥与公钥不匹配")
+	// ErrRandomSource 从随机数来源读取失败
+	ErrRandomSource = errors.New("读取随机数来源失败")
+	// ErrRingSizeMismatch 签名中 U_i 的个数与公钥环大小不一致
+	ErrRingSizeMismatch = errors.New("签名与公钥环大小不一致")
+	// ErrMalformedSignature 签名结构不完整或取值非法
+	ErrMalformedSignature = errors.New("签名格式错误")
+	// ErrUnsupportedTranscript 哈希转录编码版本未知或未被允许
+	ErrUnsupportedTranscript = errors.New("不支持的哈希转录编码版本")
+	// ErrInvalidEncoding 签名、公钥或公钥环的编码格式错误
+	ErrInvalidEncoding = errors.New("编码格式错误")
+	// ErrInvalidPoint 点不在曲线上或不属于素数阶子群
+	ErrInvalidPoint = errors.New("点不在曲线上或不属于素数阶子群")
+	// ErrIdentityPoint 公钥或签名中出现了不允许的无穷远点
+	ErrIdentityPoint = errors.New("点为无穷远点")
+	// ErrChallengeMismatch 重新计算的挑战值 C 与签名中的 C 不一致
+	ErrChallengeMismatch = errors.New("挑战值校验失败")
+	// ErrBatchLengthMismatch 批量验证时消息、公钥环与签名的个数不一致
+	ErrBatchLengthMismatch = errors.New("批量验证的输入个数不一致")
+	// ErrPoolEntryUsed 预计算条目为 nil 或已被用于签名
+	ErrPoolEntryUsed = errors.New("预计算条目已被使用")
+	// ErrPoolClosed 预计算池已关闭
+	ErrPoolClosed = errors.New("预计算池已关闭")
+	// ErrPoolDeterministic 确定性签名的随机数由消息派生，不能使用预计算
+	ErrPoolDeterministic = errors.New("确定性签名不能使用预计算")
+	// ErrInvalidPoolSize 预计算池的容量或诱饵个数非法
+	ErrInvalidPoolSize = errors.New("预计算池的容量或诱饵个数非法")
+	// ErrNotLinkable 要求可链接签名，但签名中没有密钥镜像
+	ErrNotLinkable = errors.New("签名不是可链接签名")
+	// ErrLinkMismatch 密钥镜像的环证明校验失败
+	ErrLinkMismatch = errors.New("密钥镜像的环证明校验失败")
+	// ErrNotTraceable 要求可追踪签名，但签名中没有追踪密文，或 Open 的签名不是可追踪签名
+	ErrNotTraceable = errors.New("签名不是可追踪签名")
+	// ErrTracingKeyRequired 验证可追踪签名时未通过 WithTracing 指定管理者公钥
+	ErrTracingKeyRequired = errors.New("验证可追踪签名须指定管理者公钥")
+	// ErrTraceMismatch 追踪密文的环证明校验失败
+	ErrTraceMismatch = errors.New("追踪密文的环证明校验失败")
+	// ErrNilManager 追踪管理者或其密钥为 nil
+	ErrNilManager = errors.New("追踪管理者密钥为空")
+	// ErrOpenFailed 解密得到的公钥不在环中
+	ErrOpenFailed = errors.New("解密得到的公钥不在环中")
+	// ErrInvalidOpenProof 打开证明校验失败
+	ErrInvalidOpenProof = errors.New("打开证明校验失败")
+)
+
+// -------------------- 可选参数 --------------------
+
+// Config 保存 NewSigner、NewRingContext、Sign、Verify 与密钥导出使用的可选配置
+type Config struct {
+	// Random 随机数来源，默认为 crypto/rand.Reader
+	Random io.Reader
+	// Deterministic 为 true 时，Sign 中的所有随机量由 HMAC-DRBG 确定性派生，不再读取 Random
+	Deterministic bool
+	// ExtraEntropy 确定性模式下额外混入 HMAC-DRBG 的熵，可为空
+	ExtraEntropy []byte
+	// Transcript Sign 使用的哈希转录编码版本，默认为 TranscriptV2
+	Transcript TranscriptVersion
+	// AllowLegacy 为 true 时，Verify 接受使用 TranscriptLegacy 编码的旧签名
+	AllowLegacy bool
+	// KDFIterations 加密私钥 PEM 时 PBKDF2 的迭代次数，默认为 KeyPEM.DefaultIterations
+	KDFIterations int
+	// Precompute 为 true 时，NewRingContext 为每个公钥构造定点预计算表
+	Precompute bool
+	// Workers SignContext 与 VerifyContext 处理环成员时使用的协程数，也是 VerifyBatch 并发验证签名的协程数，默认为 1
+	Workers int
+	// Linkable 为 true 时签名附带密钥镜像及其环证明，验证时要求签名是可链接签名
+	Linkable bool
+	// Tracing 追踪管理者公钥，不为 nil 时签名附带加密给管理者的签名者身份，验证时要求签名是针对该公钥的可追踪签名
+	Tracing *bls.PointG1
+}
+
+// Option 以函数式选项的方式修改 Config
+type Option func(*Config)
+
+// WithRandom 指定生成密钥与签名时使用的随机数来源
+func WithRandom(r io.Reader) Option {
+	return func(c *Config) {
+		c.Random = r
+	}
+}
+
+// WithDeterministic 启用确定性签名模式（类似 RFC 6979）
+// 随机量由私钥、消息与公钥环派生，extra 不为空时作为额外熵混入
+func WithDeterministic(extra []byte) Option {
+	return func(c *Config) {
+		c.Deterministic = true
+		c.ExtraEntropy = extra
+	}
+}
+
+// WithTranscript 指定 Sign 使用的哈希转录编码版本
+func WithTranscript(v TranscriptVersion) Option {
+	return func(c *Config) {
+		c.Transcript = v
+	}
+}
+
+// WithLegacyTranscript 允许 Verify 接受旧版编码的签名
+func WithLegacyTranscript() Option {
+	return func(c *Config) {
+		c.AllowLegacy = true
+	}
+}
+
+// WithKDFIterations 指定加密私钥 PEM 时 PBKDF2 的迭代次数
+func WithKDFIterations(n int) Option {
+	return func(c *Config) {
+		c.KDFIterations = n
+	}
+}
+
+// WithPrecompute 令 NewRingContext 为每个公钥构造定点预计算表，适用于同一个环上的大量签名与验证
+func WithPrecompute() Option {
+	return func(c *Config) {
+		c.Precompute = true
+	}
+}
+
+// WithWorkers 指定处理环成员时使用的协程数，n <= 0 时使用 runtime.GOMAXPROCS(0)
+func WithWorkers(n int) Option {
+	return func(c *Config) {
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		c.Workers = n
+	}
+}
+
+// WithLinkable 启用可链接模式：签名附带密钥镜像，验证时拒绝不带密钥镜像的签名，需使用 TranscriptV2
+func WithLinkable() Option {
+	return func(c *Config) {
+		c.Linkable = true
+	}
+}
+
+// WithTracing 启用可追踪模式：签名者把自己的公钥加密给管理者公钥 mpk，管理者可通过 Open 打开签名，需使用 TranscriptV2
+// 验证可追踪签名时须指定同一个 mpk，验证时拒绝不带追踪密文的签名
+func WithTracing(mpk *bls.PointG1) Option {
+	return func(c *Config) {
+		c.Tracing = mpk
+	}
+}
+
+// NewConfig 以默认配置为基础依次应用 opts
+func NewConfig(opts ...Option) *Config {
+	c := &Config{Random: rand.Reader, Transcript: TranscriptV2, KDFIterations: KeyPEM.DefaultIterations, Workers: 1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// AcceptsTranscript 判断 Verify 是否接受版本为 v 的签名
+func (c *Config) AcceptsTranscript(v TranscriptVersion) bool {
+	if v == TranscriptLegacy {
+		return c.AllowLegacy
+	}
+	return v.Supported()
+}
+
 // -------------------- 工具函数 --------------------
 
 // CompareBigInts 判断 a 和 b 在数值上是否相等
@@ -48,6 +307,8 @@ func InvZq(a *big.Int) *big.Int {
 
 // SubG1 计算两个 G1 群元素 p1 和 p2 的差值 p1 - p2，等价于 p1 + (-p2)
 func SubG1(p1, p2 *bls.PointG1) *bls.PointG1 {
+	g1 := getG1()
+	defer putG1(g1)
 	n := g1.New()
 	g1.Neg(n, p2)
 	r := g1.New()
@@ -57,6 +318,8 @@ func SubG1(p1, p2 *bls.PointG1) *bls.PointG1 {
 
 // AddG1 计算两个 G1 群元素 p1 和 p2 的相加，返回结果落回 G1 域
 func AddG1(p1, p2 *bls.PointG1) *bls.PointG1 {
+	g1 := getG1()
+	defer putG1(g1)
 	r := g1.New()
 	g1.Add(r, p1, p2)
 	return r
@@ -64,24 +327,37 @@ func AddG1(p1, p2 *bls.PointG1) *bls.PointG1 {
 
 // CompareG1 比较两个 G1 群元素在字节序列上的相等性，如果完全相同则返回 true
 func CompareG1(p1, p2 *bls.PointG1) bool {
-	b1 := g1.ToBytes(p1)
-	b2 := g1.ToBytes(p2)
+	b1 := marshalG1(p1)
+	b2 := marshalG1(p2)
 	return bytes.Equal(b1, b2)
 }
 
+// RandomPointG1From 从随机数来源 r 中随机生成一个 G1 群元素。实现：随机标量 k * G
+func RandomPointG1From(r io.Reader) (*bls.PointG1, error) {
+	k, err := RandomZqFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return baseMulG1(k), nil
+}
+
+// baseMulG1 计算 k \cdot P 并转换为仿射坐标，之后 marshalG1 对副本编码时无需再求逆
+func baseMulG1(k *big.Int) *bls.PointG1 {
+	g1 := getG1()
+	defer putG1(g1)
+	p := g1.New()
+	g1.MulScalarBig(p, g1.One(), k)
+	g1.Affine(p)
+	return p
+}
+
 // RandomPointG1 随机生成一个 G1 群元素，返回点。实现：随机标量 k * G
 func RandomPointG1() *bls.PointG1 {
-	// 生成随机标量 k
-	k, err := rand.Int(rand.Reader, Order)
+	p, err := RandomPointG1From(rand.Reader)
 	if err != nil {
 		panic(fmt.Sprintf("生成随机标量失败: %v", err))
 	}
-	// 基点
-	base := g1.One()
-	r := g1.New()
-	// 标量乘
-	g1.MulScalarBig(r, base, k)
-	return r
+	return p
 }
 
 // AddZq 计算两个有限域标量 a 和 b 的和，并对 Order 取模
@@ -98,39 +374,79 @@ func SubZq(a, b *big.Int) *big.Int {
 
 // ScalarMulG1 计算给定 G1 点 p 与标量 k 的乘积，返回新的群元素
 func ScalarMulG1(p *bls.PointG1, k *big.Int) *bls.PointG1 {
+	g1 := getG1()
+	defer putG1(g1)
 	r := g1.New()
 	g1.MulScalarBig(r, p, k)
 	return r
 }
 
+// MultiScalarMulG1 计算多标量乘法 \sum_i k_i \cdot p_i，points 与 scalars 的长度须一致
+// 标量约减到 [0, Order) 后交给 kilic 的 Pippenger 分桶实现 MultiExpBig
+func MultiScalarMulG1(points []*bls.PointG1, scalars []*big.Int) *bls.PointG1 {
+	g1 := getG1()
+	defer putG1(g1)
+	ks := make([]*big.Int, len(scalars))
+	for i, k := range scalars {
+		if k.Sign() < 0 || k.Cmp(Order) >= 0 {
+			k = new(big.Int).Mod(k, Order)
+		}
+		ks[i] = k
+	}
+
+	r := g1.New()
+	if _, err := g1.MultiExpBig(r, points, ks); err != nil {
+		panic("MultiScalarMulG1: points 与 scalars 长度不一致")
+	}
+	return r
+}
+
 // MulZq 计算两个有限域标量 a 和 b 的乘积，并对 Order 取模
 func MulZq(a, b *big.Int) *big.Int {
 	prod := new(big.Int).Mul(a, b)
 	return prod.Mod(prod, Order)
 }
 
-// RandomZq 在 Zq* 的有限域内取一个随机数
+// RandomZqFrom 从随机数来源 r 中在 Zq* 的有限域内取一个随机数
+func RandomZqFrom(r io.Reader) (*big.Int, error) {
+	k, err := rand.Int(r, Order)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRandomSource, err)
+	}
+	return k, nil
+}
+
+// RandomZq 在 Zq* 的有限域内取一个随机数，随机数来源为 crypto/rand.Reader
 func RandomZq() *big.Int {
-	k, err := rand.Int(rand.Reader, Order)
+	k, err := RandomZqFrom(rand.Reader)
 	if err != nil {
-		fmt.Println("在 Zq* 的有限域内取随机数失败：", err)
-		return nil
+		panic(fmt.Sprintf("在 Zq* 的有限域内取随机数失败：%v", err))
 	}
 	return k
 }
 
 // NewSigner 用于系统中生成 Signer (sk_i, pk_i)
-func NewSigner() *Signer {
+// 可通过 WithRandom 指定随机数来源，读取失败时返回错误
+func NewSigner(opts ...Option) (*Signer, error) {
+	g1 := getG1()
+	defer putG1(g1)
+	cfg := NewConfig(opts...)
+
 	// 私钥
-	sk := RandomZq()
+	sk, err := RandomZqFrom(cfg.Random)
+	if err != nil {
+		return nil, err
+	}
 	// 公钥 = sk * G
 	base := g1.One()
 	pk := g1.New()
 	g1.MulScalarBig(pk, base, sk)
-	return &Signer{PrivateKey: sk, PublicKey: pk}
+	g1.Affine(pk)
+	return &Signer{PrivateKey: sk, PublicKey: pk}, nil
 }
 
 // HashToZq 将任意若干字节切片拼接做 SHA256，然后结果映射到 Z_q
+// 这是 TranscriptLegacy 使用的旧版编码，新签名请使用 HashTranscript
 func HashToZq(args ...interface{}) *big.Int {
 	// 初始化拼接的字节数组
 	var concatenated []byte
@@ -143,11 +459,11 @@ func HashToZq(args ...interface{}) *big.Int {
 			concatenated = append(concatenated, v...)
 		case *bls.PointG1:
 			// 如果是 *bn256.G1 类型，序列化后拼接
-			concatenated = append(concatenated, g1.ToBytes(v)...)
+			concatenated = append(concatenated, marshalG1(v)...)
 		case []*bls.PointG1:
 			// 如果是 []bn256.G1 类型，逐个序列化后拼接
 			for _, p := range v {
-				concatenated = append(concatenated, g1.ToBytes(p)...)
+				concatenated = append(concatenated, marshalG1(p)...)
 			}
 		case *big.Int:
 			// 如果是 *big.Int 类型，将其字节表示拼接
@@ -172,3 +488,136 @@ func HashToZq(args ...interface{}) *big.Int {
 
 	return hashMod
 }
+
+// HashTranscript 按转录编码版本 version 计算哈希并映射到 Z_q，tag 为哈希用途的域分离标签
+func HashTranscript(version TranscriptVersion, tag string, args ...interface{}) *big.Int {
+	switch version {
+	case TranscriptLegacy:
+		return HashToZq(args...)
+	case TranscriptV1:
+		return HashToZqV1(tag, args...)
+	default:
+		return HashToZqV2(tag, args...)
+	}
+}
+
+// HashToZqV1 使用 v1 转录编码计算哈希并映射到 Z_q
+// 编码以 HashDomain + tag 开头，每个参数带类型字节，变长数据带长度前缀，标量编码为定长 32 字节；
+// 对 SHA-512 的 64 字节输出取模，使结果在 Z_q 上近似均匀
+func HashToZqV1(tag string, args ...interface{}) *big.Int {
+	return hashToZq(HashDomain, tag, args)
+}
+
+// HashToZqV2 使用 v2 转录编码计算哈希并映射到 Z_q，参数编码与 v1 相同，仅域分离前缀不同
+func HashToZqV2(tag string, args ...interface{}) *big.Int {
+	return hashToZq(HashDomainV2, tag, args)
+}
+
+// hashToZq 以 domain + tag 为域分离标签写入各参数，对 SHA-512 的输出取模
+func hashToZq(domain, tag string, args []interface{}) *big.Int {
+	buf := appendTranscript(appendBytes(nil, []byte(domain+tag)), args...)
+	hash := sha512.Sum512(buf)
+	hashInt := new(big.Int).SetBytes(hash[:])
+	return hashInt.Mod(hashInt, Order)
+}
+
+// appendTranscript 依次写入各参数：每个参数带类型字节，变长数据带长度前缀，标量编码为定长 32 字节
+func appendTranscript(buf []byte, args ...interface{}) []byte {
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case []byte:
+			buf = append(buf, 0x01)
+			buf = appendBytes(buf, v)
+		case *bls.PointG1:
+			buf = append(buf, 0x02)
+			buf = append(buf, marshalG1(v)...)
+		case []*bls.PointG1:
+			buf = append(buf, 0x03)
+			buf = binary.BigEndian.AppendUint64(buf, uint64(len(v)))
+			for _, p := range v {
+				buf = append(buf, marshalG1(p)...)
+			}
+		case *big.Int:
+			buf = append(buf, 0x04)
+			buf = appendScalar(buf, v)
+		case []*big.Int:
+			buf = append(buf, 0x05)
+			buf = binary.BigEndian.AppendUint64(buf, uint64(len(v)))
+			for _, bi := range v {
+				buf = appendScalar(buf, bi)
+			}
+		default:
+			panic(fmt.Sprintf("不支持的类型: %T", v))
+		}
+	}
+	return buf
+}
+
+// RingDigest 计算公钥环的定长摘要，即对 HashDomainV2 + "ring" 与转录编码的 PKList 做 SHA-256
+func RingDigest(PKList []*bls.PointG1) []byte {
+	return ringDigest(appendTranscript(nil, PKList))
+}
+
+// ringDigest 由公钥环的转录编码计算环摘要
+func ringDigest(encoding []byte) []byte {
+	digest := sha256.Sum256(append(appendBytes(nil, []byte(HashDomainV2+"ring")), encoding...))
+	return digest[:]
+}
+
+// transcript 一次签名或验证中复用的哈希上下文
+// v2 编码预先把消息与公钥环压缩为定长摘要，之后每个哈希只需写入摘要；旧版与 v1 编码仍按原样拼接
+type transcript struct {
+	version TranscriptVersion
+	Message []byte
+	PKList  []*bls.PointG1
+	context []byte
+}
+
+// newTranscript 构造哈希上下文，v2 编码下只在这里遍历一次消息，公钥环的摘要取自 rc
+func newTranscript(version TranscriptVersion, Message []byte, rc *RingContext) *transcript {
+	t := &transcript{version: version, Message: Message, PKList: rc.pkList}
+	if version == TranscriptV2 {
+		buf := appendTranscript(appendBytes(nil, []byte(HashDomainV2+"context")), Message, rc.digest)
+		digest := sha256.Sum256(buf)
+		t.context = digest[:]
+	}
+	return t
+}
+
+// Hi 计算 U 对应的 H_i
+func (t *transcript) Hi(U *bls.PointG1) *big.Int {
+	if t.version == TranscriptV2 {
+		return HashToZqV2(TagHi, t.context, U)
+	}
+	return HashTranscript(t.version, TagHi, t.Message, t.PKList, U)
+}
+
+// E 计算 e = H(PKList, Message, T, C)
+func (t *transcript) E(T *bls.PointG1, C *big.Int) *big.Int {
+	if t.version == TranscriptV2 {
+		return HashToZqV2(TagE, t.context, T, C)
+	}
+	return HashTranscript(t.version, TagE, t.PKList, t.Message, T, C)
+}
+
+// Link 计算密钥镜像环证明的挑战值 c_{i+1} = H(context, I, C, L_i, R_i)，只用于 v2 编码
+// 写入签名的挑战值 C，使环证明与签名的其余部分绑定
+func (t *transcript) Link(I *bls.PointG1, C *big.Int, L, R *bls.PointG1) *big.Int {
+	return HashToZqV2(TagLink, t.context, I, C, L, R)
+}
+
+// Trace 计算追踪密文环证明的挑战值 c_{i+1} = H(context, mpk, E_1, E_2, C, A_i, B_i, D_i)，只用于 v2 编码
+func (t *transcript) Trace(mpk, E1, E2 *bls.PointG1, C *big.Int, A, B, D *bls.PointG1) *big.Int {
+	return HashToZqV2(TagTrace, t.context, mpk, E1, E2, C, A, B, D)
+}
+
+// appendBytes 以 8 字节大端长度前缀写入变长字节串
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// appendScalar 将标量对 Order 取模后编码为定长 32 字节写入
+func appendScalar(buf []byte, k *big.Int) []byte {
+	return append(buf, new(big.Int).Mod(k, Order).FillBytes(make([]byte, 32))...)
+}
@@ -0,0 +1,156 @@
+package RSCP
+
+import (
+	"context"
+	"fmt"
+	bls "github.com/kilic/bls12-381"
+	"io"
+	"math/big"
+)
+
+// ringRef 以切片首元素的地址与长度标识同一个公钥环
+type ringRef struct {
+	first **bls.PointG1
+	n     int
+}
+
+// cachedRing 同一个公钥环的 RingContext 或校验错误
+type cachedRing struct {
+	rc  *RingContext
+	err error
+}
+
+// batchItem 批量验证中单个签名的预处理结果
+type batchItem struct {
+	index  int
+	keys   []string
+	PKList []*bls.PointG1
+	UI     []*bls.PointG1
+	HiList []*big.Int
+	V      *bls.PointG2
+	delta  *big.Int
+}
+
+// VerifyBatch 批量验证多个环签名，返回与输入一一对应的验证结果，nil 表示签名合法
+// 对每个签名取随机的 128 位指数 δ_j，检查 e(P, \sum_j δ_j V_j) = e(\sum_j δ_j Sum_j, Q)；
+// 由于 P、Q 对所有签名相同，整批只需一次双配对，多个签名共用的公钥在多标量乘法中合并为一项
+// 整批校验失败时通过二分查找定位不合法的签名，其结果为 ErrPairingMismatch；结构不合法或标签校验失败的签名直接记录对应的错误，不参与配对
+// Messages、PKLists 与 SignerResults 个数不一致，或读取随机数失败时返回错误
+func VerifyBatch(Messages [][]byte, PKLists [][]*bls.PointG1, SignerResults []*Sigma, opts ...Option) ([]error, error) {
+	if len(Messages) != len(PKLists) || len(Messages) != len(SignerResults) {
+		return nil, fmt.Errorf("%w: 消息 %d 条，公钥环 %d 个，签名 %d 个",
+			ErrBatchLengthMismatch, len(Messages), len(PKLists), len(SignerResults))
+	}
+	cfg := NewConfig(opts...)
+
+	// 1. 逐个做结构校验并计算 H_i，传入同一个 PKList 切片的签名共用同一个 RingContext
+	results := make([]error, len(SignerResults))
+	rings := make(map[ringRef]cachedRing)
+	items := make([]*batchItem, 0, len(SignerResults))
+	for j, sigma := range SignerResults {
+		PKList := PKLists[j]
+		ref := ringRef{n: len(PKList)}
+		if len(PKList) > 0 {
+			ref.first = &PKList[0]
+		}
+		ring, ok := rings[ref]
+		if !ok {
+			ring.rc, ring.err = NewRingContext(PKList)
+			rings[ref] = ring
+		}
+		if ring.err != nil {
+			results[j] = ring.err
+			continue
+		}
+		if err := checkVerifyInput(cfg, ring.rc, sigma); err != nil {
+			results[j] = err
+			continue
+		}
+
+		delta, err := randomExponent(cfg.Random)
+		if err != nil {
+			return nil, err
+		}
+		item := &batchItem{
+			index:  j,
+			keys:   make([]string, len(PKList)),
+			PKList: PKList,
+			UI:     sigma.UI,
+			V:      sigma.V,
+			delta:  delta,
+		}
+		item.HiList, _ = sigmaTranscript(Messages[j], ring.rc, sigma).hiList(context.Background(), 1, sigma)
+		for i := range sigma.UI {
+			item.keys[i] = string(marshalG1(PKList[i]))
+		}
+		// 可链接签名的标签等式各自使用不同的 B = H_p(Scope)，在这里逐个校验
+		if sigma.Tag != nil {
+			if err := verifyTag(sigma, item.HiList); err != nil {
+				results[j] = err
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+
+	// 2. 整批校验，失败时二分定位
+	for _, j := range bisectBatch(items) {
+		results[j] = ErrPairingMismatch
+	}
+	return results, nil
+}
+
+// bisectBatch 返回 items 中不合法签名的下标：整批通过则全部合法，否则拆成两半分别检查
+func bisectBatch(items []*batchItem) []int {
+	if len(items) == 0 || checkBatch(items) {
+		return nil
+	}
+	if len(items) == 1 {
+		return []int{items[0].index}
+	}
+	mid := len(items) / 2
+	return append(bisectBatch(items[:mid]), bisectBatch(items[mid:])...)
+}
+
+// checkBatch 检查 e(P, \sum_j δ_j V_j) = e(\sum_j δ_j \sum_i (H_{j,i} \cdot pk_{j,i} + U_{j,i}), Q)
+func checkBatch(items []*batchItem) bool {
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	var points []*bls.PointG1
+	var scalars []*big.Int
+	position := make(map[string]int)
+	VSum := blsG2.New()
+
+	for _, item := range items {
+		for i, pk := range item.PKList {
+			// 相同公钥的系数 δ_j \cdot H_{j,i} 累加到同一项
+			k := new(big.Int).Mul(item.delta, item.HiList[i])
+			if pos, ok := position[item.keys[i]]; ok {
+				scalars[pos] = new(big.Int).Add(scalars[pos], k)
+			} else {
+				position[item.keys[i]] = len(points)
+				points = append(points, pk)
+				scalars = append(scalars, k)
+			}
+			points = append(points, item.UI[i])
+			scalars = append(scalars, item.delta)
+		}
+		tmp := blsG2.New()
+		blsG2.MulScalarBig(tmp, item.V, item.delta)
+		blsG2.Add(VSum, VSum, tmp)
+	}
+	return VerifyPairing(MultiScalarMulG1(points, scalars), VSum)
+}
+
+// randomExponent 从 r 中读取批量验证使用的 128 位非零随机指数
+func randomExponent(r io.Reader) (*big.Int, error) {
+	var buf [16]byte
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRandomSource, err)
+		}
+		if k := new(big.Int).SetBytes(buf[:]); k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
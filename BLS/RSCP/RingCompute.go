@@ -1,12 +1,36 @@
 package RSCP
 
 import (
+	"BRFL/DRBG"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
 	bls "github.com/kilic/bls12-381"
+	"io"
 	"math/big"
 )
 
+// VerifyPairing 验证 e(P, V) 是否等于 e(Sum, Q)
+// 等价于在同一个 Engine 中检查 e(P, V) \cdot e(-Sum, Q) = 1，只做一次最终幂运算
+func VerifyPairing(Sum *bls.PointG1, V *bls.PointG2) bool {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	blsG2 := getG2()
+	defer putG2(blsG2)
+
+	// AddPair 会把传入的点原地转换为仿射坐标，因此这里传入副本
+	engine := bls.NewEngine()
+	engine.AddPair(blsG1.One(), blsG2.New().Set(V))
+	engine.AddPairInv(Sum, blsG2.One())
+	return engine.Check()
+}
+
 // ComputeV 计算 V = (r + h_s * sk_s) * Q
 func ComputeV(R, H_s, SK_S *big.Int) *bls.PointG2 {
+	blsG2 := getG2()
+	defer putG2(blsG2)
 	sum := new(big.Int).Add(R, new(big.Int).Mul(H_s, SK_S))
 	sum.Mod(sum, blsOrder)
 
@@ -24,24 +48,224 @@ func ComputeUS(
 	UiList []*bls.PointG1,
 	flag int,
 ) (US *bls.PointG1) {
+	blsG1 := getG1()
+	defer putG1(blsG1)
 
 	// 1. tmp1 = r * G1
 	tmp1 := blsG1.New()
 	blsG1.MulScalarBig(tmp1, blsG1.One(), r)
 
 	// 2. 计算 \sum_{i != s} (U_i + H_i * pk_i)
-	tmpSum := blsG1.New() // 先置为零点
-	for i, Ui := range UiList {
-		if i == flag {
+	tmpSum := ComputeSum(HiList, PKList, UiList, flag)
+
+	// 3. U_s = tmp1 - tmpSum
+	US = SubG1(tmp1, tmpSum)
+
+	return
+}
+
+// ComputeSum 排除 signer_index 后计算 H_i * PK_i + U_i 的和
+// 其中 \sum H_i * PK_i 部分通过 MultiScalarMulG1 一次算出，U_i 直接累加
+func ComputeSum(H_i []*big.Int, PK_List []*bls.PointG1, U_i []*bls.PointG1, signer_index int) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	points := make([]*bls.PointG1, 0, len(H_i))
+	scalars := make([]*big.Int, 0, len(H_i))
+	sum := blsG1.New()
+	for i := range H_i {
+		if i == signer_index {
 			continue
 		}
-		tmp2 := ScalarMulG1(PKList[i], HiList[i]) // H_i * pk_i
-		tmpSumPart := AddG1(Ui, tmp2)             // U_i + tmp2
-		blsG1.Add(tmpSum, tmpSum, tmpSumPart)
+		points = append(points, PK_List[i])
+		scalars = append(scalars, H_i[i])
+		blsG1.Add(sum, sum, U_i[i])
 	}
+	return blsG1.Add(sum, sum, MultiScalarMulG1(points, scalars))
+}
 
-	// 3. U_s = tmp1 - tmpSum
-	US = SubG1(tmp1, tmpSum)
+// ValidatePoint 校验 G1 点：须在曲线上、属于素数阶子群，且不能是无穷远点
+func ValidatePoint(p *bls.PointG1) error {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	if err := checkSubgroup(p); err != nil {
+		return err
+	}
+	if blsG1.IsZero(p) {
+		return ErrIdentityPoint
+	}
+	return nil
+}
+
+// checkSubgroup 校验 G1 点在曲线上且属于素数阶子群，允许无穷远点
+func checkSubgroup(p *bls.PointG1) error {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	if p == nil || !blsG1.IsOnCurve(p) || !blsG1.InCorrectSubgroup(p) {
+		return ErrInvalidPoint
+	}
+	return nil
+}
+
+// ValidateG2Point 校验 G2 点：须在曲线上、属于素数阶子群，且不能是无穷远点
+func ValidateG2Point(p *bls.PointG2) error {
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	if p == nil || !blsG2.IsOnCurve(p) || !blsG2.InCorrectSubgroup(p) {
+		return ErrInvalidPoint
+	}
+	if blsG2.IsZero(p) {
+		return ErrIdentityPoint
+	}
+	return nil
+}
+
+// ValidateRing 校验公钥环：不能为空、不能包含 nil 公钥、无效点或无穷远点、不能包含重复公钥
+// 返回公钥序列化结果到下标的映射，便于后续定位签名者
+func ValidateRing(PKList []*bls.PointG1) (index map[string]int, err error) {
+	return validateRing(context.Background(), PKList)
+}
+
+// validateRing ValidateRing 的实现，每 sumChunk 个公钥检查一次 ctx，ctx 被取消时返回 ctx.Err()
+func validateRing(ctx context.Context, PKList []*bls.PointG1) (index map[string]int, err error) {
+	if len(PKList) == 0 {
+		return nil, ErrEmptyRing
+	}
 
+	index = make(map[string]int, len(PKList))
+	for i, v := range PKList {
+		if i%sumChunk == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if v == nil {
+			return nil, fmt.Errorf("%w: 下标 %d", ErrNilPublicKey, i)
+		}
+		if err := ValidatePoint(v); err != nil {
+			return nil, fmt.Errorf("%w: 下标 %d", err, i)
+		}
+		key := string(marshalG1(v))
+		if j, ok := index[key]; ok {
+			return nil, fmt.Errorf("%w: 下标 %d 与 %d", ErrDuplicatePublicKey, j, i)
+		}
+		index[key] = i
+	}
 	return
 }
+
+// FindSigner 校验公钥环与签名者的密钥对，并返回签名者公钥在 PKList 中的下标
+func FindSigner(PKList []*bls.PointG1, SignerS *Signer) (flag int, err error) {
+	if SignerS == nil || SignerS.PrivateKey == nil || SignerS.PublicKey == nil {
+		return -1, ErrNilSigner
+	}
+
+	index, err := ValidateRing(PKList)
+	if err != nil {
+		return -1, err
+	}
+
+	flag, ok := index[string(marshalG1(SignerS.PublicKey))]
+	if !ok {
+		return -1, ErrSignerNotInRing
+	}
+	if !matchKeyPair(SignerS) {
+		return -1, ErrKeyMismatch
+	}
+	return flag, nil
+}
+
+// matchKeyPair 判断签名者的公钥是否等于 PrivateKey \cdot P，密钥不匹配时签名无法通过验证
+func matchKeyPair(SignerS *Signer) bool {
+	return CompareG1(baseMulG1(SignerS.PrivateKey), SignerS.PublicKey)
+}
+
+// CheckSigma 校验签名结构本身：U_i 与 V 不能为 nil，且须为素数阶子群中的有效点，V 不能是无穷远点
+func CheckSigma(SignerResult *Sigma) error {
+	if SignerResult == nil || SignerResult.V == nil {
+		return fmt.Errorf("%w: 缺少签名字段", ErrMalformedSignature)
+	}
+
+	if err := ValidateG2Point(SignerResult.V); err != nil {
+		return fmt.Errorf("%w: V", err)
+	}
+
+	for i, v := range SignerResult.UI {
+		if v == nil {
+			return fmt.Errorf("%w: U_%d 为 nil", ErrMalformedSignature, i)
+		}
+		if err := checkSubgroup(v); err != nil {
+			return fmt.Errorf("%w: U_%d", err, i)
+		}
+	}
+	if err := checkLinkable(SignerResult); err != nil {
+		return err
+	}
+	return checkThreshold(SignerResult)
+}
+
+// ValidateSigma 校验签名结构，并要求 U_i 的个数与环大小一致
+func ValidateSigma(PKList []*bls.PointG1, SignerResult *Sigma) error {
+	if err := CheckSigma(SignerResult); err != nil {
+		return err
+	}
+	if len(SignerResult.UI) != len(PKList) {
+		return fmt.Errorf("%w: U_i 个数为 %d，环大小为 %d", ErrRingSizeMismatch, len(SignerResult.UI), len(PKList))
+	}
+	return nil
+}
+
+// checkVerifyInput 验证前的公共检查：签名结构、cfg 是否接受签名的转录版本，可链接模式下签名的范围，以及要求的最小门限；公钥环已由 NewRingContext 校验
+func checkVerifyInput(cfg *Config, rc *RingContext, SignerResult *Sigma) error {
+	if err := ValidateSigma(rc.pkList, SignerResult); err != nil {
+		return err
+	}
+	if !cfg.AcceptsTranscript(SignerResult.Version) {
+		return fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, SignerResult.Version)
+	}
+	if cfg.Linkable {
+		if SignerResult.Tag == nil {
+			return ErrNotLinkable
+		}
+		if !bytes.Equal(SignerResult.Scope, cfg.Scope) {
+			return ErrScopeMismatch
+		}
+	}
+	// 普通签名相当于门限为 1 的签名
+	if t := max(SignerResult.Threshold, 1); t < cfg.Threshold {
+		return fmt.Errorf("%w: 签名门限为 %d，要求 %d", ErrThresholdTooLow, t, cfg.Threshold)
+	}
+	return nil
+}
+
+// NewNonceReader 构造确定性签名模式下的随机数来源
+// HMAC-DRBG 以定长编码的私钥为熵，以签名选项、消息与公钥环的摘要为 nonce，cfg.ExtraEntropy 追加在个性化字符串之后
+// 影响挑战值的选项都写入 nonce，同一消息在不同选项下签名时不会复用随机数，否则由两个签名即可解出私钥
+func NewNonceReader(sk *big.Int, Message []byte, PKList []*bls.PointG1, cfg *Config) io.Reader {
+	// 1. 私钥编码为定长 32 字节
+	entropy := new(big.Int).Mod(sk, blsOrder).FillBytes(make([]byte, 32))
+
+	// 2. nonce = SHA256(options || len(Message) || Message || pk_1 || ... || pk_n)
+	h := sha256.New()
+	h.Write(nonceOptions(cfg))
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(Message)))
+	h.Write(length[:])
+	h.Write(Message)
+	for _, pk := range PKList {
+		h.Write(marshalG1(pk))
+	}
+
+	personalization := append([]byte(NonceDomain), cfg.ExtraEntropy...)
+	return DRBG.New(entropy, h.Sum(nil), personalization)
+}
+
+// nonceOptions 编码影响挑战值的签名选项，作为确定性签名 nonce 的前缀：
+// 转录版本 (1) || 是否可链接 (1) [ || 范围长度 (8) || Scope ]
+// 范围写入 nonce 后，同一消息在不同范围内的签名不会复用随机数，否则两个 V 之差会泄露 sk \cdot Q
+func nonceOptions(cfg *Config) []byte {
+	if !cfg.Linkable {
+		return []byte{byte(cfg.Transcript), 0}
+	}
+	buf := binary.BigEndian.AppendUint64([]byte{byte(cfg.Transcript), 1}, uint64(len(cfg.Scope)))
+	return append(buf, cfg.Scope...)
+}
@@ -0,0 +1,177 @@
+package RSCP
+
+import (
+	"context"
+	bls "github.com/kilic/bls12-381"
+	"math/big"
+)
+
+// fixedBaseWindow 定点预计算表的窗口宽度（比特），取 8 使每个窗口恰好对应标量的一个字节
+const fixedBaseWindow = 8
+
+// fixedBaseWindows 覆盖 blsOrder 全部比特所需的窗口个数，即每个公钥预计算表的长度
+var fixedBaseWindows = (blsOrder.BitLen() + fixedBaseWindow - 1) / fixedBaseWindow
+
+// RingContext 由公钥环一次性构造、可在多次签名与验证之间复用的上下文
+// 保存公钥环的规范编码与摘要、公钥到下标的映射，以及可选的定点预计算表；构造完成后只读，可被多个协程共享
+type RingContext struct {
+	pkList   []*bls.PointG1
+	encoding []byte
+	digest   []byte
+	index    map[string]int
+	tables   [][]*bls.PointG1
+}
+
+// NewRingContext 校验公钥环并构造 RingContext，构造完成后不能再修改 PKList 中的公钥
+// 通过 WithPrecompute 为每个公钥构造定点预计算表，以额外的内存换取更快的环求和
+func NewRingContext(PKList []*bls.PointG1, opts ...Option) (*RingContext, error) {
+	return newRingContext(context.Background(), PKList, opts...)
+}
+
+// newRingContext NewRingContext 的实现，校验公钥环时 ctx 被取消则返回 ctx.Err()
+func newRingContext(ctx context.Context, PKList []*bls.PointG1, opts ...Option) (*RingContext, error) {
+	cfg := NewConfig(opts...)
+
+	index, err := validateRing(ctx, PKList)
+	if err != nil {
+		return nil, err
+	}
+	encoding := appendTranscript(nil, PKList)
+	rc := &RingContext{
+		pkList:   append([]*bls.PointG1(nil), PKList...),
+		encoding: encoding,
+		digest:   ringDigest(encoding),
+		index:    index,
+	}
+
+	if cfg.Precompute {
+		rc.tables = make([][]*bls.PointG1, len(PKList))
+		for i, pk := range PKList {
+			rc.tables[i] = fixedBaseTable(pk)
+		}
+	}
+	return rc, nil
+}
+
+// PKList 返回公钥环，调用方不能修改其中的公钥
+func (rc *RingContext) PKList() []*bls.PointG1 {
+	return rc.pkList
+}
+
+// Len 返回环的大小
+func (rc *RingContext) Len() int {
+	return len(rc.pkList)
+}
+
+// Encoding 返回公钥环的规范编码，即转录编码中 PKList 对应的部分
+func (rc *RingContext) Encoding() []byte {
+	return rc.encoding
+}
+
+// Digest 返回公钥环的摘要，与 RingDigest(PKList) 相同
+func (rc *RingContext) Digest() []byte {
+	return rc.digest
+}
+
+// Precomputed 判断是否构造了定点预计算表
+func (rc *RingContext) Precomputed() bool {
+	return rc.tables != nil
+}
+
+// Index 返回公钥在环中的下标，不在环中时返回 false
+func (rc *RingContext) Index(pk *bls.PointG1) (int, bool) {
+	if pk == nil {
+		return -1, false
+	}
+	i, ok := rc.index[string(marshalG1(pk))]
+	if !ok {
+		return -1, false
+	}
+	return i, true
+}
+
+// FindSigner 校验签名者的密钥对，并返回其公钥在环中的下标
+func (rc *RingContext) FindSigner(SignerS *Signer) (flag int, err error) {
+	if SignerS == nil || SignerS.PrivateKey == nil || SignerS.PublicKey == nil {
+		return -1, ErrNilSigner
+	}
+	flag, ok := rc.Index(SignerS.PublicKey)
+	if !ok {
+		return -1, ErrSignerNotInRing
+	}
+	if !matchKeyPair(SignerS) {
+		return -1, ErrKeyMismatch
+	}
+	return flag, nil
+}
+
+// computeSum 排除 signer_index 后计算 H_i * PK_i + U_i 的和
+// 构造了预计算表时 \sum H_i * PK_i 部分由 fixedBaseSum 算出，否则与 ComputeSum 相同
+func (rc *RingContext) computeSum(H_i []*big.Int, U_i []*bls.PointG1, signer_index int) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	if rc.tables == nil {
+		return ComputeSum(H_i, rc.pkList, U_i, signer_index)
+	}
+
+	tables := make([][]*bls.PointG1, 0, len(H_i))
+	scalars := make([]*big.Int, 0, len(H_i))
+	sum := blsG1.New()
+	for i := range H_i {
+		if i == signer_index {
+			continue
+		}
+		tables = append(tables, rc.tables[i])
+		scalars = append(scalars, H_i[i])
+		blsG1.Add(sum, sum, U_i[i])
+	}
+	return blsG1.Add(sum, sum, fixedBaseSum(tables, scalars))
+}
+
+// fixedBaseTable 计算 pk 的定点预计算表，第 j 项为 2^{8j} \cdot pk，覆盖 blsOrder 的全部比特
+func fixedBaseTable(pk *bls.PointG1) []*bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	table := make([]*bls.PointG1, fixedBaseWindows)
+	table[0] = new(bls.PointG1).Set(pk)
+	for j := 1; j < fixedBaseWindows; j++ {
+		p := new(bls.PointG1).Set(table[j-1])
+		for k := 0; k < fixedBaseWindow; k++ {
+			blsG1.Double(p, p)
+		}
+		table[j] = p
+	}
+	return table
+}
+
+// fixedBaseSum 利用定点预计算表计算 \sum_i scalars[i] \cdot pk_i
+// 标量按字节分块，第 j 块的值 d 使 2^{8j} \cdot pk_i 累加到桶 d 中，最后由 \sum_d d \cdot B_d 得到结果，全程不需要倍点
+func fixedBaseSum(tables [][]*bls.PointG1, scalars []*big.Int) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	buckets := make([]*bls.PointG1, 1<<fixedBaseWindow)
+	buf := make([]byte, fixedBaseWindows)
+	for i, table := range tables {
+		new(big.Int).Mod(scalars[i], blsOrder).FillBytes(buf)
+		for j, p := range table {
+			d := buf[len(buf)-1-j]
+			if d == 0 {
+				continue
+			}
+			if buckets[d] == nil {
+				buckets[d] = blsG1.New()
+			}
+			blsG1.Add(buckets[d], buckets[d], p)
+		}
+	}
+
+	// \sum_d d \cdot B_d = \sum_d (B_d + B_{d+1} + ... + B_{255})
+	running, sum := blsG1.New(), blsG1.New()
+	for d := len(buckets) - 1; d > 0; d-- {
+		if buckets[d] != nil {
+			blsG1.Add(running, running, buckets[d])
+		}
+		blsG1.Add(sum, sum, running)
+	}
+	return sum
+}
@@ -0,0 +1,246 @@
+package RSCP
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// -------------------- 二进制编码 --------------------
+//
+// Sigma 的二进制编码格式如下，整数均为大端序，括号内为字节数：
+//
+//	EncodingVersion (1) || SchemeID (1) || Sigma.Version (1)
+//	|| n (4) || U_1 ... U_n (各 PointSize) || V (G2PointSize)
+//	[ || Tag (PointSize) || m (4) || Scope (m) || W_1 ... W_n (各 PointSize) ]
+//	[ || t (4) || F_1 ... F_{n-t} (各 ScalarSize) ]
+//
+// 方括号内分别为可链接签名与门限签名附带的部分，二者不会同时出现。V 之后没有剩余数据时是普通签名；
+// 门限部分至多 4 + (n-1) \cdot ScalarSize 字节，总是短于可链接部分，因此由剩余数据的长度即可区分。
+// 其中 G1 点以 48 字节、G2 点以 96 字节的压缩形式编码，标量为定长 32 字节。
+
+const (
+	// SchemeName 方案名称，与 RingSig 注册表中的名称一致，JSON 文档中用于标识方案
+	SchemeName = "rscp-bls12381"
+	// EncodingVersion 二进制编码格式的版本
+	EncodingVersion byte = 1
+	// SchemeID 二进制编码中标识 RSCP/BLS12-381 的字节
+	SchemeID byte = 0x04
+	// PointSize G1 点编码后的字节数
+	PointSize = 48
+	// G2PointSize G2 点编码后的字节数
+	G2PointSize = 96
+	// ScalarSize 标量编码后的字节数
+	ScalarSize = 32
+)
+
+// headerSize 编码头部（EncodingVersion、SchemeID、Sigma.Version）的字节数
+const headerSize = 3
+
+// EncodedSize 返回环大小为 n 时签名编码后的字节数
+func EncodedSize(n int) int {
+	return headerSize + 4 + n*PointSize + G2PointSize
+}
+
+// LinkableEncodedSize 返回环大小为 n、范围长度为 m 时可链接签名编码后的字节数
+func LinkableEncodedSize(n, m int) int {
+	return EncodedSize(n) + PointSize + 4 + m + n*PointSize
+}
+
+// ThresholdEncodedSize 返回环大小为 n、门限为 t 时门限签名编码后的字节数
+func ThresholdEncodedSize(n, t int) int {
+	return EncodedSize(n) + 4 + (n-t)*ScalarSize
+}
+
+// MarshalBinary 将签名编码为二进制格式，实现 encoding.BinaryMarshaler
+func (s *Sigma) MarshalBinary() ([]byte, error) {
+	if err := CheckSigma(s); err != nil {
+		return nil, err
+	}
+	if uint64(len(s.UI)) > math.MaxUint32 || uint64(len(s.Scope)) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: 环过大", ErrMalformedSignature)
+	}
+
+	size := EncodedSize(len(s.UI))
+	if s.Tag != nil {
+		size = LinkableEncodedSize(len(s.UI), len(s.Scope))
+	}
+	if s.Threshold > 0 {
+		size = ThresholdEncodedSize(len(s.UI), s.Threshold)
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, EncodingVersion, SchemeID, byte(s.Version))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(s.UI)))
+	for _, v := range s.UI {
+		buf = append(buf, encodePoint(v)...)
+	}
+	buf = append(buf, encodeG2Point(s.V)...)
+	if s.Tag != nil {
+		buf = append(buf, encodePoint(s.Tag)...)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(s.Scope)))
+		buf = append(buf, s.Scope...)
+		for _, w := range s.WI {
+			buf = append(buf, encodePoint(w)...)
+		}
+	}
+	if s.Threshold > 0 {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(s.Threshold))
+		for _, k := range s.F {
+			buf = appendScalar(buf, k)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary 从二进制格式解码签名，实现 encoding.BinaryUnmarshaler
+// 编码版本、方案标识、长度、点与标量的取值均会被校验，并按 CheckSigma 拒绝无效点，失败时 s 保持不变
+func (s *Sigma) UnmarshalBinary(data []byte) error {
+	// 1. 校验头部
+	version, data, err := readHeader(data)
+	if err != nil {
+		return err
+	}
+	if len(data) < 4 {
+		return fmt.Errorf("%w: 数据长度不足", ErrInvalidEncoding)
+	}
+
+	// 2. 读取环大小，并在分配内存前核对总长度
+	n := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	// 可链接签名还须容纳 Tag、范围长度与 n 个 W_i，范围的长度在读取 V 之后核对；更短的剩余数据只能是门限部分
+	base := uint64(n)*PointSize + G2PointSize
+	if uint64(len(data)) < base {
+		return fmt.Errorf("%w: 数据长度与环大小 %d 不符", ErrInvalidEncoding, n)
+	}
+	rest := uint64(len(data)) - base
+	linkable := rest >= PointSize+4+uint64(n)*PointSize
+	threshold := rest != 0 && !linkable
+
+	// 3. 读取 U_i
+	UI := make([]*bls.PointG1, n)
+	for i := range UI {
+		if UI[i], data, err = readPoint(data); err != nil {
+			return err
+		}
+	}
+
+	// 4. 读取 V
+	V, err := readG2Point(data[:G2PointSize])
+	if err != nil {
+		return err
+	}
+	data = data[G2PointSize:]
+	sig := Sigma{UI: UI, V: V, Version: version}
+
+	// 5. 可链接签名：读取 Tag、Scope 与 W_i
+	if linkable {
+		if sig.Tag, data, err = readPoint(data); err != nil {
+			return err
+		}
+		m := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(len(data)) != uint64(m)+uint64(n)*PointSize {
+			return fmt.Errorf("%w: 数据长度与范围长度 %d 不符", ErrInvalidEncoding, m)
+		}
+		sig.Scope = append([]byte{}, data[:m]...)
+		data = data[m:]
+		sig.WI = make([]*bls.PointG1, n)
+		for i := range sig.WI {
+			if sig.WI[i], data, err = readPoint(data); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 6. 门限签名：读取 t 与 F_1, ..., F_{n-t}
+	if threshold {
+		if rest < 4 {
+			return fmt.Errorf("%w: 数据长度不足", ErrInvalidEncoding)
+		}
+		t := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if t == 0 || t > n || uint64(len(data)) != uint64(n-t)*ScalarSize {
+			return fmt.Errorf("%w: 数据长度与门限 %d 不符", ErrInvalidEncoding, t)
+		}
+		sig.Threshold = int(t)
+		sig.F = make([]*big.Int, n-t)
+		for i := range sig.F {
+			if sig.F[i], data, err = readScalar(data); err != nil {
+				return err
+			}
+		}
+	}
+	if err := CheckSigma(&sig); err != nil {
+		return err
+	}
+	*s = sig
+	return nil
+}
+
+// readHeader 校验编码头部，返回签名的转录版本与剩余数据
+func readHeader(data []byte) (TranscriptVersion, []byte, error) {
+	if len(data) < headerSize {
+		return 0, nil, fmt.Errorf("%w: 数据长度不足", ErrInvalidEncoding)
+	}
+	if data[0] != EncodingVersion {
+		return 0, nil, fmt.Errorf("%w: 不支持的编码版本 %d", ErrInvalidEncoding, data[0])
+	}
+	if data[1] != SchemeID {
+		return 0, nil, fmt.Errorf("%w: 方案标识 %#x 不是 RSCP/BLS12-381", ErrInvalidEncoding, data[1])
+	}
+	version := TranscriptVersion(data[2])
+	if !version.Supported() {
+		return 0, nil, fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, version)
+	}
+	return version, data[headerSize:], nil
+}
+
+// encodePoint 将 G1 点编码为 PointSize 字节
+func encodePoint(p *bls.PointG1) []byte {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+
+	// ToCompressed 会原地修改参数，这里对副本编码
+	return blsG1.ToCompressed(new(bls.PointG1).Set(p))
+}
+
+// readPoint 从 data 头部读取一个 G1 点，返回剩余数据
+func readPoint(data []byte) (*bls.PointG1, []byte, error) {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	p, err := blsG1.FromCompressed(data[:PointSize])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return p, data[PointSize:], nil
+}
+
+// readScalar 从 data 头部读取一个定长标量，要求其落在 [0, blsOrder) 内，返回剩余数据
+func readScalar(data []byte) (*big.Int, []byte, error) {
+	k := new(big.Int).SetBytes(data[:ScalarSize])
+	if k.Cmp(blsOrder) >= 0 {
+		return nil, nil, fmt.Errorf("%w: 标量超出 Z_q 范围", ErrInvalidEncoding)
+	}
+	return k, data[ScalarSize:], nil
+}
+
+// encodeG2Point 将 G2 点编码为 G2PointSize 字节
+func encodeG2Point(p *bls.PointG2) []byte {
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	return blsG2.ToCompressed(new(bls.PointG2).Set(p))
+}
+
+// readG2Point 读取一个 G2 点，data 的长度必须恰好为 G2PointSize
+func readG2Point(data []byte) (*bls.PointG2, error) {
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	p, err := blsG2.FromCompressed(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return p, nil
+}
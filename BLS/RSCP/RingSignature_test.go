@@ -1,14 +1,43 @@
 package RSCP
 
 import (
+	"BRFL/KeyPEM"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	bls "github.com/kilic/bls12-381"
+	"io"
+	"math/big"
+	mrand "math/rand"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 var MessageTrue = []byte("这是用来正确签名的信息。")
 var MessageFalse = []byte("这是用来错误验证的信息。")
 
+// newRing 生成 n 个签名者及对应的公钥环
+func newRing(t testing.TB, n int, opts ...Option) ([]*Signer, []*bls.PointG1) {
+	t.Helper()
+	L := make([]*Signer, n)
+	List := make([]*bls.PointG1, n)
+	for i := range L {
+		signer, err := NewSigner(opts...)
+		if err != nil {
+			t.Fatalf("生成签名者失败: %v", err)
+		}
+		L[i] = signer
+		List[i] = signer.PublicKey
+	}
+	return L, List
+}
+
 func TestRingSignature(t *testing.T) {
 	fmt.Println("=== 开始测试 BRFL 环签名方案 ===")
 
@@ -18,14 +47,20 @@ func TestRingSignature(t *testing.T) {
 	var List []*bls.PointG1 // 环签名的公钥列表
 	SignerS := 2
 	for i := 0; i < n; i++ {
-		signer := NewSigner()
+		signer, err := NewSigner()
+		if err != nil {
+			t.Fatalf("生成签名者失败: %v", err)
+		}
 		L = append(L, signer)
 		List = append(List, signer.PublicKey)
 	}
 	fmt.Printf("已生成 %d 个签名者\n", n)
 
 	// 开始签名
-	SignerResult := Sign(MessageTrue, List, L[SignerS])
+	SignerResult, err := Sign(MessageTrue, List, L[SignerS])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
 
 	//fmt.Println("签名结果 Sigma 为：")
 	//fmt.Println("UI 为：", SignerResult.UI)
@@ -37,3 +72,1611 @@ func TestRingSignature(t *testing.T) {
 	Verify2 := Verify(MessageFalse, List, SignerResult)
 	fmt.Println(Verify2)
 }
+
+// sameSigma 判断两个签名是否完全相同
+func sameSigma(a, b *Sigma) bool {
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	for i := range a.UI {
+		if !CompareG1(a.UI[i], b.UI[i]) {
+			return false
+		}
+	}
+	if (a.Tag == nil) != (b.Tag == nil) || len(a.WI) != len(b.WI) || !bytes.Equal(a.Scope, b.Scope) {
+		return false
+	}
+	if a.Tag != nil && !CompareG1(a.Tag, b.Tag) {
+		return false
+	}
+	for i := range a.WI {
+		if !CompareG1(a.WI[i], b.WI[i]) {
+			return false
+		}
+	}
+	if a.Threshold != b.Threshold || len(a.F) != len(b.F) {
+		return false
+	}
+	for i := range a.F {
+		if a.F[i].Cmp(b.F[i]) != 0 {
+			return false
+		}
+	}
+	return blsG2.Equal(a.V, b.V)
+}
+
+// 测试签名时对非法公钥环的检查
+func TestSignInvalidRing(t *testing.T) {
+	_, List := newRing(t, 4)
+	others, _ := newRing(t, 1)
+	outsider := others[0]
+	member := &Signer{PrivateKey: nil, PublicKey: List[0]}
+
+	cases := []struct {
+		name   string
+		ring   []*bls.PointG1
+		signer *Signer
+		want   error
+	}{
+		{"空环", nil, outsider, ErrEmptyRing},
+		{"签名者不在环中", List, outsider, ErrSignerNotInRing},
+		{"环中存在 nil 公钥", append([]*bls.PointG1{outsider.PublicKey, nil}, List...), outsider, ErrNilPublicKey},
+		{"环中存在重复公钥", append([]*bls.PointG1{outsider.PublicKey}, append(List, List[1])...), outsider, ErrDuplicatePublicKey},
+		{"签名者为 nil", List, nil, ErrNilSigner},
+		{"签名者私钥为 nil", List, member, ErrNilSigner},
+		{"私钥与公钥不匹配", List, &Signer{PrivateKey: outsider.PrivateKey, PublicKey: List[0]}, ErrKeyMismatch},
+	}
+
+	for _, c := range cases {
+		sigma, err := Sign(MessageTrue, c.ring, c.signer)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if sigma != nil {
+			t.Errorf("%s: 出错时不应返回签名", c.name)
+		}
+	}
+}
+
+// 测试 VerifyDetailed 返回的拒绝原因
+func TestVerifyDetailed(t *testing.T) {
+	n := 4
+	L, List := newRing(t, n)
+	sigma, err := Sign(MessageTrue, List, L[1])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	short := *sigma
+	short.UI = sigma.UI[:n-1]
+	nilUI := *sigma
+	nilUI.UI = append([]*bls.PointG1(nil), sigma.UI...)
+	nilUI.UI[0] = nil
+	noV := *sigma
+	noV.V = nil
+
+	cases := []struct {
+		name    string
+		message []byte
+		ring    []*bls.PointG1
+		sigma   *Sigma
+		want    error
+	}{
+		{"合法签名", MessageTrue, List, sigma, nil},
+		{"消息不匹配", MessageFalse, List, sigma, ErrPairingMismatch},
+		{"空环", MessageTrue, nil, sigma, ErrEmptyRing},
+		{"环大小不一致", MessageTrue, List, &short, ErrRingSizeMismatch},
+		{"签名为 nil", MessageTrue, List, nil, ErrMalformedSignature},
+		{"U_i 为 nil", MessageTrue, List, &nilUI, ErrMalformedSignature},
+		{"缺少 V", MessageTrue, List, &noV, ErrMalformedSignature},
+	}
+
+	for _, c := range cases {
+		err := VerifyDetailed(c.message, c.ring, c.sigma)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if Verify(c.message, c.ring, c.sigma) != (c.want == nil) {
+			t.Errorf("%s: Verify 与 VerifyDetailed 结果不一致", c.name)
+		}
+	}
+}
+
+// 测试通过 WithRandom 注入随机数来源
+func TestWithRandom(t *testing.T) {
+	// 相同种子的随机数来源应得到完全相同的密钥与签名
+	L1, List1 := newRing(t, 3, WithRandom(mrand.New(mrand.NewSource(1))))
+	L2, List2 := newRing(t, 3, WithRandom(mrand.New(mrand.NewSource(1))))
+	for i := range List1 {
+		if !CompareG1(List1[i], List2[i]) {
+			t.Fatalf("相同随机数来源生成的公钥 %d 不一致", i)
+		}
+	}
+
+	sigma1, err := Sign(MessageTrue, List1, L1[0], WithRandom(mrand.New(mrand.NewSource(2))))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma2, err := Sign(MessageTrue, List2, L2[0], WithRandom(mrand.New(mrand.NewSource(2))))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !sameSigma(sigma1, sigma2) {
+		t.Errorf("相同随机数来源生成的签名不一致")
+	}
+	if !Verify(MessageTrue, List1, sigma1) {
+		t.Errorf("注入随机数来源生成的签名验证失败")
+	}
+
+	// 随机数来源读取失败时应返回错误而不是 panic
+	broken := WithRandom(iotest.ErrReader(errors.New("熵源不可用")))
+	if _, err := NewSigner(broken); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("NewSigner: 期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+	if _, err := Sign(MessageTrue, List1, L1[0], broken); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("Sign: 期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+}
+
+// 测试确定性签名模式：相同输入两次签名的结果逐字节相同
+func TestDeterministicSign(t *testing.T) {
+	L, List := newRing(t, 4, WithRandom(mrand.New(mrand.NewSource(3))))
+
+	sigma1, err := Sign(MessageTrue, List, L[2], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	// 确定性模式下不读取 Random，即使来源已损坏也应得到相同结果
+	broken := WithRandom(iotest.ErrReader(errors.New("熵源不可用")))
+	sigma2, err := Sign(MessageTrue, List, L[2], broken, WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !sameSigma(sigma1, sigma2) {
+		t.Errorf("确定性模式下两次签名结果不一致")
+	}
+	if !Verify(MessageTrue, List, sigma1) {
+		t.Errorf("确定性签名验证失败")
+	}
+
+	// 消息或额外熵不同时，派生出的签名应不同
+	sigma3, err := Sign(MessageFalse, List, L[2], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma4, err := Sign(MessageTrue, List, L[2], WithDeterministic([]byte("额外熵")))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if sameSigma(sigma1, sigma3) || sameSigma(sigma1, sigma4) {
+		t.Errorf("不同输入派生出了相同的签名")
+	}
+	if !Verify(MessageTrue, List, sigma4) {
+		t.Errorf("混入额外熵的确定性签名验证失败")
+	}
+
+	// 转录版本不同时派生出的随机数也不同，否则同一消息的两个签名会泄露私钥
+	seen := make(map[string]TranscriptVersion)
+	for _, v := range []TranscriptVersion{TranscriptLegacy, TranscriptV1, TranscriptV2} {
+		nonce := make([]byte, 32)
+		if _, err := io.ReadFull(NewNonceReader(L[2].PrivateKey, MessageTrue, List, NewConfig(WithTranscript(v))), nonce); err != nil {
+			t.Fatalf("读取随机数失败: %v", err)
+		}
+		if u, ok := seen[string(nonce)]; ok {
+			t.Errorf("版本 %d 与 %d 派生出了相同的随机数", v, u)
+		}
+		seen[string(nonce)] = v
+	}
+
+	// 已知答案测试：固定的密钥环与消息在各转录版本下的确定性签名编码，随机数的派生方式改变时此处会失败
+	for _, c := range []struct {
+		version TranscriptVersion
+		want    string
+	}{
+		{
+			version: TranscriptLegacy,
+			want: "01040000000004b31a647743ccf34f73ddc63264c596051f112bf8ac7bcc98cfa76d0f8a4a67765435b34e5724348c4d" +
+				"ae33e92e9ac99d893eb85b1634cdd0f37c9f0d5177fa15066ebb2584c8af037356ed1e6175c656ea5bb4a75d868e692c" +
+				"17fcc7248d3762b56277d1a4adb6f5abce5cdba8b2b7fb0ff44f2b1dbfd3de9b1a0236be6fbcfd7b4e3f71824e495a7e" +
+				"d492f9c370f0fd989c65decb0c76242a1a6818d30a1d88112a5c5676000363b543bc8bb6ee6f7df00f14754d16d02a9b" +
+				"be502783371baeabd1272743de6510192a061c98f93d06cac05ff9f1ecd78ab24514e3300d9e7cc0418258bc7f5b4ab8" +
+				"ace92fe0e89ffd04f4dec78ea0198caa55ac3e26b23c0acb53ab32f954f917850c7119218f77cdabd64b49760243ad94" +
+				"c932553027b853",
+		},
+		{
+			version: TranscriptV1,
+			want: "01040100000004a8afbd39d3555a4ccdaa950f58a3bd47bb73cb2dbcb10bab056e28c9fa32618408a32802c50893d099" +
+				"86546d8c4cf8e2b625704cf9683b719f5b2b2e3a4cc6f353e7cc4488b3e252b81ee8027b4fc73528c20cd08d91596144" +
+				"6fb9b0503e4edd9964832c6548340583e42138892f5ceeb88a2bfdd007991987f87d67e6cc7a8b815fdbac6711d177b9" +
+				"202c603c09b40196e8714fa720d8c0699761c2e9c36c50397f7b5ba113d0a55c84256ca4b07a3f80302a962f4f7675cc" +
+				"9f927faba367ffa3c58846d7e4f42e388ef6d640ae29da0b87abff5dd2ff540ba8d3d130dd5b7c558d41c14259e8e650" +
+				"f1a64f314ca0eb103139132bafdb644ce550a2056ecf0a3ef301bdc74bb5bb11a53656930bb6ee4969810cce0e3c921b" +
+				"e85e3e854cdf4e",
+		},
+		{
+			version: TranscriptV2,
+			want: "010402000000048d20fb5552f1b54cee69eaaef8bb431b678d73fe24e6d03da1b318b567e4877ba1ac95e4b2d00615b9" +
+				"9955545e1ae5f78d6033420f9367caf15fdea207501d2778d17b47fb4d9724027d989d700553a727bcb2d6d0f41283a2" +
+				"417dcb6921baef93048e660da4ac2eed776f2f986eee8dcf9191ae70d3dfd206d8c936e2619ecd0862741f6c164940f6" +
+				"89d132f1f07d98a351bb8d365150e1b51e62a3c71e844980ca54a626ef9e0b1be2e1cab3a3aeed5238630525c3f614b7" +
+				"8321af0ff24bfc96500d2bb7d821087178650e20eaf4557b01321f1e33dda009fff166328472974b47256c91a2f548c2" +
+				"ddcef3c34e8aec15c095049c0e9f1638a463eda8d549d0cda4b584cb32af435cc70d501522f308dcd440b7f78b909992" +
+				"68815aab71afd2",
+		},
+	} {
+		sigma, err := Sign(MessageTrue, List, L[2], WithTranscript(c.version), WithDeterministic(nil))
+		if err != nil {
+			t.Fatalf("版本 %d: 签名失败: %v", c.version, err)
+		}
+		data, err := sigma.MarshalBinary()
+		if err != nil {
+			t.Fatalf("版本 %d: 编码失败: %v", c.version, err)
+		}
+		if got := hex.EncodeToString(data); got != c.want {
+			t.Errorf("版本 %d: 签名编码与已知答案不同: %s", c.version, got)
+		}
+	}
+}
+
+// 测试哈希转录编码的版本选择、域分离与旧版兼容
+func TestTranscriptVersions(t *testing.T) {
+	L, List := newRing(t, 3)
+
+	// 旧版编码的签名需要显式允许才能通过验证
+	legacy, err := Sign(MessageTrue, List, L[0], WithTranscript(TranscriptLegacy))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, legacy); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, legacy, WithLegacyTranscript()); err != nil {
+		t.Errorf("旧版签名验证失败: %v", err)
+	}
+
+	// v1 编码的签名无需额外选项即可通过验证，但改写为 v2 后不再合法
+	v1, err := Sign(MessageTrue, List, L[0], WithTranscript(TranscriptV1))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, v1); err != nil {
+		t.Errorf("v1 签名验证失败: %v", err)
+	}
+	upgraded := *v1
+	upgraded.Version = TranscriptV2
+	if Verify(MessageTrue, List, &upgraded) {
+		t.Errorf("篡改版本号的签名通过了验证")
+	}
+
+	// 篡改版本号后签名不再合法
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if sigma.Version != TranscriptV2 {
+		t.Errorf("默认转录版本为 %d，期望 %d", sigma.Version, TranscriptV2)
+	}
+	downgraded := *sigma
+	downgraded.Version = TranscriptLegacy
+	if Verify(MessageTrue, List, &downgraded, WithLegacyTranscript()) {
+		t.Errorf("篡改版本号的签名通过了验证")
+	}
+
+	// 未知版本
+	if _, err := Sign(MessageTrue, List, L[0], WithTranscript(9)); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("Sign: 期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+	unknown := *sigma
+	unknown.Version = 9
+	if err := VerifyDetailed(MessageTrue, List, &unknown); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("VerifyDetailed: 期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+
+	// v1 编码带长度前缀与域分离标签，旧版编码则会发生碰撞
+	if HashToZq([]byte("ab"), []byte("c")).Cmp(HashToZq([]byte("a"), []byte("bc"))) != 0 {
+		t.Errorf("旧版编码应当对拼接结果相同的输入给出相同哈希")
+	}
+	if HashToZqV1(TagHi, []byte("ab"), []byte("c")).Cmp(HashToZqV1(TagHi, []byte("a"), []byte("bc"))) == 0 {
+		t.Errorf("v1 编码对不同输入给出了相同哈希")
+	}
+	if HashToZqV1(TagHi, MessageTrue).Cmp(HashToZqV1("other", MessageTrue)) == 0 {
+		t.Errorf("不同标签给出了相同哈希")
+	}
+
+	// v2 与 v1 的域分离前缀不同；环摘要依赖公钥顺序
+	if HashToZqV1(TagHi, MessageTrue).Cmp(HashToZqV2(TagHi, MessageTrue)) == 0 {
+		t.Errorf("v1 与 v2 编码给出了相同哈希")
+	}
+	swapped := append([]*bls.PointG1{List[1], List[0]}, List[2:]...)
+	if bytes.Equal(RingDigest(List), RingDigest(swapped)) {
+		t.Errorf("交换公钥顺序后环摘要不变")
+	}
+}
+
+// 测试签名二进制编码的往返与非法输入
+func TestMarshalBinary(t *testing.T) {
+	L, List := newRing(t, 5)
+	sigma, err := Sign(MessageTrue, List, L[3])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	data, err := sigma.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if len(data) != EncodedSize(len(List)) {
+		t.Errorf("编码长度为 %d，期望 %d", len(data), EncodedSize(len(List)))
+	}
+
+	decoded := new(Sigma)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if !sameSigma(sigma, decoded) || decoded.Version != sigma.Version {
+		t.Errorf("解码结果与原签名不一致")
+	}
+	if err := VerifyDetailed(MessageTrue, List, decoded); err != nil {
+		t.Errorf("解码后的签名验证失败: %v", err)
+	}
+	again, _ := decoded.MarshalBinary()
+	if !bytes.Equal(data, again) {
+		t.Errorf("重新编码的结果不一致")
+	}
+
+	// 非法输入
+	badPoint := append([]byte(nil), data...)
+	badPoint[headerSize+4+PointSize-1] ^= 0x01
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"空数据", nil},
+		{"截断", data[:len(data)-1]},
+		{"多余字节", append(append([]byte(nil), data...), 0x00)},
+		{"编码版本错误", append([]byte{EncodingVersion + 1}, data[1:]...)},
+		{"方案标识错误", append([]byte{EncodingVersion, SchemeID + 1}, data[2:]...)},
+		{"转录版本未知", append([]byte{EncodingVersion, SchemeID, 9}, data[3:]...)},
+		{"点不在曲线上", badPoint},
+	}
+
+	for _, c := range cases {
+		out := new(Sigma)
+		if err := out.UnmarshalBinary(c.data); err == nil {
+			t.Errorf("%s: 非法编码被成功解码", c.name)
+		}
+		if out.UI != nil {
+			t.Errorf("%s: 解码失败时修改了签名", c.name)
+		}
+	}
+
+	if _, err := (&Sigma{}).MarshalBinary(); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+}
+
+// 测试签名、公钥与公钥环文档的 JSON 编码
+func TestJSON(t *testing.T) {
+	L, List := newRing(t, 3)
+	sigma, err := Sign(MessageTrue, List, L[1])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	// 签名：字段名固定，不依赖 Go 结构体即可读取
+	data, err := json.Marshal(sigma)
+	if err != nil {
+		t.Fatalf("编码签名失败: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("解析 JSON 失败: %v", err)
+	}
+	for _, name := range []string{"scheme", "version", "ui", "v"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("签名 JSON 缺少字段 %q", name)
+		}
+	}
+	if fields["scheme"] != SchemeName {
+		t.Errorf("签名 JSON 的方案为 %v，期望 %s", fields["scheme"], SchemeName)
+	}
+
+	var decoded Sigma
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("解码签名失败: %v", err)
+	}
+	if !sameSigma(sigma, &decoded) || !Verify(MessageTrue, List, &decoded) {
+		t.Errorf("解码后的签名与原签名不一致")
+	}
+
+	// 公钥：私钥不得写入 JSON
+	data, err = json.Marshal(L[0])
+	if err != nil {
+		t.Fatalf("编码公钥失败: %v", err)
+	}
+	if bytes.Contains(data, []byte(L[0].PrivateKey.Text(16))) {
+		t.Errorf("公钥 JSON 中包含私钥")
+	}
+	var pub Signer
+	if err := json.Unmarshal(data, &pub); err != nil {
+		t.Fatalf("解码公钥失败: %v", err)
+	}
+	if pub.PrivateKey != nil || !CompareG1(pub.PublicKey, L[0].PublicKey) {
+		t.Errorf("解码后的公钥不一致")
+	}
+
+	// 公钥环文档
+	ring := &Ring{Members: []RingMember{
+		{Label: "alice", PublicKey: List[0]},
+		{PublicKey: List[1]},
+		{Label: "carol", PublicKey: List[2]},
+	}}
+	data, err = json.Marshal(ring)
+	if err != nil {
+		t.Fatalf("编码公钥环失败: %v", err)
+	}
+	var decodedRing Ring
+	if err := json.Unmarshal(data, &decodedRing); err != nil {
+		t.Fatalf("解码公钥环失败: %v", err)
+	}
+	if decodedRing.Members[0].Label != "alice" || decodedRing.Members[1].Label != "" {
+		t.Errorf("公钥环标签不一致")
+	}
+	if !Verify(MessageTrue, decodedRing.PKList(), sigma) {
+		t.Errorf("使用解码后的公钥环验证失败")
+	}
+
+	// 非法输入
+	pk := hex.EncodeToString(encodePoint(List[0]))
+	cases := []struct {
+		name string
+		data string
+		into interface{}
+	}{
+		{"方案不符", `{"scheme":"other","pk":"` + pk + `"}`, new(Signer)},
+		{"未知字段", `{"scheme":"` + SchemeName + `","pk":"` + pk + `","sk":"00"}`, new(Signer)},
+		{"非十六进制", `{"scheme":"` + SchemeName + `","pk":"zz"}`, new(Signer)},
+		{"点长度错误", `{"scheme":"` + SchemeName + `","pk":"` + pk[2:] + `"}`, new(Signer)},
+		{"空环", `{"scheme":"` + SchemeName + `","members":[]}`, new(Ring)},
+		{"重复公钥", `{"scheme":"` + SchemeName + `","members":[{"pk":"` + pk + `"},{"pk":"` + pk + `"}]}`, new(Ring)},
+		{"签名缺少 ui", `{"scheme":"` + SchemeName + `","version":1}`, new(Sigma)},
+	}
+	for _, c := range cases {
+		if err := json.Unmarshal([]byte(c.data), c.into); err == nil {
+			t.Errorf("%s: 非法 JSON 被成功解码", c.name)
+		}
+	}
+}
+
+// 测试密钥的 PEM 导出与导入
+func TestPEM(t *testing.T) {
+	L, List := newRing(t, 3)
+	passphrase := []byte("口令")
+
+	data, err := L[0].MarshalPrivateKeyPEM(passphrase, WithKDFIterations(1000))
+	if err != nil {
+		t.Fatalf("导出私钥失败: %v", err)
+	}
+	signer, err := ParsePrivateKeyPEM(data, passphrase)
+	if err != nil {
+		t.Fatalf("导入私钥失败: %v", err)
+	}
+	if signer.PrivateKey.Cmp(L[0].PrivateKey) != 0 || !CompareG1(signer.PublicKey, L[0].PublicKey) {
+		t.Errorf("导入的密钥与原密钥不一致")
+	}
+	sigma, err := Sign(MessageTrue, List, signer)
+	if err != nil || !Verify(MessageTrue, List, sigma) {
+		t.Errorf("使用导入的私钥签名失败: %v", err)
+	}
+	if _, err := ParsePrivateKeyPEM(data, []byte("错误口令")); !errors.Is(err, KeyPEM.ErrDecryption) {
+		t.Errorf("期望错误 %v，实际为 %v", KeyPEM.ErrDecryption, err)
+	}
+
+	data, err = L[1].MarshalPublicKeyPEM()
+	if err != nil {
+		t.Fatalf("导出公钥失败: %v", err)
+	}
+	pk, err := ParsePublicKeyPEM(data)
+	if err != nil || !CompareG1(pk, L[1].PublicKey) {
+		t.Errorf("导入的公钥与原公钥不一致: %v", err)
+	}
+	if _, err := ParsePrivateKeyPEM(data, passphrase); !errors.Is(err, KeyPEM.ErrInvalidPEM) {
+		t.Errorf("期望错误 %v，实际为 %v", KeyPEM.ErrInvalidPEM, err)
+	}
+}
+
+// fp2 表示 F_{p^2} 中的元素 a·i + b（i^2 = -1），仅用于在测试中构造曲线点
+type fp2 struct{ a, b *big.Int }
+
+func fp2Mul(x, y fp2, p *big.Int) fp2 {
+	a := new(big.Int).Add(new(big.Int).Mul(x.a, y.b), new(big.Int).Mul(x.b, y.a))
+	b := new(big.Int).Sub(new(big.Int).Mul(x.b, y.b), new(big.Int).Mul(x.a, y.a))
+	return fp2{a.Mod(a, p), b.Mod(b, p)}
+}
+
+func fp2Exp(x fp2, e, p *big.Int) fp2 {
+	r := fp2{big.NewInt(0), big.NewInt(1)}
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = fp2Mul(r, r, p)
+		if e.Bit(i) == 1 {
+			r = fp2Mul(r, x, p)
+		}
+	}
+	return r
+}
+
+// fp2Sqrt 计算 p ≡ 3 (mod 4) 时 F_{p^2} 上的平方根，x 不是平方元时返回 false
+func fp2Sqrt(x fp2, p *big.Int) (fp2, bool) {
+	e := new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(3)), 2)
+	a1 := fp2Exp(x, e, p)
+	alpha := fp2Mul(fp2Mul(a1, a1, p), x, p)
+	x0 := fp2Mul(a1, x, p)
+
+	var r fp2
+	if alpha.a.Sign() == 0 && alpha.b.Cmp(new(big.Int).Sub(p, big.NewInt(1))) == 0 {
+		r = fp2{x0.b, new(big.Int).Sub(p, x0.a)}
+		r.b.Mod(r.b, p)
+	} else {
+		e = new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(1)), 1)
+		r = fp2Mul(fp2Exp(fp2{alpha.a, new(big.Int).Add(alpha.b, big.NewInt(1))}, e, p), x0, p)
+	}
+	sq := fp2Mul(r, r, p)
+	return r, sq.a.Cmp(x.a) == 0 && sq.b.Cmp(x.b) == 0
+}
+
+// g2Candidates 依次返回扭曲线 y^2 = x^3 + B 上的点 (x, y) 的定长编码 x.a || x.b || y.a || y.b
+func g2Candidates(p *big.Int, B fp2, size int, yield func([]byte) bool) {
+	for k := int64(1); ; k++ {
+		x := fp2{big.NewInt(0), big.NewInt(k)}
+		rhs := fp2Mul(fp2Mul(x, x, p), x, p)
+		rhs = fp2{new(big.Int).Add(rhs.a, B.a), new(big.Int).Add(rhs.b, B.b)}
+		rhs.a.Mod(rhs.a, p)
+		rhs.b.Mod(rhs.b, p)
+		y, ok := fp2Sqrt(rhs, p)
+		if !ok {
+			continue
+		}
+		var buf []byte
+		for _, v := range []*big.Int{x.a, x.b, y.a, y.b} {
+			buf = append(buf, v.FillBytes(make([]byte, size))...)
+		}
+		if yield(buf) {
+			return
+		}
+	}
+}
+
+// blsP 为 BLS12-381 的基域模数
+var blsP, _ = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+
+// nonSubgroupG1 构造一个在曲线 y^2 = x^3 + 4 上、但不属于素数阶子群的点
+func nonSubgroupG1(t *testing.T) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	t.Helper()
+	exp := new(big.Int).Rsh(new(big.Int).Add(blsP, big.NewInt(1)), 2)
+	for x := int64(1); ; x++ {
+		X := big.NewInt(x)
+		rhs := new(big.Int).Exp(X, big.NewInt(3), blsP)
+		rhs.Add(rhs, big.NewInt(4)).Mod(rhs, blsP)
+		Y := new(big.Int).Exp(rhs, exp, blsP)
+		if new(big.Int).Exp(Y, big.NewInt(2), blsP).Cmp(rhs) != 0 {
+			continue
+		}
+		point, err := blsG1.FromBytes(append(X.FillBytes(make([]byte, 48)), Y.FillBytes(make([]byte, 48))...))
+		if err != nil {
+			t.Fatalf("构造曲线点失败: %v", err)
+		}
+		if !blsG1.InCorrectSubgroup(point) {
+			return point
+		}
+	}
+}
+
+// nonSubgroupG2 构造一个在扭曲线 y^2 = x^3 + 4(i + 1) 上、但不属于素数阶子群的点
+func nonSubgroupG2(t *testing.T) *bls.PointG2 {
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	t.Helper()
+	var point *bls.PointG2
+	g2Candidates(blsP, fp2{big.NewInt(4), big.NewInt(4)}, 48, func(b []byte) bool {
+		p, err := blsG2.FromBytes(b)
+		if err != nil {
+			t.Fatalf("构造曲线点失败: %v", err)
+		}
+		if blsG2.InCorrectSubgroup(p) {
+			return false
+		}
+		point = p
+		return true
+	})
+	return point
+}
+
+// 测试对无效点与无穷远点的拒绝
+func TestPointValidation(t *testing.T) {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	L, List := newRing(t, 3)
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	bad := nonSubgroupG1(t)
+	badG2 := nonSubgroupG2(t)
+
+	if err := ValidatePoint(bad); !errors.Is(err, ErrInvalidPoint) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrInvalidPoint, err)
+	}
+	if err := ValidateG2Point(sigma.V); err != nil {
+		t.Errorf("有效的 V 未通过校验: %v", err)
+	}
+	if err := ValidateG2Point(badG2); !errors.Is(err, ErrInvalidPoint) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrInvalidPoint, err)
+	}
+
+	// 1. 公钥环中的无穷远点与子群外的点
+	for _, c := range []struct {
+		name string
+		pk   *bls.PointG1
+		want error
+	}{
+		{"无穷远点", blsG1.Zero(), ErrIdentityPoint},
+		{"子群外的点", bad, ErrInvalidPoint},
+	} {
+		ring := []*bls.PointG1{List[0], List[1], c.pk}
+		if _, err := Sign(MessageTrue, ring, L[0]); !errors.Is(err, c.want) {
+			t.Errorf("签名 %s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if err := VerifyDetailed(MessageTrue, ring, sigma); !errors.Is(err, c.want) {
+			t.Errorf("验证 %s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+	}
+
+	// 2. 签名中的无穷远点与子群外的点
+	for _, c := range []struct {
+		name   string
+		mutate func(s *Sigma)
+		want   error
+	}{
+		{"V 为无穷远点", func(s *Sigma) { s.V = blsG2.Zero() }, ErrIdentityPoint},
+		{"V 不在子群中", func(s *Sigma) { s.V = badG2 }, ErrInvalidPoint},
+		{"U_i 不在子群中", func(s *Sigma) { s.UI = []*bls.PointG1{sigma.UI[0], bad, sigma.UI[2]} }, ErrInvalidPoint},
+	} {
+		forged := *sigma
+		c.mutate(&forged)
+		if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+	}
+
+	// 3. 解码器拒绝子群外的点与无穷远点
+	data, err := sigma.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	forged := append([]byte(nil), data...)
+	copy(forged[len(forged)-G2PointSize:], encodeG2Point(blsG2.Zero()))
+	if err := new(Sigma).UnmarshalBinary(forged); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("二进制解码: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+	copy(forged[len(forged)-G2PointSize:], encodeG2Point(badG2))
+	if err := new(Sigma).UnmarshalBinary(forged); err == nil {
+		t.Errorf("二进制解码: 子群外的 V 被成功解码")
+	}
+
+	data, err = json.Marshal(sigma)
+	if err != nil {
+		t.Fatalf("JSON 编码失败: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("JSON 解码失败: %v", err)
+	}
+	fields["v"] = hex.EncodeToString(encodeG2Point(blsG2.Zero()))
+	data, _ = json.Marshal(fields)
+	if err := json.Unmarshal(data, new(Sigma)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码签名: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+
+	identity := hex.EncodeToString(encodePoint(blsG1.Zero()))
+	data = []byte(fmt.Sprintf(`{"scheme":%q,"pk":%q}`, SchemeName, identity))
+	if err := json.Unmarshal(data, new(Signer)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码公钥: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+	data = []byte(fmt.Sprintf(`{"scheme":%q,"members":[{"label":"a","pk":%q}]}`, SchemeName, identity))
+	if err := json.Unmarshal(data, new(Ring)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码公钥环: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+
+	data = KeyPEM.EncodePublicKey(SchemeName, CurveName, encodePoint(blsG1.Zero()))
+	if _, err := ParsePublicKeyPEM(data); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("PEM 解码公钥: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+}
+
+// naiveSumG1 逐个标量乘后累加，作为 MultiScalarMulG1 的对照
+func naiveSumG1(points []*bls.PointG1, scalars []*big.Int) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	sum := blsG1.Zero()
+	for i, p := range points {
+		sum = AddG1(sum, ScalarMulG1(p, new(big.Int).Mod(scalars[i], blsOrder)))
+	}
+	return sum
+}
+
+// randomMSMInput 生成 n 个随机点与随机标量
+func randomMSMInput(n int) ([]*bls.PointG1, []*big.Int) {
+	points := make([]*bls.PointG1, n)
+	scalars := make([]*big.Int, n)
+	for i := range points {
+		points[i] = RandomPointG1()
+		scalars[i] = RandomZq()
+	}
+	return points, scalars
+}
+
+// 测试多标量乘法与逐个标量乘的结果一致
+func TestMultiScalarMulG1(t *testing.T) {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	for _, n := range []int{0, 1, 5, 31, 32, 100} {
+		points, scalars := randomMSMInput(n)
+		if n >= 5 {
+			// 相同的点与标量落入同一个桶、零标量、负标量、超出 Order 的标量、无穷远点
+			points[1], scalars[1] = points[0], new(big.Int).Set(scalars[0])
+			scalars[2] = big.NewInt(0)
+			scalars[3] = big.NewInt(-5)
+			scalars[4] = new(big.Int).Add(blsOrder, big.NewInt(7))
+			points[n-1] = blsG1.Zero()
+		}
+		if !CompareG1(MultiScalarMulG1(points, scalars), naiveSumG1(points, scalars)) {
+			t.Errorf("n = %d: 多标量乘法结果与逐个计算不一致", n)
+		}
+	}
+}
+
+// 对比逐个标量乘与多标量乘法在不同环大小下的耗时
+func BenchmarkMultiScalarMulG1(b *testing.B) {
+	for _, n := range []int{16, 256, 4096} {
+		points, scalars := randomMSMInput(n)
+		b.Run(fmt.Sprintf("naive/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				naiveSumG1(points, scalars)
+			}
+		})
+		b.Run(fmt.Sprintf("msm/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiScalarMulG1(points, scalars)
+			}
+		})
+	}
+}
+
+// 测试单次多配对校验 e(P, V) = e(Sum, Q)
+func TestVerifyPairing(t *testing.T) {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	k := RandomZq()
+	Sum := ScalarMulG1(blsG1.One(), k)
+	V := blsG2.New()
+	blsG2.MulScalarBig(V, blsG2.One(), k)
+	if !VerifyPairing(Sum, V) {
+		t.Errorf("e(P, kQ) = e(kP, Q) 校验失败")
+	}
+	if VerifyPairing(AddG1(Sum, blsG1.One()), V) {
+		t.Errorf("错误的 Sum 通过了配对校验")
+	}
+	if VerifyPairing(blsG1.Zero(), V) {
+		t.Errorf("Sum 为无穷远点时通过了配对校验")
+	}
+}
+
+// 测试批量验证：共享与不同的公钥环、二分定位不合法签名的下标
+func TestVerifyBatch(t *testing.T) {
+	L1, ring1 := newRing(t, 4)
+	L2, ring2 := newRing(t, 3)
+
+	var Messages [][]byte
+	var PKLists [][]*bls.PointG1
+	var Sigmas []*Sigma
+	for j := 0; j < 6; j++ {
+		msg := []byte(fmt.Sprintf("批量消息 %d", j))
+		ring, signer := ring1, L1[j%len(L1)]
+		if j >= 4 {
+			ring, signer = ring2, L2[j%len(L2)]
+		}
+		sigma, err := Sign(msg, ring, signer)
+		if err != nil {
+			t.Fatalf("签名 %d 失败: %v", j, err)
+		}
+		Messages = append(Messages, msg)
+		PKLists = append(PKLists, ring)
+		Sigmas = append(Sigmas, sigma)
+	}
+
+	results, err := VerifyBatch(Messages, PKLists, Sigmas)
+	if err != nil || len(results) != len(Sigmas) {
+		t.Fatalf("合法批次验证失败: %v %v", results, err)
+	}
+	for j, r := range results {
+		if r != nil {
+			t.Errorf("签名 %d 验证失败: %v", j, r)
+		}
+	}
+	if results, err := VerifyBatch(nil, nil, nil); err != nil || len(results) != 0 {
+		t.Errorf("空批次验证失败: %v %v", results, err)
+	}
+
+	// 篡改消息、替换 V、签名缺失：应当恰好报告这三个下标
+	badMessages := append([][]byte(nil), Messages...)
+	badMessages[1] = MessageFalse
+	badSigmas := append([]*Sigma(nil), Sigmas...)
+	swapped := *Sigmas[4]
+	swapped.V = Sigmas[5].V
+	badSigmas[4] = &swapped
+	badSigmas[5] = nil
+	results, err = VerifyBatch(badMessages, PKLists, badSigmas)
+	if err != nil {
+		t.Fatalf("批量验证出错: %v", err)
+	}
+	want := map[int]error{1: ErrPairingMismatch, 4: ErrPairingMismatch, 5: ErrMalformedSignature}
+	for j, r := range results {
+		if !errors.Is(r, want[j]) {
+			t.Errorf("签名 %d: 期望错误 %v，实际为 %v", j, want[j], r)
+		}
+		if (r == nil) != Verify(badMessages[j], PKLists[j], badSigmas[j]) {
+			t.Errorf("签名 %d: 批量结果与单独验证不一致", j)
+		}
+	}
+
+	// 非法的公钥环按下标报告原因，不影响同批的其他签名
+	results, err = VerifyBatch(Messages[:2], [][]*bls.PointG1{PKLists[0], nil}, Sigmas[:2])
+	if err != nil || results[0] != nil || !errors.Is(results[1], ErrEmptyRing) {
+		t.Errorf("批量验证结果为 %v, %v", results, err)
+	}
+
+	if _, err := VerifyBatch(Messages[:2], PKLists, Sigmas); !errors.Is(err, ErrBatchLengthMismatch) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrBatchLengthMismatch, err)
+	}
+	if _, err := VerifyBatch(Messages, PKLists, Sigmas, WithRandom(iotest.ErrReader(errors.New("无熵")))); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+}
+
+// 测试 RingContext 的构造与签名者查找，以及带预计算表时与 Sign/Verify 的互通
+func TestRingContext(t *testing.T) {
+	L, List := newRing(t, 5)
+	others, _ := newRing(t, 1)
+
+	if _, err := NewRingContext(nil); !errors.Is(err, ErrEmptyRing) {
+		t.Errorf("空环: 期望错误 %v，实际为 %v", ErrEmptyRing, err)
+	}
+	if _, err := NewRingContext(append(List, List[0])); !errors.Is(err, ErrDuplicatePublicKey) {
+		t.Errorf("重复公钥: 期望错误 %v，实际为 %v", ErrDuplicatePublicKey, err)
+	}
+
+	for _, precompute := range []bool{false, true} {
+		var opts []Option
+		if precompute {
+			opts = append(opts, WithPrecompute())
+		}
+		rc, err := NewRingContext(List, opts...)
+		if err != nil {
+			t.Fatalf("构造 RingContext 失败: %v", err)
+		}
+		if rc.Len() != len(List) || rc.Precomputed() != precompute {
+			t.Errorf("环大小为 %d，预计算状态为 %v", rc.Len(), rc.Precomputed())
+		}
+		if !bytes.Equal(rc.Digest(), RingDigest(List)) {
+			t.Errorf("环摘要与 RingDigest 不一致")
+		}
+		for i, pk := range List {
+			if j, ok := rc.Index(pk); !ok || j != i {
+				t.Errorf("公钥 %d 的下标为 %d", i, j)
+			}
+		}
+		if _, err := SignWithRing(MessageTrue, rc, others[0]); !errors.Is(err, ErrSignerNotInRing) {
+			t.Errorf("期望错误 %v，实际为 %v", ErrSignerNotInRing, err)
+		}
+
+		// 使用 RingContext 的签名与普通签名可以互相验证
+		sigma, err := SignWithRing(MessageTrue, rc, L[2])
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		if err := VerifyDetailed(MessageTrue, List, sigma); err != nil {
+			t.Errorf("SignWithRing 的签名验证失败: %v", err)
+		}
+		plain, err := Sign(MessageTrue, List, L[4], WithTranscript(TranscriptV1))
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		if err := VerifyDetailedWithRing(MessageTrue, rc, plain); err != nil {
+			t.Errorf("VerifyDetailedWithRing 验证失败: %v", err)
+		}
+		if VerifyWithRing(MessageFalse, rc, plain) {
+			t.Errorf("错误消息通过了验证")
+		}
+
+		// 环求和与 ComputeSum 一致
+		HiList := make([]*big.Int, len(List))
+		UiList := make([]*bls.PointG1, len(List))
+		for i := range List {
+			HiList[i] = RandomZq()
+			UiList[i] = RandomPointG1()
+		}
+		for _, skip := range []int{-1, 3} {
+			if !CompareG1(rc.computeSum(HiList, UiList, skip), ComputeSum(HiList, List, UiList, skip)) {
+				t.Errorf("预计算状态为 %v 时环求和结果不一致，排除下标 %d", precompute, skip)
+			}
+		}
+	}
+}
+
+// 比较 Verify 与复用 RingContext（含预计算表）的验证开销
+func BenchmarkVerifyWithRing(b *testing.B) {
+	L := make([]*Signer, 256)
+	List := make([]*bls.PointG1, len(L))
+	for i := range L {
+		L[i], _ = NewSigner()
+		List[i] = L[i].PublicKey
+	}
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		b.Fatalf("签名失败: %v", err)
+	}
+
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Verify(MessageTrue, List, sigma)
+		}
+	})
+	for _, precompute := range []bool{false, true} {
+		var opts []Option
+		if precompute {
+			opts = append(opts, WithPrecompute())
+		}
+		rc, _ := NewRingContext(List, opts...)
+		b.Run(fmt.Sprintf("RingContext/precompute=%v", precompute), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				VerifyWithRing(MessageTrue, rc, sigma)
+			}
+		})
+	}
+}
+
+// 多个协程共享同一个公钥环、签名与 RingContext 并发签名、验证与编码，配合 go test -race 检查数据竞争
+func TestConcurrentSignVerify(t *testing.T) {
+	L, List := newRing(t, 4)
+	shared, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	rc, err := NewRingContext(List, WithPrecompute())
+	if err != nil {
+		t.Fatalf("构造 RingContext 失败: %v", err)
+	}
+
+	workers, rounds := 8, 3
+	errs := make(chan error, workers*rounds)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				msg := []byte(fmt.Sprintf("并发消息 %d-%d", w, r))
+				sigma, err := SignWithRing(msg, rc, L[(w+r)%len(L)])
+				if err != nil {
+					errs <- fmt.Errorf("协程 %d 签名失败: %w", w, err)
+					return
+				}
+				if err := VerifyDetailed(msg, List, sigma); err != nil {
+					errs <- fmt.Errorf("协程 %d 验证失败: %w", w, err)
+				}
+				if err := VerifyDetailedWithRing(MessageTrue, rc, shared); err != nil {
+					errs <- fmt.Errorf("协程 %d 验证共享签名失败: %w", w, err)
+				}
+				if _, err := shared.MarshalBinary(); err != nil {
+					errs <- fmt.Errorf("协程 %d 编码失败: %w", w, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// 测试不同协程数下 SignContext 与 VerifyContext 的结果一致，以及 ctx 取消后的返回值
+func TestSignContext(t *testing.T) {
+	L, List := newRing(t, 9)
+
+	// 协程数不影响确定性签名的结果
+	want, err := SignContext(context.Background(), MessageTrue, List, L[4], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	for _, workers := range []int{2, 4, 16, 0} {
+		for _, precompute := range []bool{false, true} {
+			opts := []Option{WithDeterministic(nil), WithWorkers(workers)}
+			if precompute {
+				opts = append(opts, WithPrecompute())
+			}
+			rc, err := NewRingContext(List, opts...)
+			if err != nil {
+				t.Fatalf("构造 RingContext 失败: %v", err)
+			}
+			sigma, err := SignWithRing(MessageTrue, rc, L[4], opts...)
+			if err != nil {
+				t.Fatalf("workers=%d: 签名失败: %v", workers, err)
+			}
+			if !sameSigma(want, sigma) {
+				t.Errorf("workers=%d, precompute=%v: 签名与单协程结果不同", workers, precompute)
+			}
+			if err := VerifyContext(context.Background(), MessageTrue, List, sigma, WithWorkers(workers)); err != nil {
+				t.Errorf("workers=%d: 验证失败: %v", workers, err)
+			}
+			if err := VerifyContext(context.Background(), MessageFalse, List, sigma, WithWorkers(workers)); err == nil {
+				t.Errorf("workers=%d: 错误消息验证通过", workers)
+			}
+		}
+	}
+
+	// 已取消的 ctx 直接返回 ctx.Err()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, workers := range []int{1, 4} {
+		if _, err := SignContext(ctx, MessageTrue, List, L[4], WithWorkers(workers)); !errors.Is(err, context.Canceled) {
+			t.Errorf("workers=%d: 签名期望错误 %v，实际为 %v", workers, context.Canceled, err)
+		}
+		if err := VerifyContext(ctx, MessageTrue, List, want, WithWorkers(workers)); !errors.Is(err, context.Canceled) {
+			t.Errorf("workers=%d: 验证期望错误 %v，实际为 %v", workers, context.Canceled, err)
+		}
+	}
+}
+
+// newLargeRing 以 pk_i = (i+1)·P 快速构造大小为 n 的环，返回下标为 index 的签名者，仅用于测试取消
+func newLargeRing(n, index int) (*Signer, []*bls.PointG1) {
+	P := baseMulG1(big.NewInt(1))
+	List := make([]*bls.PointG1, n)
+	List[0] = P
+	for i := 1; i < n; i++ {
+		List[i] = AddG1(List[i-1], P)
+	}
+	return &Signer{PrivateKey: big.NewInt(int64(index + 1)), PublicKey: List[index]}, List
+}
+
+// 测试签名与验证进行中 ctx 超时：应返回 context.DeadlineExceeded，且远早于未取消时完成
+func TestContextCancelMidway(t *testing.T) {
+	if testing.Short() {
+		t.Skip("大环测试耗时较长")
+	}
+	signer, List := newLargeRing(4096, 100)
+
+	// 签名逐个生成 U_i，每个成员处理前检查 ctx
+	for _, workers := range []int{1, 4} {
+		start := time.Now()
+		if _, err := SignContext(context.Background(), MessageTrue, List[:2048], signer, WithWorkers(workers)); err != nil {
+			t.Fatalf("workers=%d: 签名失败: %v", workers, err)
+		}
+		full := time.Since(start)
+
+		ctx, cancel := context.WithTimeout(context.Background(), full/20)
+		start = time.Now()
+		_, err := SignContext(ctx, MessageTrue, List[:2048], signer, WithWorkers(workers))
+		elapsed := time.Since(start)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("workers=%d: 签名期望错误 %v，实际为 %v", workers, context.DeadlineExceeded, err)
+		}
+		if elapsed > full/2 {
+			t.Errorf("workers=%d: 取消后签名耗时 %v，未取消时为 %v", workers, elapsed, full)
+		}
+	}
+
+	// 验证的环求和按 sumChunk 分段，段与段之间检查 ctx；大环签名的 U_i 直接取环中的点，
+	// 结构合法但配对不符，未取消时完整计算后返回 ErrPairingMismatch
+	small, err := Sign(MessageTrue, List[100:102], signer)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma := *small
+	sigma.UI = List
+	start := time.Now()
+	if err := VerifyContext(context.Background(), MessageTrue, List, &sigma); !errors.Is(err, ErrPairingMismatch) {
+		t.Fatalf("期望错误 %v，实际为 %v", ErrPairingMismatch, err)
+	}
+	full := time.Since(start)
+
+	ctx, cancel := context.WithTimeout(context.Background(), full/20)
+	defer cancel()
+	start = time.Now()
+	err = VerifyContext(ctx, MessageTrue, List, &sigma)
+	elapsed := time.Since(start)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("验证期望错误 %v，实际为 %v", context.DeadlineExceeded, err)
+	}
+	if elapsed > full/2 {
+		t.Errorf("取消后验证耗时 %v，未取消时为 %v", elapsed, full)
+	}
+}
+
+// benchRingSizes 基准测试中扫描的环大小
+var benchRingSizes = []int{2, 8, 32, 128}
+
+// 不同环大小下的签名开销，sig-bytes 为签名编码后的字节数
+func BenchmarkSign(b *testing.B) {
+	for _, n := range benchRingSizes {
+		L, List := newRing(b, n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Sign(MessageTrue, List, L[i%n]); err != nil {
+					b.Fatalf("签名失败: %v", err)
+				}
+			}
+			b.ReportMetric(float64(EncodedSize(n)), "sig-bytes")
+		})
+	}
+}
+
+// 不同环大小下的验证开销
+func BenchmarkVerify(b *testing.B) {
+	for _, n := range benchRingSizes {
+		L, List := newRing(b, n)
+		sigma, err := Sign(MessageTrue, List, L[0])
+		if err != nil {
+			b.Fatalf("签名失败: %v", err)
+		}
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := VerifyDetailed(MessageTrue, List, sigma); err != nil {
+					b.Fatalf("验证失败: %v", err)
+				}
+			}
+			b.ReportMetric(float64(EncodedSize(n)), "sig-bytes")
+		})
+	}
+}
+
+// 测试可链接模式：同一私钥在同一范围内的签名可被链接，标签与范围须与签名一致，编码往返后仍可验证
+func TestLinkable(t *testing.T) {
+	L, List := newRing(t, 5)
+	round1, round2 := []byte("round-1"), []byte("round-2")
+
+	sign := func(msg []byte, signer *Signer, opts ...Option) *Sigma {
+		t.Helper()
+		sigma, err := Sign(msg, List, signer, opts...)
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		return sigma
+	}
+	sig1 := sign(MessageTrue, L[1], WithScope(round1))
+	sig2 := sign(MessageFalse, L[1], WithScope(round1))
+	sig3 := sign(MessageTrue, L[3], WithScope(round1))
+	sig4 := sign(MessageTrue, L[1], WithScope(round2))
+	plain := sign(MessageTrue, L[1])
+
+	for _, c := range []struct {
+		msg   []byte
+		scope []byte
+		sigma *Sigma
+	}{{MessageTrue, round1, sig1}, {MessageFalse, round1, sig2}, {MessageTrue, round1, sig3}, {MessageTrue, round2, sig4}} {
+		if err := VerifyDetailed(c.msg, List, c.sigma, WithScope(c.scope)); err != nil {
+			t.Errorf("可链接签名验证失败: %v", err)
+		}
+	}
+	if !Link(sig1, sig2) {
+		t.Errorf("同一签名者在同一范围内的两个签名未被链接")
+	}
+	if Link(sig1, sig3) || Link(sig1, sig4) || Link(sig1, plain) || Link(nil, sig1) {
+		t.Errorf("不同签名者、不同范围或非可链接签名被链接")
+	}
+	if tag, err := LinkTag(L[1], round1); err != nil || !CompareG1(tag, sig1.Tag) {
+		t.Errorf("LinkTag 与签名中的标签不同: %v", err)
+	}
+
+	// 范围不符、要求可链接但签名不带标签、非 v2 编码
+	if err := VerifyDetailed(MessageTrue, List, sig4, WithScope(round1)); !errors.Is(err, ErrScopeMismatch) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrScopeMismatch, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, plain, WithScope(round1)); !errors.Is(err, ErrNotLinkable) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNotLinkable, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, plain); err != nil {
+		t.Errorf("普通签名验证失败: %v", err)
+	}
+	if _, err := Sign(MessageTrue, List, L[1], WithScope(round1), WithTranscript(TranscriptV1)); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+
+	// 确定性模式下，同一消息在不同范围内的签名使用不同的随机数
+	det1 := sign(MessageTrue, L[1], WithScope(round1), WithDeterministic(nil))
+	det2 := sign(MessageTrue, L[1], WithScope(round2), WithDeterministic(nil))
+	if err := VerifyDetailed(MessageTrue, List, det2, WithScope(round2)); err != nil {
+		t.Errorf("确定性可链接签名验证失败: %v", err)
+	}
+	if CompareG1(det1.UI[0], det2.UI[0]) {
+		t.Errorf("不同范围的确定性签名复用了随机数")
+	}
+
+	// 替换标签或范围、篡改 W_i 后验证失败
+	forged := *sig1
+	forged.Tag = sig3.Tag
+	if err := VerifyDetailed(MessageTrue, List, &forged); err == nil {
+		t.Errorf("替换标签后验证通过")
+	}
+	forged = *sig1
+	forged.Scope = round2
+	if err := VerifyDetailed(MessageTrue, List, &forged); err == nil {
+		t.Errorf("替换范围后验证通过")
+	}
+	forged = *sig1
+	forged.WI = append([]*bls.PointG1{sig3.WI[0]}, sig1.WI[1:]...)
+	if err := VerifyDetailed(MessageTrue, List, &forged); err == nil {
+		t.Errorf("篡改 W_i 后验证通过")
+	}
+	forged = *sig1
+	forged.WI = sig1.WI[1:]
+	if err := CheckSigma(&forged); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("W_i 个数错误: 期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+
+	// 不更新 H_i 而只替换标签与 W_s 时，第二个配对等式不成立
+	forged = *sig1
+	forged.Tag = ScalarMulG1(HashToPointG1(round1), L[2].PrivateKey)
+	forged.WI = append([]*bls.PointG1(nil), sig1.WI...)
+	if err := verifyTag(&forged, hiOf(t, MessageTrue, List, sig1)); !errors.Is(err, ErrTagMismatch) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrTagMismatch, err)
+	}
+
+	// 二进制与 JSON 编码往返
+	data, err := sig1.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if len(data) != LinkableEncodedSize(len(List), len(round1)) {
+		t.Errorf("编码长度为 %d，期望 %d", len(data), LinkableEncodedSize(len(List), len(round1)))
+	}
+	var decoded Sigma
+	if err := decoded.UnmarshalBinary(data); err != nil || !sameSigma(&decoded, sig1) {
+		t.Errorf("二进制解码结果与原签名不同: %v", err)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("截断的编码: 期望错误 %v，实际为 %v", ErrInvalidEncoding, err)
+	}
+	empty := sign(MessageTrue, L[0], WithScope(nil))
+	for _, sigma := range []*Sigma{sig1, empty} {
+		js, err := json.Marshal(sigma)
+		if err != nil {
+			t.Fatalf("JSON 编码失败: %v", err)
+		}
+		decoded = Sigma{}
+		if err := json.Unmarshal(js, &decoded); err != nil || !sameSigma(&decoded, sigma) {
+			t.Errorf("JSON 解码结果与原签名不同: %v", err)
+		}
+		if err := VerifyDetailed(MessageTrue, List, &decoded, WithScope(sigma.Scope)); err != nil {
+			t.Errorf("解码后验证失败: %v", err)
+		}
+	}
+	if js, _ := json.Marshal(plain); bytes.Contains(js, []byte("tag")) {
+		t.Errorf("普通签名的 JSON 中出现了 tag")
+	}
+
+	// 批量验证：替换标签的签名被单独定位
+	forged = *sig2
+	forged.Tag = sig3.Tag
+	results, err := VerifyBatch([][]byte{MessageTrue, MessageFalse, MessageTrue}, [][]*bls.PointG1{List, List, List}, []*Sigma{sig1, &forged, plain})
+	if err != nil || results[0] != nil || !errors.Is(results[1], ErrTagMismatch) || results[2] != nil {
+		t.Errorf("批量验证结果为 %v, %v", results, err)
+	}
+}
+
+// hiOf 按签名自身的范围与标签重新计算各成员的 H_i
+func hiOf(t *testing.T, Message []byte, PKList []*bls.PointG1, sigma *Sigma) []*big.Int {
+	t.Helper()
+	rc, err := NewRingContext(PKList)
+	if err != nil {
+		t.Fatalf("构造 RingContext 失败: %v", err)
+	}
+	HiList, err := sigmaTranscript(Message, rc, sigma).hiList(context.Background(), 1, sigma)
+	if err != nil {
+		t.Fatalf("计算 H_i 失败: %v", err)
+	}
+	return HiList
+}
+
+// 测试 t-of-n 门限签名：t = 1..n 时签名均可验证，门限、编码与篡改检查
+func TestThreshold(t *testing.T) {
+	const n = 5
+	L, List := newRing(t, n)
+	var sigmas []*Sigma
+	for th := 1; th <= n; th++ {
+		// 每轮选取不同位置的 th 个签名者，且顺序与环中的顺序不同
+		var signers []*Signer
+		for k := 0; k < th; k++ {
+			signers = append(signers, L[(th+2*k)%n])
+		}
+		sigma, err := SignThreshold(MessageTrue, List, signers)
+		if err != nil {
+			t.Fatalf("t = %d: 签名失败: %v", th, err)
+		}
+		sigmas = append(sigmas, sigma)
+		if sigma.Threshold != th || len(sigma.F) != n-th {
+			t.Errorf("t = %d: 签名门限为 %d，系数 %d 个", th, sigma.Threshold, len(sigma.F))
+		}
+
+		if err := VerifyDetailed(MessageTrue, List, sigma, WithThreshold(th)); err != nil {
+			t.Errorf("t = %d: 验证失败: %v", th, err)
+		}
+		if err := VerifyDetailed(MessageFalse, List, sigma); !errors.Is(err, ErrPairingMismatch) {
+			t.Errorf("t = %d: 错误消息: 期望错误 %v，实际为 %v", th, ErrPairingMismatch, err)
+		}
+		if err := VerifyDetailed(MessageTrue, List, sigma, WithThreshold(th+1)); !errors.Is(err, ErrThresholdTooLow) {
+			t.Errorf("t = %d: 期望错误 %v，实际为 %v", th, ErrThresholdTooLow, err)
+		}
+
+		// 声称更高的门限或篡改系数后验证失败
+		forged := *sigma
+		if th < n {
+			forged.Threshold, forged.F = th+1, sigma.F[1:]
+			if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, ErrPairingMismatch) {
+				t.Errorf("t = %d: 提高门限: 期望错误 %v，实际为 %v", th, ErrPairingMismatch, err)
+			}
+			forged = *sigma
+			forged.F = append([]*big.Int{new(big.Int).Add(sigma.F[0], big.NewInt(1))}, sigma.F[1:]...)
+			if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, ErrPairingMismatch) {
+				t.Errorf("t = %d: 篡改系数: 期望错误 %v，实际为 %v", th, ErrPairingMismatch, err)
+			}
+		}
+		forged = *sigma
+		forged.F = append(sigma.F, big.NewInt(1))
+		if err := CheckSigma(&forged); !errors.Is(err, ErrMalformedSignature) {
+			t.Errorf("t = %d: 系数个数错误: 期望错误 %v，实际为 %v", th, ErrMalformedSignature, err)
+		}
+
+		// 二进制与 JSON 编码往返
+		data, err := sigma.MarshalBinary()
+		if err != nil {
+			t.Fatalf("t = %d: 编码失败: %v", th, err)
+		}
+		if len(data) != ThresholdEncodedSize(n, th) {
+			t.Errorf("t = %d: 编码长度为 %d，期望 %d", th, len(data), ThresholdEncodedSize(n, th))
+		}
+		var decoded Sigma
+		if err := decoded.UnmarshalBinary(data); err != nil || !sameSigma(&decoded, sigma) {
+			t.Errorf("t = %d: 二进制解码结果与原签名不同: %v", th, err)
+		}
+		if err := decoded.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("t = %d: 截断的编码: 期望错误 %v，实际为 %v", th, ErrInvalidEncoding, err)
+		}
+		js, err := json.Marshal(sigma)
+		if err != nil {
+			t.Fatalf("t = %d: JSON 编码失败: %v", th, err)
+		}
+		decoded = Sigma{}
+		if err := json.Unmarshal(js, &decoded); err != nil || !sameSigma(&decoded, sigma) {
+			t.Errorf("t = %d: JSON 解码结果与原签名不同: %v", th, err)
+		}
+	}
+
+	// 普通签名视为门限 1
+	plain, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, plain, WithThreshold(1)); err != nil {
+		t.Errorf("普通签名验证失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, plain, WithThreshold(2)); !errors.Is(err, ErrThresholdTooLow) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrThresholdTooLow, err)
+	}
+
+	// 批量验证中混合门限签名与普通签名
+	results, err := VerifyBatch([][]byte{MessageTrue, MessageTrue, MessageFalse}, [][]*bls.PointG1{List, List, List},
+		[]*Sigma{sigmas[2], plain, sigmas[3]})
+	if err != nil || results[0] != nil || results[1] != nil || !errors.Is(results[2], ErrPairingMismatch) {
+		t.Errorf("批量验证结果为 %v, %v", results, err)
+	}
+
+	// 非法的签名者集合与选项
+	if _, err := SignThreshold(MessageTrue, List, []*Signer{L[1], L[1]}); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("重复签名者: 期望错误 %v，实际为 %v", ErrInvalidThreshold, err)
+	}
+	if _, err := SignThreshold(MessageTrue, List, nil); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("没有签名者: 期望错误 %v，实际为 %v", ErrInvalidThreshold, err)
+	}
+	if _, err := SignThreshold(MessageTrue, List, L[:2], WithDeterministic(nil)); !errors.Is(err, ErrThresholdOption) {
+		t.Errorf("确定性模式: 期望错误 %v，实际为 %v", ErrThresholdOption, err)
+	}
+	if _, err := SignThreshold(MessageTrue, List, L[:2], WithScope([]byte("round-1"))); !errors.Is(err, ErrThresholdOption) {
+		t.Errorf("可链接模式: 期望错误 %v，实际为 %v", ErrThresholdOption, err)
+	}
+}
+
+// 测试门限签名的多方流程：承诺、建立会话、贡献与合成，以及一次性随机数与贡献的校验
+func TestThresholdSession(t *testing.T) {
+	L, List := newRing(t, 6)
+	rc, err := NewRingContext(List)
+	if err != nil {
+		t.Fatalf("构造 RingContext 失败: %v", err)
+	}
+	signers := []*Signer{L[4], L[1], L[3]}
+
+	// 1. 各签名者生成承诺
+	nonces := make([]*ThresholdNonce, len(signers))
+	commitments := make([]*ThresholdCommitment, len(signers))
+	for i, s := range signers {
+		if nonces[i], commitments[i], err = ThresholdCommit(rc, s); err != nil {
+			t.Fatalf("承诺失败: %v", err)
+		}
+	}
+	if _, err := NewThresholdAggregator(MessageTrue, rc, append(commitments, commitments[0])); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("重复承诺: 期望错误 %v，实际为 %v", ErrInvalidThreshold, err)
+	}
+
+	// 2. 建立会话
+	aggregator, err := NewThresholdAggregator(MessageTrue, rc, commitments)
+	if err != nil {
+		t.Fatalf("建立会话失败: %v", err)
+	}
+	session := aggregator.Session()
+	if fmt.Sprint(session.Signers) != "[1 3 4]" {
+		t.Errorf("会话中的签名者为 %v", session.Signers)
+	}
+
+	// 3. 签名者只能使用自己的随机数，且随机数只能使用一次
+	if _, err := ThresholdContribute(rc, session, nonces[0], signers[1]); !errors.Is(err, ErrSessionMismatch) {
+		t.Errorf("他人的随机数: 期望错误 %v，实际为 %v", ErrSessionMismatch, err)
+	}
+	if _, err := ThresholdContribute(rc, session, nonces[0], signers[0]); !errors.Is(err, ErrNonceUsed) {
+		t.Errorf("随机数已被使用: 期望错误 %v，实际为 %v", ErrNonceUsed, err)
+	}
+	extra, _, err := ThresholdCommit(rc, signers[0])
+	if err != nil {
+		t.Fatalf("承诺失败: %v", err)
+	}
+	if _, err := ThresholdContribute(rc, session, extra, signers[0]); !errors.Is(err, ErrSessionMismatch) {
+		t.Errorf("会话中没有该承诺: 期望错误 %v，实际为 %v", ErrSessionMismatch, err)
+	}
+	if nonces[0], commitments[0], err = ThresholdCommit(rc, signers[0]); err != nil {
+		t.Fatalf("承诺失败: %v", err)
+	}
+	if aggregator, err = NewThresholdAggregator(MessageTrue, rc, commitments); err != nil {
+		t.Fatalf("建立会话失败: %v", err)
+	}
+	session = aggregator.Session()
+
+	// 4. 各签名者给出贡献
+	contributions := make([]*ThresholdContribution, len(signers))
+	for i, s := range signers {
+		if contributions[i], err = ThresholdContribute(rc, session, nonces[i], s); err != nil {
+			t.Fatalf("贡献失败: %v", err)
+		}
+	}
+	if _, err := ThresholdContribute(rc, session, nonces[1], signers[1]); !errors.Is(err, ErrNonceUsed) {
+		t.Errorf("重复贡献: 期望错误 %v，实际为 %v", ErrNonceUsed, err)
+	}
+
+	// 5. 缺少、重复或错误的贡献被拒绝
+	if _, err := aggregator.Combine(contributions[:2]); !errors.Is(err, ErrInvalidContribution) {
+		t.Errorf("缺少贡献: 期望错误 %v，实际为 %v", ErrInvalidContribution, err)
+	}
+	dup := []*ThresholdContribution{contributions[0], contributions[0], contributions[2]}
+	if _, err := aggregator.Combine(dup); !errors.Is(err, ErrInvalidContribution) {
+		t.Errorf("重复贡献: 期望错误 %v，实际为 %v", ErrInvalidContribution, err)
+	}
+	bad := []*ThresholdContribution{{Index: contributions[0].Index, V: contributions[1].V}, contributions[1], contributions[2]}
+	if _, err := aggregator.Combine(bad); !errors.Is(err, ErrInvalidContribution) {
+		t.Errorf("错误贡献: 期望错误 %v，实际为 %v", ErrInvalidContribution, err)
+	}
+
+	sigma, err := aggregator.Combine(contributions)
+	if err != nil {
+		t.Fatalf("合成失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, sigma, WithThreshold(len(signers))); err != nil {
+		t.Errorf("门限签名验证失败: %v", err)
+	}
+}
+
+// 测试分布式门限签名：承诺、会话与贡献只以 JSON 传递，签名者以自己构造的 RingContext 由会话的公开字段给出贡献
+func TestThresholdSessionJSON(t *testing.T) {
+	L, List := newRing(t, 5)
+	signers := []*Signer{L[0], L[3]}
+
+	// roundTrip 模拟经网络传递：编码为 JSON 后解码到 out
+	roundTrip := func(in, out interface{}) {
+		t.Helper()
+		js, err := json.Marshal(in)
+		if err != nil {
+			t.Fatalf("JSON 编码失败: %v", err)
+		}
+		if err := json.Unmarshal(js, out); err != nil {
+			t.Fatalf("JSON 解码失败: %v", err)
+		}
+	}
+
+	// 1. 各签名者以自己的 RingContext 生成承诺并发给汇总者
+	rings := make([]*RingContext, len(signers))
+	nonces := make([]*ThresholdNonce, len(signers))
+	commitments := make([]*ThresholdCommitment, len(signers))
+	for i, s := range signers {
+		var err error
+		if rings[i], err = NewRingContext(append([]*bls.PointG1(nil), List...)); err != nil {
+			t.Fatalf("构造 RingContext 失败: %v", err)
+		}
+		nonce, commitment, err := ThresholdCommit(rings[i], s)
+		if err != nil {
+			t.Fatalf("承诺失败: %v", err)
+		}
+		nonces[i], commitments[i] = nonce, new(ThresholdCommitment)
+		roundTrip(commitment, commitments[i])
+	}
+
+	// 2. 汇总者建立会话，只把公开部分发给签名者
+	rc, err := NewRingContext(List)
+	if err != nil {
+		t.Fatalf("构造 RingContext 失败: %v", err)
+	}
+	aggregator, err := NewThresholdAggregator(MessageTrue, rc, commitments)
+	if err != nil {
+		t.Fatalf("建立会话失败: %v", err)
+	}
+	js, err := json.Marshal(aggregator.Session())
+	if err != nil {
+		t.Fatalf("JSON 编码失败: %v", err)
+	}
+	if bytes.Contains(js, []byte("ksum")) || bytes.Contains(js, []byte("kSum")) {
+		t.Errorf("会话的 JSON 中出现了 kSum")
+	}
+
+	// 3. 签名者由解码得到的会话给出贡献，再发回汇总者合成
+	contributions := make([]*ThresholdContribution, len(signers))
+	for i, s := range signers {
+		var session ThresholdSession
+		if err := json.Unmarshal(js, &session); err != nil {
+			t.Fatalf("JSON 解码失败: %v", err)
+		}
+		contribution, err := ThresholdContribute(rings[i], &session, nonces[i], s)
+		if err != nil {
+			t.Fatalf("贡献失败: %v", err)
+		}
+		contributions[i] = new(ThresholdContribution)
+		roundTrip(contribution, contributions[i])
+	}
+	sigma, err := aggregator.Combine(contributions)
+	if err != nil {
+		t.Fatalf("合成失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, sigma, WithThreshold(len(signers))); err != nil {
+		t.Errorf("门限签名验证失败: %v", err)
+	}
+
+	// 4. 结构不合法的会话与其他方案的文档被拒绝
+	var session ThresholdSession
+	for _, bad := range []string{
+		strings.Replace(string(js), `"signers":[0,3]`, `"signers":[3,0]`, 1),
+		strings.Replace(string(js), `"threshold":2`, `"threshold":3`, 1),
+	} {
+		if err := json.Unmarshal([]byte(bad), &session); !errors.Is(err, ErrSessionMismatch) {
+			t.Errorf("期望错误 %v，实际为 %v", ErrSessionMismatch, err)
+		}
+	}
+	if err := json.Unmarshal([]byte(strings.Replace(string(js), SchemeName, "other", 1)), &session); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrInvalidEncoding, err)
+	}
+	if session.UI != nil {
+		t.Errorf("解码失败后会话被修改")
+	}
+}
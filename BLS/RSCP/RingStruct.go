@@ -1,10 +1,17 @@
 package RSCP
 
 import (
+	"BRFL/KeyPEM"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"runtime"
+	"sync"
 
 	bls "github.com/kilic/bls12-381"
 )
@@ -12,20 +19,108 @@ import (
 // -------------------- 全局参数 --------------------
 
 var (
-	// 建议在初始化时全局只创建一次 G1, G2 实例
 	// kilic 的说明：同一个 Engine/G1/G2 对象不适合并发，需要多线程时应分别创建
-	blsG1   = bls.NewG1()
-	blsG2   = bls.NewG2()
-	blsPair = bls.NewEngine()
+	// 因此 G1、G2 实例放在池中，每次调用各自取出；Engine 在每次配对时新建
+	g1Pool = sync.Pool{New: func() interface{} { return bls.NewG1() }}
+	g2Pool = sync.Pool{New: func() interface{} { return bls.NewG2() }}
 
 	// BLS12-381 的群阶（与 Fr、G1、G2 同阶）
-	blsOrder = blsG1.Q()
+	blsOrder = bls.NewG1().Q()
 )
 
+// getG1 从池中取出一个 G1 实例，用完后须通过 putG1 归还
+func getG1() *bls.G1 {
+	return g1Pool.Get().(*bls.G1)
+}
+
+// putG1 把 G1 实例归还到池中
+func putG1(g *bls.G1) {
+	g1Pool.Put(g)
+}
+
+// getG2 从池中取出一个 G2 实例，用完后须通过 putG2 归还
+func getG2() *bls.G2 {
+	return g2Pool.Get().(*bls.G2)
+}
+
+// putG2 把 G2 实例归还到池中
+func putG2(g *bls.G2) {
+	g2Pool.Put(g)
+}
+
+// marshalG1 返回 p 的非压缩编码
+// ToUncompressed 会把参数原地转换为仿射坐标，这里对副本编码，使多个协程共享的公钥与签名中的点只被读取
+func marshalG1(p *bls.PointG1) []byte {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	return blsG1.ToUncompressed(new(bls.PointG1).Set(p))
+}
+
+// marshalG2 返回 p 的非压缩编码，同样对副本编码
+func marshalG2(p *bls.PointG2) []byte {
+	blsG2 := getG2()
+	defer putG2(blsG2)
+	return blsG2.ToUncompressed(new(bls.PointG2).Set(p))
+}
+
+// NonceDomain 确定性签名模式下 HMAC-DRBG 的个性化字符串
+const NonceDomain = "RSCP/BLS12-381/nonce/v1"
+
+// HashDomain v1 转录编码的域分离前缀，与具体哈希用途的标签拼接后作为完整的域标签
+const HashDomain = "RSCP/BLS12-381/hash/v1/"
+
+// HashDomainV2 v2 转录编码的域分离前缀
+const HashDomainV2 = "RSCP/BLS12-381/hash/v2/"
+
+// 各哈希用途的域分离标签
+const (
+	// TagHi 计算 H_i（包括签名者的 h_s）时使用的标签
+	TagHi = "H_i"
+	// TagHp 可链接模式下把范围字符串哈希到 G1 时使用的标签
+	TagHp = "H_p"
+	// TagHiLink 可链接签名计算 H_i 时使用的标签，H_i 同时绑定范围、标签与 W_i
+	TagHiLink = "H_i/link"
+	// TagThreshold 门限签名计算挑战值 c = f(0) 时使用的标签
+	TagThreshold = "threshold"
+	// TagThresholdKey 门限签名计算公钥系数 a_i 时使用的标签
+	TagThresholdKey = "threshold/key"
+)
+
+// TranscriptVersion 哈希转录编码的版本
+type TranscriptVersion uint8
+
+const (
+	// TranscriptLegacy 旧版编码：直接拼接原始字节，仅用于兼容已有签名
+	TranscriptLegacy TranscriptVersion = 0
+	// TranscriptV1 带域分离标签、类型与长度前缀、定长标量的编码
+	TranscriptV1 TranscriptVersion = 1
+	// TranscriptV2 与 v1 编码规则相同，但先把消息与公钥环压缩为定长的上下文摘要，使每个 H_i 的计算与环大小无关
+	TranscriptV2 TranscriptVersion = 2
+)
+
+// Supported 判断当前实现是否支持该转录编码版本
+func (v TranscriptVersion) Supported() bool {
+	return v == TranscriptLegacy || v == TranscriptV1 || v == TranscriptV2
+}
+
 // Sigma 签名结果结构体
 type Sigma struct {
 	UI []*bls.PointG1
 	V  *bls.PointG2
+	// Version 计算签名时使用的哈希转录编码版本
+	Version TranscriptVersion
+
+	// Scope 可链接签名的范围字符串，同一私钥在同一范围内的签名具有相同的 Tag
+	Scope []byte
+	// Tag 可链接签名的标签 sk \cdot H_p(Scope)；非可链接签名为 nil
+	Tag *bls.PointG1
+	// WI 与 U_i 一一对应、以 H_p(Scope) 为基的辅助量，用于证明 Tag 与环一致
+	WI []*bls.PointG1
+
+	// Threshold 门限签名的门限 t，即至少有 t 个不同的成员参与了签名；普通签名为 0
+	Threshold int
+	// F 门限签名中多项式 f 的系数 F_1, ..., F_{n-t}，f(0) 由 U_i 哈希得到
+	F []*big.Int
 }
 
 // Signer 签名者结构体
@@ -34,10 +129,182 @@ type Signer struct {
 	PublicKey  *bls.PointG1
 }
 
+// -------------------- 错误定义 --------------------
+
+var (
+	// ErrEmptyRing 环中没有任何公钥
+	ErrEmptyRing = errors.New("环为空")
+	// ErrNilPublicKey 环中存在 nil 公钥
+	ErrNilPublicKey = errors.New("环中存在 nil 公钥")
+	// ErrDuplicatePublicKey 环中存在重复的公钥
+	ErrDuplicatePublicKey = errors.New("环中存在重复的公钥")
+	// ErrNilSigner 签名者或其密钥为 nil
+	ErrNilSigner = errors.New("签名者密钥为空")
+	// ErrSignerNotInRing 签名者公钥不在环中
+	ErrSignerNotInRing = errors.New("签名者公钥不在环中")
+	// ErrKeyMismatch 签名者的公钥不等于 PrivateKey \cdot P
+	ErrKeyMismatch = errors.New("签名者私钥与公钥不匹配")
+	// ErrRandomSource 从随机数来源读取失败
+	ErrRandomSource = errors.New("读取随机数来源失败")
+	// ErrRingSizeMismatch 签名中 U_i 的个数与公钥环大小不一致
+	ErrRingSizeMismatch = errors.New("签名与公钥环大小不一致")
+	// ErrMalformedSignature 签名结构不完整或取值非法
+	ErrMalformedSignature = errors.New("签名格式错误")
+	// ErrUnsupportedTranscript 哈希转录编码版本未知或未被允许
+	ErrUnsupportedTranscript = errors.New("不支持的哈希转录编码版本")
+	// ErrInvalidEncoding 签名、公钥或公钥环的编码格式错误
+	ErrInvalidEncoding = errors.New("编码格式错误")
+	// ErrInvalidPoint 点不在曲线上或不属于素数阶子群
+	ErrInvalidPoint = errors.New("点不在曲线上或不属于素数阶子群")
+	// ErrIdentityPoint 公钥或签名中出现了不允许的无穷远点
+	ErrIdentityPoint = errors.New("点为无穷远点")
+	// ErrPairingMismatch 配对等式 e(P, V) = e(Sum, Q) 不成立
+	ErrPairingMismatch = errors.New("配对校验失败")
+	// ErrBatchLengthMismatch 批量验证时消息、公钥环与签名的个数不一致
+	ErrBatchLengthMismatch = errors.New("批量验证的输入个数不一致")
+	// ErrNotLinkable 要求可链接签名，但签名中没有标签
+	ErrNotLinkable = errors.New("签名不是可链接签名")
+	// ErrScopeMismatch 可链接签名的范围与验证时要求的范围不同
+	ErrScopeMismatch = errors.New("可链接签名的范围不符")
+	// ErrTagMismatch 配对等式 e(H_p(Scope), V) = e(\sum_i (W_i + H_i \cdot Tag), Q) 不成立
+	ErrTagMismatch = errors.New("标签的配对校验失败")
+	// ErrInvalidThreshold 门限为 0、超过环大小，或承诺的下标越界、重复
+	ErrInvalidThreshold = errors.New("门限或承诺非法")
+	// ErrThresholdTooLow 签名的门限低于验证时要求的门限
+	ErrThresholdTooLow = errors.New("签名的门限低于要求")
+	// ErrThresholdOption 门限签名不支持确定性模式与可链接模式
+	ErrThresholdOption = errors.New("门限签名不支持确定性模式与可链接模式")
+	// ErrNonceUsed 门限签名的一次性随机数为 nil 或已被使用
+	ErrNonceUsed = errors.New("一次性随机数已被使用")
+	// ErrSessionMismatch 门限签名会话不完整，或不包含签名者的承诺
+	ErrSessionMismatch = errors.New("门限签名会话与承诺不符")
+	// ErrInvalidContribution 签名者的贡献缺失、重复或校验失败
+	ErrInvalidContribution = errors.New("签名者的贡献校验失败")
+)
+
+// -------------------- 可选参数 --------------------
+
+// Config 保存 NewSigner、NewRingContext、Sign、Verify 与密钥导出使用的可选配置
+type Config struct {
+	// Random 随机数来源，默认为 crypto/rand.Reader
+	Random io.Reader
+	// Deterministic 为 true 时，Sign 中的所有随机量由 HMAC-DRBG 确定性派生，不再读取 Random
+	Deterministic bool
+	// ExtraEntropy 确定性模式下额外混入 HMAC-DRBG 的熵，可为空
+	ExtraEntropy []byte
+	// Transcript Sign 使用的哈希转录编码版本，默认为 TranscriptV2
+	Transcript TranscriptVersion
+	// AllowLegacy 为 true 时，Verify 接受使用 TranscriptLegacy 编码的旧签名
+	AllowLegacy bool
+	// KDFIterations 加密私钥 PEM 时 PBKDF2 的迭代次数，默认为 KeyPEM.DefaultIterations
+	KDFIterations int
+	// Precompute 为 true 时，NewRingContext 为每个公钥构造定点预计算表
+	Precompute bool
+	// Workers SignContext 与 VerifyContext 处理环成员时使用的协程数，默认为 1
+	Workers int
+	// Linkable 为 true 时签名附带范围 Scope 下的标签，验证时要求签名是同一范围下的可链接签名
+	Linkable bool
+	// Scope 可链接模式的范围字符串，例如投票或训练轮次的标识
+	Scope []byte
+	// Threshold 验证时要求的最小门限，普通签名视为门限 1；为 0 时不检查
+	Threshold int
+}
+
+// Option 以函数式选项的方式修改 Config
+type Option func(*Config)
+
+// WithRandom 指定生成密钥与签名时使用的随机数来源
+func WithRandom(r io.Reader) Option {
+	return func(c *Config) {
+		c.Random = r
+	}
+}
+
+// WithDeterministic 启用确定性签名模式（类似 RFC 6979）
+// 随机量由私钥、消息与公钥环派生，extra 不为空时作为额外熵混入
+func WithDeterministic(extra []byte) Option {
+	return func(c *Config) {
+		c.Deterministic = true
+		c.ExtraEntropy = extra
+	}
+}
+
+// WithTranscript 指定 Sign 使用的哈希转录编码版本
+func WithTranscript(v TranscriptVersion) Option {
+	return func(c *Config) {
+		c.Transcript = v
+	}
+}
+
+// WithLegacyTranscript 允许 Verify 接受旧版编码的签名
+func WithLegacyTranscript() Option {
+	return func(c *Config) {
+		c.AllowLegacy = true
+	}
+}
+
+// WithKDFIterations 指定加密私钥 PEM 时 PBKDF2 的迭代次数
+func WithKDFIterations(n int) Option {
+	return func(c *Config) {
+		c.KDFIterations = n
+	}
+}
+
+// WithPrecompute 令 NewRingContext 为每个公钥构造定点预计算表，适用于同一个环上的大量签名与验证
+func WithPrecompute() Option {
+	return func(c *Config) {
+		c.Precompute = true
+	}
+}
+
+// WithWorkers 指定处理环成员时使用的协程数，n <= 0 时使用 runtime.GOMAXPROCS(0)
+func WithWorkers(n int) Option {
+	return func(c *Config) {
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		c.Workers = n
+	}
+}
+
+// WithScope 启用范围为 scope 的可链接模式：签名附带标签，验证时拒绝不带标签或范围不同的签名，需使用 TranscriptV2
+func WithScope(scope []byte) Option {
+	return func(c *Config) {
+		c.Linkable = true
+		c.Scope = append([]byte{}, scope...)
+	}
+}
+
+// WithThreshold 要求签名至少由 t 个不同的成员参与，拒绝门限更低的门限签名；t > 1 时拒绝普通签名
+func WithThreshold(t int) Option {
+	return func(c *Config) {
+		c.Threshold = t
+	}
+}
+
+// NewConfig 以默认配置为基础依次应用 opts
+func NewConfig(opts ...Option) *Config {
+	c := &Config{Random: rand.Reader, Transcript: TranscriptV2, KDFIterations: KeyPEM.DefaultIterations, Workers: 1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// AcceptsTranscript 判断 Verify 是否接受版本为 v 的签名
+func (c *Config) AcceptsTranscript(v TranscriptVersion) bool {
+	if v == TranscriptLegacy {
+		return c.AllowLegacy
+	}
+	return v.Supported()
+}
+
 // -------------------- 工具函数 --------------------
 
 // SubG1 计算 p1 - p2
 func SubG1(p1, p2 *bls.PointG1) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
 	ret := blsG1.New()
 	blsG1.Sub(ret, p1, p2)
 	return ret
@@ -45,6 +312,8 @@ func SubG1(p1, p2 *bls.PointG1) *bls.PointG1 {
 
 // AddG1 计算 p1 + p2
 func AddG1(p1, p2 *bls.PointG1) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
 	ret := blsG1.New()
 	blsG1.Add(ret, p1, p2)
 	return ret
@@ -52,46 +321,115 @@ func AddG1(p1, p2 *bls.PointG1) *bls.PointG1 {
 
 // ScalarMulG1 计算 k * p
 func ScalarMulG1(p *bls.PointG1, k *big.Int) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
 	ret := blsG1.New()
 	blsG1.MulScalarBig(ret, p, k)
 	return ret
 }
 
-// RandomPointG1 随机生成一个 G1 群元素 (即随机标量乘生成元)
-func RandomPointG1() *bls.PointG1 {
-	k := RandomZq()
+// MultiScalarMulG1 计算多标量乘法 \sum_i k_i \cdot p_i，points 与 scalars 的长度须一致
+// 标量约减到 [0, Order) 后交给 kilic 的 Pippenger 分桶实现 MultiExpBig
+func MultiScalarMulG1(points []*bls.PointG1, scalars []*big.Int) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	ks := make([]*big.Int, len(scalars))
+	for i, k := range scalars {
+		if k.Sign() < 0 || k.Cmp(blsOrder) >= 0 {
+			k = new(big.Int).Mod(k, blsOrder)
+		}
+		ks[i] = k
+	}
+
+	r := blsG1.New()
+	if _, err := blsG1.MultiExpBig(r, points, ks); err != nil {
+		panic("MultiScalarMulG1: points 与 scalars 长度不一致")
+	}
+	return r
+}
+
+// RandomPointG1From 从随机数来源 r 中随机生成一个 G1 群元素 (即随机标量乘生成元)
+func RandomPointG1From(r io.Reader) (*bls.PointG1, error) {
+	k, err := RandomZqFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return baseMulG1(k), nil
+}
+
+// baseMulG1 计算 k \cdot G1 并转换为仿射坐标，之后 marshalG1 对副本编码时无需再求逆
+func baseMulG1(k *big.Int) *bls.PointG1 {
+	blsG1 := getG1()
+	defer putG1(blsG1)
 	p := blsG1.New()
 	// G1.One() 是生成元，MulScalarBig 做标量乘法
 	blsG1.MulScalarBig(p, blsG1.One(), k)
+	blsG1.Affine(p)
 	return p
 }
 
+// RandomPointG1 随机生成一个 G1 群元素 (即随机标量乘生成元)
+func RandomPointG1() *bls.PointG1 {
+	p, err := RandomPointG1From(rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("随机取数失败: %v", err))
+	}
+	return p
+}
+
+// MulZq 计算两个有限域标量 a 和 b 的乘积，并对 Order 取模
+func MulZq(a, b *big.Int) *big.Int {
+	prod := new(big.Int).Mul(a, b)
+	return prod.Mod(prod, blsOrder)
+}
+
 // CompareG1 比较两个 G1 群元素是否相等
 func CompareG1(p1, p2 *bls.PointG1) bool {
+	blsG1 := getG1()
+	defer putG1(blsG1)
 	return blsG1.Equal(p1, p2)
 }
 
-// RandomZq 在 Zq 中取一个随机数
+// RandomZqFrom 从随机数来源 r 中在 Zq* 的有限域内取一个随机数
+func RandomZqFrom(r io.Reader) (*big.Int, error) {
+	k, err := rand.Int(r, blsOrder)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRandomSource, err)
+	}
+	return k, nil
+}
+
+// RandomZq 在 Zq* 的有限域内取一个随机数，随机数来源为 crypto/rand.Reader
 func RandomZq() *big.Int {
-	k, err := rand.Int(rand.Reader, blsOrder)
+	k, err := RandomZqFrom(rand.Reader)
 	if err != nil {
-		panic(fmt.Sprintf("随机取数失败: %v", err))
+		panic(fmt.Sprintf("在 Zq* 的有限域内取随机数失败：%v", err))
 	}
 	return k
 }
 
 // NewSigner 生成 (sk, pk)
-func NewSigner() *Signer {
-	sk := RandomZq()
+// 可通过 WithRandom 指定随机数来源，读取失败时返回错误
+func NewSigner(opts ...Option) (*Signer, error) {
+	blsG1 := getG1()
+	defer putG1(blsG1)
+	cfg := NewConfig(opts...)
+
+	sk, err := RandomZqFrom(cfg.Random)
+	if err != nil {
+		return nil, err
+	}
 	pk := blsG1.New()
 	blsG1.MulScalarBig(pk, blsG1.One(), sk) // pk = sk * G
+	blsG1.Affine(pk)
 	return &Signer{
 		PrivateKey: sk,
 		PublicKey:  pk,
-	}
+	}, nil
 }
 
 // HashToZq 将任意若干字节切片拼接做 SHA256，然后结果映射到 Z_q
+// 这是 TranscriptLegacy 使用的旧版编码，新签名请使用 HashTranscript
 func HashToZq(args ...interface{}) *big.Int {
 	var buf []byte
 	for _, arg := range args {
@@ -99,16 +437,16 @@ func HashToZq(args ...interface{}) *big.Int {
 		case []byte:
 			buf = append(buf, v...)
 		case *bls.PointG1:
-			// ToUncompressed / ToBytes 都可以，这里用 ToUncompressed()
-			b := blsG1.ToUncompressed(v)
+			// 使用非压缩编码
+			b := marshalG1(v)
 			buf = append(buf, b...)
 		case []*bls.PointG1:
 			for _, g1 := range v {
-				b := blsG1.ToUncompressed(g1)
+				b := marshalG1(g1)
 				buf = append(buf, b...)
 			}
 		case *bls.PointG2:
-			b := blsG2.ToUncompressed(v)
+			b := marshalG2(v)
 			buf = append(buf, b...)
 		case *big.Int:
 			buf = append(buf, v.Bytes()...)
@@ -126,3 +464,141 @@ func HashToZq(args ...interface{}) *big.Int {
 	hInt.Mod(hInt, blsOrder) // 映射到有限域
 	return hInt
 }
+
+// HashTranscript 按转录编码版本 version 计算哈希并映射到 Z_q，tag 为哈希用途的域分离标签
+func HashTranscript(version TranscriptVersion, tag string, args ...interface{}) *big.Int {
+	switch version {
+	case TranscriptLegacy:
+		return HashToZq(args...)
+	case TranscriptV1:
+		return HashToZqV1(tag, args...)
+	default:
+		return HashToZqV2(tag, args...)
+	}
+}
+
+// HashToZqV1 使用 v1 转录编码计算哈希并映射到 Z_q
+// 编码以 HashDomain + tag 开头，每个参数带类型字节，变长数据带长度前缀，标量编码为定长 32 字节；
+// 对 SHA-512 的 64 字节输出取模，使结果在 Z_q 上近似均匀
+func HashToZqV1(tag string, args ...interface{}) *big.Int {
+	return hashToZq(HashDomain, tag, args)
+}
+
+// HashToZqV2 使用 v2 转录编码计算哈希并映射到 Z_q，参数编码与 v1 相同，仅域分离前缀不同
+func HashToZqV2(tag string, args ...interface{}) *big.Int {
+	return hashToZq(HashDomainV2, tag, args)
+}
+
+// hashToZq 以 domain + tag 为域分离标签写入各参数，对 SHA-512 的输出取模
+func hashToZq(domain, tag string, args []interface{}) *big.Int {
+	buf := appendTranscript(appendBytes(nil, []byte(domain+tag)), args...)
+	hash := sha512.Sum512(buf)
+	hashInt := new(big.Int).SetBytes(hash[:])
+	return hashInt.Mod(hashInt, blsOrder)
+}
+
+// appendTranscript 依次写入各参数：每个参数带类型字节，变长数据带长度前缀，标量编码为定长 32 字节
+func appendTranscript(buf []byte, args ...interface{}) []byte {
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case []byte:
+			buf = append(buf, 0x01)
+			buf = appendBytes(buf, v)
+		case *bls.PointG1:
+			buf = append(buf, 0x02)
+			buf = append(buf, marshalG1(v)...)
+		case []*bls.PointG1:
+			buf = append(buf, 0x03)
+			buf = binary.BigEndian.AppendUint64(buf, uint64(len(v)))
+			for _, p := range v {
+				buf = append(buf, marshalG1(p)...)
+			}
+		case *big.Int:
+			buf = append(buf, 0x04)
+			buf = appendScalar(buf, v)
+		case []*big.Int:
+			buf = append(buf, 0x05)
+			buf = binary.BigEndian.AppendUint64(buf, uint64(len(v)))
+			for _, bi := range v {
+				buf = appendScalar(buf, bi)
+			}
+		case *bls.PointG2:
+			buf = append(buf, 0x06)
+			buf = append(buf, marshalG2(v)...)
+		default:
+			panic(fmt.Sprintf("不支持的类型: %T", v))
+		}
+	}
+	return buf
+}
+
+// RingDigest 计算公钥环的定长摘要，即对 HashDomainV2 + "ring" 与转录编码的 PKList 做 SHA-256
+func RingDigest(PKList []*bls.PointG1) []byte {
+	return ringDigest(appendTranscript(nil, PKList))
+}
+
+// ringDigest 由公钥环的转录编码计算环摘要
+func ringDigest(encoding []byte) []byte {
+	digest := sha256.Sum256(append(appendBytes(nil, []byte(HashDomainV2+"ring")), encoding...))
+	return digest[:]
+}
+
+// transcript 一次签名或验证中复用的哈希上下文
+// v2 编码预先把消息与公钥环压缩为定长摘要，之后每个哈希只需写入摘要；旧版与 v1 编码仍按原样拼接
+type transcript struct {
+	version TranscriptVersion
+	Message []byte
+	PKList  []*bls.PointG1
+	context []byte
+	// scope、tag 可链接签名的范围与标签，由 bindTag 设置
+	scope []byte
+	tag   *bls.PointG1
+}
+
+// newTranscript 构造哈希上下文，v2 编码下只在这里遍历一次消息，公钥环的摘要取自 rc
+func newTranscript(version TranscriptVersion, Message []byte, rc *RingContext) *transcript {
+	t := &transcript{version: version, Message: Message, PKList: rc.pkList}
+	if version == TranscriptV2 {
+		buf := appendTranscript(appendBytes(nil, []byte(HashDomainV2+"context")), Message, rc.digest)
+		digest := sha256.Sum256(buf)
+		t.context = digest[:]
+	}
+	return t
+}
+
+// Hi 计算 U 对应的 H_i
+func (t *transcript) Hi(U *bls.PointG1) *big.Int {
+	if t.version == TranscriptV2 {
+		return HashToZqV2(TagHi, t.context, U)
+	}
+	return HashTranscript(t.version, TagHi, U, t.Message, t.PKList)
+}
+
+// bindTag 令之后的 HiLink 绑定可链接签名的范围与标签
+func (t *transcript) bindTag(scope []byte, tag *bls.PointG1) {
+	t.scope, t.tag = scope, tag
+}
+
+// HiLink 计算可链接签名中 (U, W) 对应的 H_i = H(context, scope, tag, U, W)，只用于 v2 编码
+func (t *transcript) HiLink(U, W *bls.PointG1) *big.Int {
+	return HashToZqV2(TagHiLink, t.context, t.scope, t.tag, U, W)
+}
+
+// hiAt 计算签名中第 i 个成员的 H_i，可链接签名使用 HiLink
+func (t *transcript) hiAt(SignerResult *Sigma, i int) *big.Int {
+	if SignerResult.Tag != nil {
+		return t.HiLink(SignerResult.UI[i], SignerResult.WI[i])
+	}
+	return t.Hi(SignerResult.UI[i])
+}
+
+// appendBytes 以 8 字节大端长度前缀写入变长字节串
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// appendScalar 将标量对 Order 取模后编码为定长 32 字节写入
+func appendScalar(buf []byte, k *big.Int) []byte {
+	return append(buf, new(big.Int).Mod(k, blsOrder).FillBytes(make([]byte, 32))...)
+}
@@ -0,0 +1,453 @@
+package RSCP
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"sync/atomic"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// -------------------- 门限签名 --------------------
+//
+// t-of-n 门限签名证明环中至少有 t 个不同的成员参与了签名，但不透露是哪些成员。验证等式与普通签名相同：
+//
+//	e(P, V) = e(\sum_i (U_i + H_i \cdot pk_i), Q)
+//
+// 区别在于 H_i 不再逐个哈希，而是 H_i = a_i \cdot f(i+1)，其中 f 是次数不超过 n - t 的多项式，
+// f(0) = c = H(context, t, U_1, ..., U_n)，a_i = H(context, pk_i) 防止恶意构造的公钥相互抵消。签名中给出 f 的系数 F_1, ..., F_{n-t}。
+// 非签名者的 f(i+1) 可以任意选取并据此模拟 U_i = k_i \cdot P - H_i \cdot pk_i，但 f 由 c 与至多 n - t 个点确定，
+// 其余 t 个位置上的 H_j 在 U_j 确定之后才能算出，只有知道对应私钥才能给出 V 中 (r_j + H_j \cdot sk_j) \cdot Q 的部分。
+//
+// 多方签名分三步：各签名者调用 ThresholdCommit 生成一次性随机数并公开承诺 U_j = r_j \cdot P；
+// 汇总者收齐 t 个承诺后调用 NewThresholdAggregator 模拟其余成员并确定 f，把公开的会话 Session 发给各签名者；
+// 各签名者以自己的 RingContext 调用 ThresholdContribute 给出 V_j = (r_j + H_j \cdot sk_j) \cdot Q，汇总者逐个校验后由 Combine 合成签名。
+// 承诺、会话与贡献均可编码为 JSON 在各方之间传递（见 RingJSON.go）。汇总者知道参与签名的成员，验证者不知道。
+
+// ThresholdCommitment 签名者在第一轮公开的承诺：自己在环中的下标与 U_j = r_j \cdot P
+type ThresholdCommitment struct {
+	Index int
+	U     *bls.PointG1
+}
+
+// ThresholdNonce 签名者在第一轮生成的秘密随机数 r_j，只能用于一次 ThresholdContribute，之后被清零
+type ThresholdNonce struct {
+	index int
+	r     *big.Int
+	u     *bls.PointG1
+
+	used atomic.Bool
+}
+
+// ThresholdContribution 签名者在第二轮给出的贡献 V_j = (r_j + H_j \cdot sk_j) \cdot Q
+type ThresholdContribution struct {
+	Index int
+	V     *bls.PointG2
+}
+
+// ThresholdSession 汇总者收齐承诺后生成的签名会话，只包含可以公开给各签名者的部分
+type ThresholdSession struct {
+	Message []byte
+	// Threshold 门限 t，即参与签名的成员数
+	Threshold int
+	// Signers 参与签名的成员在环中的下标，按升序排列
+	Signers []int
+	// UI 全部成员的 U_i，F 多项式 f 的系数 F_1, ..., F_{n-t}
+	UI []*bls.PointG1
+	F  []*big.Int
+}
+
+// ThresholdAggregator 汇总者的私有状态，除公开的会话外还保存非签名者 k_i 之和，不能公开
+type ThresholdAggregator struct {
+	session *ThresholdSession
+	rc      *RingContext
+	// hi 各成员的 H_i，kSum 非签名者 k_i 之和，V 中非签名者的部分为 kSum \cdot Q
+	hi   []*big.Int
+	kSum *big.Int
+}
+
+// ThresholdCommit 第一轮：读取一次性随机数 r_j 并计算承诺 U_j = r_j \cdot P，签名者须在 rc 中
+// 不支持 WithDeterministic 与 WithScope
+func ThresholdCommit(rc *RingContext, SignerS *Signer, opts ...Option) (*ThresholdNonce, *ThresholdCommitment, error) {
+	cfg := NewConfig(opts...)
+	if err := checkThresholdConfig(cfg); err != nil {
+		return nil, nil, err
+	}
+	index, err := rc.FindSigner(SignerS)
+	if err != nil {
+		return nil, nil, err
+	}
+	return thresholdCommit(cfg.Random, index)
+}
+
+// thresholdCommit ThresholdCommit 的实现，随机数从 r 读取
+func thresholdCommit(r io.Reader, index int) (*ThresholdNonce, *ThresholdCommitment, error) {
+	k, err := RandomZqFrom(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	U := baseMulG1(k)
+	return &ThresholdNonce{index: index, r: k, u: U}, &ThresholdCommitment{Index: index, U: U}, nil
+}
+
+// NewThresholdAggregator 汇总者：以 t = len(commitments) 个承诺为签名者，为其余成员选取随机的 k_i 与 f(i+1)，
+// 模拟 U_i = k_i \cdot P - H_i \cdot pk_i，再由 c 与这些点插值得到 f，随机数按成员顺序从 WithRandom 指定的来源读取
+// 承诺的下标越界或重复、t 为 0 时返回 ErrInvalidThreshold
+func NewThresholdAggregator(Message []byte, rc *RingContext, commitments []*ThresholdCommitment, opts ...Option) (*ThresholdAggregator, error) {
+	cfg := NewConfig(opts...)
+	if err := checkThresholdConfig(cfg); err != nil {
+		return nil, err
+	}
+	n, t := rc.Len(), len(commitments)
+	if t == 0 || t > n {
+		return nil, fmt.Errorf("%w: t = %d，环大小为 %d", ErrInvalidThreshold, t, n)
+	}
+
+	// 1. 签名者的 U_j 取自承诺
+	UI := make([]*bls.PointG1, n)
+	signers := make([]int, 0, t)
+	for _, cm := range commitments {
+		if cm == nil || cm.U == nil || cm.Index < 0 || cm.Index >= n || UI[cm.Index] != nil {
+			return nil, fmt.Errorf("%w: 承诺为空、下标越界或重复", ErrInvalidThreshold)
+		}
+		if err := ValidatePoint(cm.U); err != nil {
+			return nil, fmt.Errorf("%w: 成员 %d 的承诺", err, cm.Index)
+		}
+		UI[cm.Index] = cm.U
+		signers = append(signers, cm.Index)
+	}
+	slices.Sort(signers)
+
+	// 2. 非签名者：按成员顺序读取 k_i 与 f(i+1)，U_i = k_i \cdot P - a_i f(i+1) \cdot pk_i
+	tr := newTranscript(TranscriptV2, Message, rc)
+	a := tr.keyCoefficients()
+	xs := []*big.Int{new(big.Int)}
+	ys := []*big.Int{nil}
+	kSum := new(big.Int)
+	for i := range UI {
+		if UI[i] != nil {
+			continue
+		}
+		k, err := RandomZqFrom(cfg.Random)
+		if err != nil {
+			return nil, err
+		}
+		y, err := RandomZqFrom(cfg.Random)
+		if err != nil {
+			return nil, err
+		}
+		kSum.Add(kSum, k)
+		UI[i] = SubG1(baseMulG1(k), ScalarMulG1(rc.pkList[i], MulZq(a[i], y)))
+		xs = append(xs, big.NewInt(int64(i+1)))
+		ys = append(ys, y)
+	}
+
+	// 3. f(0) = c，插值得到次数不超过 n - t 的 f，再计算全部成员的 H_i
+	ys[0] = tr.thresholdChallenge(t, UI)
+	coeffs := interpolate(xs, ys)
+	F := coeffs[1:]
+	return &ThresholdAggregator{
+		session: &ThresholdSession{
+			Message:   Message,
+			Threshold: t,
+			Signers:   signers,
+			UI:        UI,
+			F:         F,
+		},
+		rc:   rc,
+		hi:   tr.thresholdHi(t, UI, F),
+		kSum: kSum.Mod(kSum, blsOrder),
+	}, nil
+}
+
+// Session 返回发给各签名者的公开会话，调用方不能修改
+func (a *ThresholdAggregator) Session() *ThresholdSession {
+	return a.session
+}
+
+// ThresholdContribute 第二轮：签名者以自己的 rc 由公开的会话重新计算 H_j，确认会话使用了自己的承诺后给出 V_j
+// nonce 在计算前被标记为已使用，无论成功与否都会被清零；nonce 已被使用时返回 ErrNonceUsed
+func ThresholdContribute(rc *RingContext, session *ThresholdSession, nonce *ThresholdNonce, SignerS *Signer) (*ThresholdContribution, error) {
+	if err := nonce.take(); err != nil {
+		return nil, err
+	}
+	defer nonce.zeroize()
+	if rc == nil || session == nil {
+		return nil, fmt.Errorf("%w: 会话或公钥环为空", ErrSessionMismatch)
+	}
+	j := nonce.index
+	if SignerS == nil || SignerS.PrivateKey == nil || SignerS.PublicKey == nil {
+		return nil, ErrNilSigner
+	}
+	if index, ok := rc.Index(SignerS.PublicKey); !ok || index != j {
+		return nil, fmt.Errorf("%w: 签名者与随机数不对应", ErrSessionMismatch)
+	}
+	if err := session.check(rc.Len()); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(session.Signers, j) || !CompareG1(session.UI[j], nonce.u) {
+		return nil, fmt.Errorf("%w: 会话中没有成员 %d 的承诺", ErrSessionMismatch, j)
+	}
+
+	hi := newTranscript(TranscriptV2, session.Message, rc).thresholdHi(session.Threshold, session.UI, session.F)
+	return &ThresholdContribution{Index: j, V: ComputeV(nonce.r, hi[j], SignerS.PrivateKey)}, nil
+}
+
+// Combine 汇总者：逐个校验 e(P, V_j) = e(U_j + H_j \cdot pk_j, Q)，再合成 V = kSum \cdot Q + \sum_j V_j
+// 每个签名者须恰好有一份贡献，缺少、重复或校验失败时返回 ErrInvalidContribution
+func (a *ThresholdAggregator) Combine(contributions []*ThresholdContribution) (*Sigma, error) {
+	s := a.session
+	if len(contributions) != s.Threshold {
+		return nil, fmt.Errorf("%w: 收到 %d 份贡献，门限为 %d", ErrInvalidContribution, len(contributions), s.Threshold)
+	}
+	g2 := getG2()
+	defer putG2(g2)
+	V := g2.New()
+	g2.MulScalarBig(V, g2.One(), a.kSum)
+	seen := make(map[int]bool, len(contributions))
+	for _, ct := range contributions {
+		if ct == nil || ct.V == nil || seen[ct.Index] || !slices.Contains(s.Signers, ct.Index) {
+			return nil, fmt.Errorf("%w: 贡献为空、重复或不属于签名者", ErrInvalidContribution)
+		}
+		seen[ct.Index] = true
+		j := ct.Index
+		if err := ValidateG2Point(ct.V); err != nil {
+			return nil, fmt.Errorf("%w: 成员 %d: %w", ErrInvalidContribution, j, err)
+		}
+		if !VerifyPairing(AddG1(s.UI[j], ScalarMulG1(a.rc.pkList[j], a.hi[j])), ct.V) {
+			return nil, fmt.Errorf("%w: 成员 %d", ErrInvalidContribution, j)
+		}
+		g2.Add(V, V, ct.V)
+	}
+
+	return &Sigma{
+		UI:        slices.Clone(s.UI),
+		V:         V,
+		Version:   TranscriptV2,
+		Threshold: s.Threshold,
+		F:         slices.Clone(s.F),
+	}, nil
+}
+
+// SignThreshold 在同一进程中完成 len(Signers) 个签名者的门限签名，依次执行承诺、建立会话、贡献与合成
+// 随机数按签名者的顺序、再按会话的顺序从 WithRandom 指定的来源读取；签名者重复时返回 ErrInvalidThreshold
+func SignThreshold(Message []byte, PKList []*bls.PointG1, Signers []*Signer, opts ...Option) (*Sigma, error) {
+	rc, err := NewRingContext(PKList)
+	if err != nil {
+		return nil, err
+	}
+	cfg := NewConfig(opts...)
+	if err := checkThresholdConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	// 1. 各签名者生成承诺
+	nonces := make([]*ThresholdNonce, len(Signers))
+	commitments := make([]*ThresholdCommitment, len(Signers))
+	defer func() {
+		for _, nonce := range nonces {
+			if nonce != nil {
+				nonce.zeroize()
+			}
+		}
+	}()
+	for i, SignerS := range Signers {
+		index, err := rc.FindSigner(SignerS)
+		if err != nil {
+			return nil, err
+		}
+		if nonces[i], commitments[i], err = thresholdCommit(cfg.Random, index); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. 建立会话，各签名者给出贡献后合成
+	aggregator, err := NewThresholdAggregator(Message, rc, commitments, WithRandom(cfg.Random))
+	if err != nil {
+		return nil, err
+	}
+	contributions := make([]*ThresholdContribution, len(Signers))
+	for i, SignerS := range Signers {
+		if contributions[i], err = ThresholdContribute(rc, aggregator.Session(), nonces[i], SignerS); err != nil {
+			return nil, err
+		}
+	}
+	return aggregator.Combine(contributions)
+}
+
+// check 校验大小为 n 的环上的会话：门限与各字段的长度一致，签名者下标升序且不越界，U_i 合法
+func (s *ThresholdSession) check(n int) error {
+	if s.Threshold < 1 || s.Threshold > n || len(s.Signers) != s.Threshold || len(s.UI) != n || len(s.F) != n-s.Threshold {
+		return fmt.Errorf("%w: 会话结构不完整", ErrSessionMismatch)
+	}
+	for k, j := range s.Signers {
+		if j < 0 || j >= n || (k > 0 && j <= s.Signers[k-1]) {
+			return fmt.Errorf("%w: 签名者下标须升序且不越界", ErrSessionMismatch)
+		}
+	}
+	for i, U := range s.UI {
+		if U == nil {
+			return fmt.Errorf("%w: U_%d 为 nil", ErrSessionMismatch, i)
+		}
+		if err := checkSubgroup(U); err != nil {
+			return fmt.Errorf("%w: U_%d", err, i)
+		}
+	}
+	for _, k := range s.F {
+		if k == nil || k.Sign() < 0 || k.Cmp(blsOrder) >= 0 {
+			return fmt.Errorf("%w: 系数超出 Z_q 范围", ErrSessionMismatch)
+		}
+	}
+	return nil
+}
+
+// take 把随机数标记为已使用，随机数为 nil 或已被使用时返回 ErrNonceUsed
+func (n *ThresholdNonce) take() error {
+	if n == nil || !n.used.CompareAndSwap(false, true) {
+		return ErrNonceUsed
+	}
+	return nil
+}
+
+// zeroize 清零随机数 r_j
+func (n *ThresholdNonce) zeroize() {
+	n.used.Store(true)
+	zeroizeInt(n.r)
+}
+
+// zeroizeInt 覆盖 k 的底层字后将其置零
+func zeroizeInt(k *big.Int) {
+	if k == nil {
+		return
+	}
+	clear(k.Bits())
+	k.SetInt64(0)
+}
+
+// checkThresholdConfig 门限签名只使用 v2 转录编码，且不支持确定性模式与可链接模式
+func checkThresholdConfig(cfg *Config) error {
+	if cfg.Transcript != TranscriptV2 {
+		return fmt.Errorf("%w: 门限签名须使用 v2 转录编码", ErrUnsupportedTranscript)
+	}
+	if cfg.Deterministic || cfg.Linkable {
+		return ErrThresholdOption
+	}
+	return nil
+}
+
+// keyCoefficients 计算各成员公钥的系数 a_i = H(context, pk_i)
+func (t *transcript) keyCoefficients() []*big.Int {
+	a := make([]*big.Int, len(t.PKList))
+	for i, pk := range t.PKList {
+		a[i] = HashToZqV2(TagThresholdKey, t.context, pk)
+	}
+	return a
+}
+
+// thresholdChallenge 计算门限签名的挑战值 c = f(0) = H(context, t, U_1, ..., U_n)
+func (t *transcript) thresholdChallenge(threshold int, UI []*bls.PointG1) *big.Int {
+	return HashToZqV2(TagThreshold, t.context, big.NewInt(int64(threshold)), UI)
+}
+
+// thresholdHi 计算门限签名中各成员的 H_i = a_i \cdot f(i+1)，f(x) = c + \sum_k F_k x^k
+func (t *transcript) thresholdHi(threshold int, UI []*bls.PointG1, F []*big.Int) []*big.Int {
+	coeffs := append([]*big.Int{t.thresholdChallenge(threshold, UI)}, F...)
+	a := t.keyCoefficients()
+	HiList := make([]*big.Int, len(UI))
+	for i := range HiList {
+		HiList[i] = MulZq(a[i], evalPoly(coeffs, big.NewInt(int64(i+1))))
+	}
+	return HiList
+}
+
+// evalPoly 用 Horner 法计算 \sum_k coeffs[k] \cdot x^k mod q
+func evalPoly(coeffs []*big.Int, x *big.Int) *big.Int {
+	y := new(big.Int)
+	for k := len(coeffs) - 1; k >= 0; k-- {
+		y.Mul(y, x)
+		y.Add(y, coeffs[k])
+		y.Mod(y, blsOrder)
+	}
+	return y
+}
+
+// interpolate 返回经过点 (xs[i], ys[i]) 的次数不超过 len(xs) - 1 的多项式在 Z_q 上的系数，xs 须两两不同
+// 先计算 M(x) = \prod_m (x - x_m)，每个 Lagrange 基多项式的分子为 M(x) / (x - x_j)，总计 O(d^2) 次乘法
+func interpolate(xs, ys []*big.Int) []*big.Int {
+	d := len(xs)
+	q := blsOrder
+
+	// 1. M(x) 的系数，M[k] 为 x^k 的系数
+	M := make([]*big.Int, d+1)
+	M[0] = big.NewInt(1)
+	for k := 1; k <= d; k++ {
+		M[k] = new(big.Int)
+	}
+	for m, x := range xs {
+		for k := m + 1; k > 0; k-- {
+			M[k] = new(big.Int).Sub(M[k-1], new(big.Int).Mul(x, M[k]))
+			M[k].Mod(M[k], q)
+		}
+		M[0] = new(big.Int).Neg(new(big.Int).Mul(x, M[0]))
+		M[0].Mod(M[0], q)
+	}
+
+	coeffs := make([]*big.Int, d)
+	for k := range coeffs {
+		coeffs[k] = new(big.Int)
+	}
+	num := make([]*big.Int, d)
+	for j, xj := range xs {
+		// 2. 综合除法：num(x) = M(x) / (x - x_j)，同时计算 denom = \prod_{m \ne j} (x_j - x_m)
+		carry := new(big.Int).Set(M[d])
+		for k := d - 1; k >= 0; k-- {
+			num[k] = carry
+			carry = new(big.Int).Add(M[k], new(big.Int).Mul(xj, carry))
+			carry.Mod(carry, q)
+		}
+		denom := big.NewInt(1)
+		for m, xm := range xs {
+			if m != j {
+				denom.Mul(denom, new(big.Int).Sub(xj, xm))
+				denom.Mod(denom, q)
+			}
+		}
+
+		// 3. 累加 y_j / denom \cdot num(x)
+		scale := MulZq(ys[j], new(big.Int).ModInverse(denom, q))
+		for k := range coeffs {
+			coeffs[k].Add(coeffs[k], new(big.Int).Mul(scale, num[k]))
+			coeffs[k].Mod(coeffs[k], q)
+		}
+	}
+	return coeffs
+}
+
+// checkThreshold 校验门限部分的结构：Threshold 为 0 时不能有系数；否则须使用 v2 编码、不能同时是可链接签名，
+// 且 1 <= t <= n、系数个数为 n - t
+func checkThreshold(SignerResult *Sigma) error {
+	t, n := SignerResult.Threshold, len(SignerResult.UI)
+	if t == 0 {
+		if SignerResult.F != nil {
+			return fmt.Errorf("%w: 缺少门限", ErrMalformedSignature)
+		}
+		return nil
+	}
+	if SignerResult.Version != TranscriptV2 {
+		return fmt.Errorf("%w: 门限签名须使用 v2 转录编码", ErrMalformedSignature)
+	}
+	if SignerResult.Tag != nil {
+		return fmt.Errorf("%w: 门限签名不能是可链接签名", ErrMalformedSignature)
+	}
+	if t < 0 || t > n || len(SignerResult.F) != n-t {
+		return fmt.Errorf("%w: 门限 %d 与环大小 %d、系数个数 %d 不符", ErrMalformedSignature, t, n, len(SignerResult.F))
+	}
+	for _, k := range SignerResult.F {
+		if k == nil || k.Sign() < 0 || k.Cmp(blsOrder) >= 0 {
+			return fmt.Errorf("%w: 标量超出 Z_q 范围", ErrMalformedSignature)
+		}
+	}
+	return nil
+}
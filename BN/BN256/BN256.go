@@ -0,0 +1,17 @@
+// Package BN256 为 BN/BRFL 与 BN/RSCP 选择 BN254 曲线的实现
+//
+// 默认使用 go-ethereum 的 crypto/bn256/google（纯 Go 参考实现）；
+// 以构建标签 bn256cloudflare 编译时改用 crypto/bn256/cloudflare（部分汇编优化，速度更快）：
+//
+//	go build -tags bn256cloudflare ./...
+//
+// 两种实现的点编码完全相同，任一实现生成的密钥与签名都能被另一实现验证
+package BN256
+
+// Backends 可选的实现名称
+const (
+	// BackendGoogle crypto/bn256/google
+	BackendGoogle = "google"
+	// BackendCloudflare crypto/bn256/cloudflare
+	BackendCloudflare = "cloudflare"
+)
@@ -0,0 +1,91 @@
+package BN256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	cloudflare "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	google "github.com/ethereum/go-ethereum/crypto/bn256/google"
+)
+
+// randomScalar 返回 [1, Order) 中的随机标量
+func randomScalar(t *testing.T) *big.Int {
+	t.Helper()
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(google.Order, big.NewInt(1)))
+	if err != nil {
+		t.Fatalf("生成随机数失败: %v", err)
+	}
+	return k.Add(k, big.NewInt(1))
+}
+
+// 测试两种实现的群阶、生成元、点运算与编码完全一致，编码可在两种实现之间互相解析
+func TestBackendsAgree(t *testing.T) {
+	if google.Order.Cmp(cloudflare.Order) != 0 || Order.Cmp(google.Order) != 0 {
+		t.Fatalf("两种实现的群阶不同")
+	}
+
+	for i := 0; i < 8; i++ {
+		a, b := randomScalar(t), randomScalar(t)
+
+		// G1: a·P 的编码相同，且对方解析后继续计算 b·(a·P) 得到相同结果
+		g1g := new(google.G1).ScalarBaseMult(a).Marshal()
+		g1c := new(cloudflare.G1).ScalarBaseMult(a).Marshal()
+		if !bytes.Equal(g1g, g1c) {
+			t.Fatalf("G1 编码不同")
+		}
+		pg, pc := new(google.G1), new(cloudflare.G1)
+		if _, err := pg.Unmarshal(g1c); err != nil {
+			t.Fatalf("google 解析 cloudflare 的 G1 失败: %v", err)
+		}
+		if _, err := pc.Unmarshal(g1g); err != nil {
+			t.Fatalf("cloudflare 解析 google 的 G1 失败: %v", err)
+		}
+		sumG := new(google.G1).Add(new(google.G1).ScalarMult(pg, b), pg)
+		sumC := new(cloudflare.G1).Add(new(cloudflare.G1).ScalarMult(pc, b), pc)
+		if !bytes.Equal(sumG.Marshal(), sumC.Marshal()) {
+			t.Fatalf("G1 运算结果不同")
+		}
+
+		// G2: 编码相同并可互相解析
+		g2g := new(google.G2).ScalarBaseMult(b).Marshal()
+		g2c := new(cloudflare.G2).ScalarBaseMult(b).Marshal()
+		if !bytes.Equal(g2g, g2c) {
+			t.Fatalf("G2 编码不同")
+		}
+		qg, qc := new(google.G2), new(cloudflare.G2)
+		if _, err := qg.Unmarshal(g2c); err != nil {
+			t.Fatalf("google 解析 cloudflare 的 G2 失败: %v", err)
+		}
+		if _, err := qc.Unmarshal(g2g); err != nil {
+			t.Fatalf("cloudflare 解析 google 的 G2 失败: %v", err)
+		}
+
+		// 配对: e(a·P, b·Q) \cdot e(-ab·P, Q) = 1，使用从对方解析得到的点
+		ab := new(big.Int).Mul(a, b)
+		ab.Mod(ab, google.Order)
+		negG := new(google.G1).Neg(new(google.G1).ScalarBaseMult(ab))
+		negC := new(cloudflare.G1).Neg(new(cloudflare.G1).ScalarBaseMult(ab))
+		oneG := new(google.G2).ScalarBaseMult(big.NewInt(1))
+		oneC := new(cloudflare.G2).ScalarBaseMult(big.NewInt(1))
+		if !google.PairingCheck([]*google.G1{pg, negG}, []*google.G2{qg, oneG}) {
+			t.Fatalf("google 配对校验失败")
+		}
+		if !cloudflare.PairingCheck([]*cloudflare.G1{pc, negC}, []*cloudflare.G2{qc, oneC}) {
+			t.Fatalf("cloudflare 配对校验失败")
+		}
+	}
+}
+
+// 测试当前构建选择的实现
+func TestBackend(t *testing.T) {
+	if Backend != BackendGoogle && Backend != BackendCloudflare {
+		t.Fatalf("未知的实现: %s", Backend)
+	}
+	p := new(G1).ScalarBaseMult(big.NewInt(2))
+	q := new(G1).Add(new(G1).ScalarBaseMult(big.NewInt(1)), new(G1).ScalarBaseMult(big.NewInt(1)))
+	if !bytes.Equal(p.Marshal(), q.Marshal()) {
+		t.Fatalf("%s: 2·P != P + P", Backend)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !bn256cloudflare
+
+package BN256
+
+import bn256 "github.com/ethereum/go-ethereum/crypto/bn256/google"
+
+// Backend 当前编译使用的实现
+const Backend = BackendGoogle
+
+// G1 BN254 上的 G1 群元素
+type G1 = bn256.G1
+
+// G2 BN254 上的 G2 群元素
+type G2 = bn256.G2
+
+// GT 配对的目标群元素
+type GT = bn256.GT
+
+// Order G1、G2 的阶
+var Order = bn256.Order
+
+// P 基域的特征
+var P = bn256.P
+
+// PairingCheck 计算 \prod_i e(a_i, b_i) 并判断是否为单位元
+func PairingCheck(a []*G1, b []*G2) bool {
+	return bn256.PairingCheck(a, b)
+}
@@ -0,0 +1,93 @@
+package BRFL
+
+import (
+	bn256 "BRFL/BN/BN256"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ringRef 以切片首元素的地址与长度标识同一个公钥环
+type ringRef struct {
+	first **bn256.G1
+	n     int
+}
+
+// cachedRing 同一个公钥环的 RingContext 或校验错误
+type cachedRing struct {
+	rc  *RingContext
+	err error
+}
+
+// VerifyBatch 批量验证多个环签名，返回与输入一一对应的验证结果，nil 表示签名合法
+// 传入同一个 PKList 切片的签名共用同一个 RingContext，指定 WithPrecompute 时为其构造定点预计算表；结构校验串行完成，挑战值的重新计算由 WithWorkers 指定的协程数并发完成，协程数不超过待验证的签名数
+// Messages、PKLists 与 SignerResults 个数不一致，或 WithTracing 指定的管理者公钥非法时返回错误
+func VerifyBatch(Messages [][]byte, PKLists [][]*bn256.G1, SignerResults []*Sigma, opts ...Option) ([]error, error) {
+	if len(Messages) != len(PKLists) || len(Messages) != len(SignerResults) {
+		return nil, fmt.Errorf("%w: 消息 %d 条，公钥环 %d 个，签名 %d 个",
+			ErrBatchLengthMismatch, len(Messages), len(PKLists), len(SignerResults))
+	}
+	cfg := NewConfig(opts...)
+	// 管理者公钥对整批签名相同，只校验一次；ValidatePoint 同时把它转换为仿射坐标，并发阶段只会读取
+	if cfg.Tracing != nil {
+		if err := ValidatePoint(cfg.Tracing); err != nil {
+			return nil, fmt.Errorf("%w: 管理者公钥", err)
+		}
+	}
+	results := make([]error, len(SignerResults))
+
+	// 1. 串行阶段：每个公钥环只校验一次，再校验各签名的结构与转录版本
+	// bn256 的 Marshal 会把点原地转换为仿射坐标，串行阶段处理完所有点后，并发阶段只会读取它们
+	rings := make(map[ringRef]cachedRing)
+	contexts := make([]*RingContext, len(PKLists))
+	pending := make([]int, 0, len(SignerResults))
+	for j, PKList := range PKLists {
+		ref := ringRef{n: len(PKList)}
+		if len(PKList) > 0 {
+			ref.first = &PKList[0]
+		}
+		ring, ok := rings[ref]
+		if !ok {
+			ring.rc, ring.err = NewRingContext(PKList, opts...)
+			rings[ref] = ring
+		}
+		contexts[j] = ring.rc
+		err := ring.err
+		if err == nil {
+			err = checkVerifyInput(cfg, ring.rc, SignerResults[j])
+		}
+		if err != nil {
+			results[j] = err
+			continue
+		}
+		for _, v := range SignerResults[j].UI {
+			v.Marshal()
+		}
+		for _, p := range []*bn256.G1{SignerResults[j].KeyImage, SignerResults[j].TE1, SignerResults[j].TE2} {
+			if p != nil {
+				p.Marshal()
+			}
+		}
+		pending = append(pending, j)
+	}
+
+	// 2. 并发阶段：各协程从队列中取出签名，重新计算挑战值
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(cfg.Workers, len(pending)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j] = verifyChallenge(context.Background(), 1, Messages[j], contexts[j], SignerResults[j], cfg.Tracing)
+			}
+		}()
+	}
+	for _, j := range pending {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
@@ -1,7 +1,13 @@
 package BRFL
 
 import (
-	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/google"
+	bn256 "BRFL/BN/BN256"
+	"BRFL/DRBG"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"math/big"
 )
 
@@ -14,7 +20,7 @@ func ComputePi(t *big.Int, e *big.Int, SS *big.Int) (Pi *big.Int) {
 	return
 }
 
-func ComputeC(rS *big.Int, skS *big.Int, pkS *bn256.G1, CS *big.Int, RM *bn256.G1, SS *big.Int) (C *big.Int) {
+func ComputeC(rS *big.Int, skS *big.Int, pkS *bn256.G1, CS *big.Int, RM *bn256.G1, SS *big.Int, version TranscriptVersion) (C *big.Int) {
 
 	// 1. 计算 tmp1 = r_s \cdot \mathit{sk}_s
 	tmp1 := MulZq(rS, skS)
@@ -31,7 +37,7 @@ func ComputeC(rS *big.Int, skS *big.Int, pkS *bn256.G1, CS *big.Int, RM *bn256.G
 	// 5. 计算 tmp3 + tmp4
 	tmp5 := AddG1(tmp3, tmp4)
 
-	C = HashToZq(tmp2, tmp5)
+	C = HashTranscript(version, TagC, tmp2, tmp5)
 
 	return
 }
@@ -55,18 +61,7 @@ func ComputeUS(rS_ *big.Int, pkS *bn256.G1, UiList []*bn256.G1, HiList []*big.In
 	tmp1 := ScalarMulG1(pkS, rS_)
 
 	// 2. 计算 tmpSum = \sum_{i \ne s}\Bigl(U_i + H_i \cdot \mathit{pk}_i\Bigr)
-	tmpSum := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
-	for i, v := range UiList {
-		if flag == i {
-			continue
-		}
-		// 计算 tmp2 = H_i \cdot \mathit{pk}_i
-		tmp2 := ScalarMulG1(PKList[i], HiList[i])
-		// 计算 tmpSumPart = U_i + tmp2
-		tmpSumPart := AddG1(v, tmp2)
-
-		tmpSum = AddG1(tmpSum, tmpSumPart)
-	}
+	tmpSum := ComputeSum(HiList, PKList, UiList, flag)
 
 	// 3. 计算 tmp1 - tmpSum
 	US = SubG1(tmp1, tmpSum)
@@ -84,7 +79,7 @@ func ComputeSS(rS *big.Int, CS *big.Int, rM *big.Int) (SS *big.Int) {
 	return
 }
 
-func ComputeCS(rS *big.Int, skS *big.Int, pkS *bn256.G1, RS *bn256.G1) (CS *big.Int) {
+func ComputeCS(rS *big.Int, skS *big.Int, pkS *bn256.G1, RS *bn256.G1, version TranscriptVersion) (CS *big.Int) {
 
 	// 1. 计算 tmp1 = r_s \cdot \mathit{sk}_s
 	tmp1 := MulZq(rS, skS)
@@ -93,7 +88,221 @@ func ComputeCS(rS *big.Int, skS *big.Int, pkS *bn256.G1, RS *bn256.G1) (CS *big.
 	tmp2 := ScalarMulG1(pkS, tmp1)
 
 	// 3. 计算 C_s = H\bigl(r_s \cdot \mathit{sk}_s \cdot \mathit{pk}_s \,\|\, R_s\bigr)
-	CS = HashToZq(tmp2, RS)
+	CS = HashTranscript(version, TagC, tmp2, RS)
 
 	return
 }
+
+// ComputeSum 排除 signer_index 后计算 H_i * PK_i + U_i 的和
+// 其中 \sum H_i * PK_i 部分通过 MultiScalarMulG1 一次算出，U_i 直接累加
+func ComputeSum(H_i []*big.Int, PK_List []*bn256.G1, U_i []*bn256.G1, signer_index int) *bn256.G1 {
+	points := make([]*bn256.G1, 0, len(H_i))
+	scalars := make([]*big.Int, 0, len(H_i))
+	sum := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+	for i := range H_i {
+		if i == signer_index {
+			continue
+		}
+		points = append(points, PK_List[i])
+		scalars = append(scalars, H_i[i])
+		sum = AddG1(sum, U_i[i])
+	}
+	return AddG1(sum, MultiScalarMulG1(points, scalars))
+}
+
+// ValidatePoint 校验 G1 点：不能为 nil，也不能是无穷远点
+// bn256 的 G1 余因子为 1，且 Unmarshal 已校验点在曲线上，因此无需额外的子群校验
+func ValidatePoint(p *bn256.G1) error {
+	if err := checkSubgroup(p); err != nil {
+		return err
+	}
+	if isIdentity(p.Marshal()) {
+		return ErrIdentityPoint
+	}
+	return nil
+}
+
+// checkSubgroup 校验 G1 点属于素数阶子群，允许无穷远点；G1 余因子为 1，已初始化的点即满足要求
+func checkSubgroup(p *bn256.G1) error {
+	if p == nil || *p == (bn256.G1{}) {
+		return ErrInvalidPoint
+	}
+	return nil
+}
+
+// isIdentity 判断 Marshal 的结果是否为无穷远点的编码（全零）
+func isIdentity(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateRing 校验公钥环：不能为空、不能包含 nil 公钥、无效点或无穷远点、不能包含重复公钥
+// 返回公钥序列化结果到下标的映射，便于后续定位签名者
+func ValidateRing(PKList []*bn256.G1) (index map[string]int, err error) {
+	return validateRing(context.Background(), PKList)
+}
+
+// validateRing ValidateRing 的实现，每 sumChunk 个公钥检查一次 ctx，ctx 被取消时返回 ctx.Err()
+func validateRing(ctx context.Context, PKList []*bn256.G1) (index map[string]int, err error) {
+	if len(PKList) == 0 {
+		return nil, ErrEmptyRing
+	}
+
+	index = make(map[string]int, len(PKList))
+	for i, v := range PKList {
+		if i%sumChunk == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if v == nil {
+			return nil, fmt.Errorf("%w: 下标 %d", ErrNilPublicKey, i)
+		}
+		if err := ValidatePoint(v); err != nil {
+			return nil, fmt.Errorf("%w: 下标 %d", err, i)
+		}
+		key := string(v.Marshal())
+		if j, ok := index[key]; ok {
+			return nil, fmt.Errorf("%w: 下标 %d 与 %d", ErrDuplicatePublicKey, j, i)
+		}
+		index[key] = i
+	}
+	return
+}
+
+// FindSigner 校验公钥环与签名者的密钥对，并返回签名者公钥在 PKList 中的下标
+func FindSigner(PKList []*bn256.G1, SignerS *Signer) (flag int, err error) {
+	if SignerS == nil || SignerS.PrivateKey == nil || SignerS.PublicKey == nil {
+		return -1, ErrNilSigner
+	}
+
+	index, err := ValidateRing(PKList)
+	if err != nil {
+		return -1, err
+	}
+
+	flag, ok := index[string(SignerS.PublicKey.Marshal())]
+	if !ok {
+		return -1, ErrSignerNotInRing
+	}
+	if !matchKeyPair(SignerS) {
+		return -1, ErrKeyMismatch
+	}
+	return flag, nil
+}
+
+// matchKeyPair 判断签名者的公钥是否等于 PrivateKey \cdot P，密钥不匹配时签名无法通过验证
+func matchKeyPair(SignerS *Signer) bool {
+	return CompareG1(new(bn256.G1).ScalarBaseMult(SignerS.PrivateKey), SignerS.PublicKey)
+}
+
+// CheckSigma 校验签名结构本身：字段不能为 nil，标量须落在 [0, Order) 内
+// 所有点须为素数阶子群中的有效点，且 R_M 与 T 不能是无穷远点
+func CheckSigma(SignerResult *Sigma) error {
+	if SignerResult == nil || SignerResult.RM == nil || SignerResult.T == nil ||
+		SignerResult.V == nil || SignerResult.C == nil || SignerResult.Pi == nil {
+		return fmt.Errorf("%w: 缺少签名字段", ErrMalformedSignature)
+	}
+	if err := ValidatePoint(SignerResult.RM); err != nil {
+		return fmt.Errorf("%w: R_M", err)
+	}
+	if err := ValidatePoint(SignerResult.T); err != nil {
+		return fmt.Errorf("%w: T", err)
+	}
+
+	for i, v := range SignerResult.UI {
+		if v == nil {
+			return fmt.Errorf("%w: U_%d 为 nil", ErrMalformedSignature, i)
+		}
+		if err := checkSubgroup(v); err != nil {
+			return fmt.Errorf("%w: U_%d", err, i)
+		}
+	}
+
+	for _, k := range []*big.Int{SignerResult.V, SignerResult.C, SignerResult.Pi} {
+		if k.Sign() < 0 || k.Cmp(bn256.Order) >= 0 {
+			return fmt.Errorf("%w: 标量超出 Z_q 范围", ErrMalformedSignature)
+		}
+	}
+	if err := checkLinkable(SignerResult); err != nil {
+		return err
+	}
+	return checkTrace(SignerResult)
+}
+
+// ValidateSigma 校验签名结构，并要求 U_i 的个数与环大小一致
+func ValidateSigma(PKList []*bn256.G1, SignerResult *Sigma) error {
+	if err := CheckSigma(SignerResult); err != nil {
+		return err
+	}
+	if len(SignerResult.UI) != len(PKList) {
+		return fmt.Errorf("%w: U_i 个数为 %d，环大小为 %d", ErrRingSizeMismatch, len(SignerResult.UI), len(PKList))
+	}
+	return nil
+}
+
+// checkVerifyInput 验证前的公共检查：签名结构、cfg 是否接受签名的转录版本，以及可链接、可追踪模式下签名是否带有对应的部分
+func checkVerifyInput(cfg *Config, rc *RingContext, SignerResult *Sigma) error {
+	if err := ValidateSigma(rc.pkList, SignerResult); err != nil {
+		return err
+	}
+	if !cfg.AcceptsTranscript(SignerResult.Version) {
+		return fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, SignerResult.Version)
+	}
+	if cfg.Linkable && SignerResult.KeyImage == nil {
+		return ErrNotLinkable
+	}
+	if cfg.Tracing != nil && SignerResult.TE1 == nil {
+		return ErrNotTraceable
+	}
+	if cfg.Tracing == nil && SignerResult.TE1 != nil {
+		return ErrTracingKeyRequired
+	}
+	if cfg.Tracing != nil {
+		if err := ValidatePoint(cfg.Tracing); err != nil {
+			return fmt.Errorf("%w: 管理者公钥", err)
+		}
+	}
+	return nil
+}
+
+// NewNonceReader 构造确定性签名模式下的随机数来源
+// HMAC-DRBG 以定长编码的私钥为熵，以签名选项、消息与公钥环的摘要为 nonce，cfg.ExtraEntropy 追加在个性化字符串之后
+// 影响挑战值的选项都写入 nonce，同一消息在不同选项下签名时不会复用随机数，否则由两个签名即可解出私钥
+func NewNonceReader(sk *big.Int, Message []byte, PKList []*bn256.G1, cfg *Config) io.Reader {
+	// 1. 私钥编码为定长 32 字节
+	entropy := new(big.Int).Mod(sk, bn256.Order).FillBytes(make([]byte, 32))
+
+	// 2. nonce = SHA256(options || len(Message) || Message || pk_1 || ... || pk_n)
+	h := sha256.New()
+	h.Write(nonceOptions(cfg))
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(Message)))
+	h.Write(length[:])
+	h.Write(Message)
+	for _, pk := range PKList {
+		h.Write(pk.Marshal())
+	}
+
+	personalization := append([]byte(NonceDomain), cfg.ExtraEntropy...)
+	return DRBG.New(entropy, h.Sum(nil), personalization)
+}
+
+// nonceOptions 编码影响挑战值的签名选项，作为确定性签名 nonce 的前缀：
+// 转录版本 (1) || 是否可链接 (1) || 是否可追踪 (1) [ || 管理者公钥 (PointSize) ]
+// 管理者公钥写入 nonce 后，同一消息为不同管理者签名时 ρ 不同，否则由两个 E_2 即可解出签名者公钥
+func nonceOptions(cfg *Config) []byte {
+	buf := []byte{byte(cfg.Transcript), 0, 0}
+	if cfg.Linkable {
+		buf[1] = 1
+	}
+	if cfg.Tracing != nil {
+		buf[2] = 1
+		buf = append(buf, encodePoint(cfg.Tracing)...)
+	}
+	return buf
+}
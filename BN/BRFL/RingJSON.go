@@ -0,0 +1,332 @@
+package BRFL
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	bn256 "BRFL/BN/BN256"
+)
+
+// -------------------- JSON 编码 --------------------
+//
+// 点与标量均编码为十六进制字符串，字节格式与二进制编码相同（见 RingEncoding.go）。
+// 每个 JSON 文档都带有 scheme 字段，解码时会拒绝未知字段并完整校验所有取值。
+
+// sigmaJSON Sigma 的 JSON 表示
+type sigmaJSON struct {
+	Scheme  string   `json:"scheme"`
+	Version uint8    `json:"version"`
+	RM      string   `json:"rm"`
+	UI      []string `json:"ui"`
+	V       string   `json:"v"`
+	C       string   `json:"c"`
+	T       string   `json:"t"`
+	Pi      string   `json:"pi"`
+	// 以下字段仅出现在可链接签名中
+	KeyImage string   `json:"key_image,omitempty"`
+	LC       string   `json:"lc,omitempty"`
+	LR       []string `json:"lr,omitempty"`
+	// 以下字段仅出现在可追踪签名中
+	TE1 string   `json:"te1,omitempty"`
+	TE2 string   `json:"te2,omitempty"`
+	TC  string   `json:"tc,omitempty"`
+	TZ  []string `json:"tz,omitempty"`
+	TY  []string `json:"ty,omitempty"`
+}
+
+// publicKeyJSON 公钥的 JSON 表示
+type publicKeyJSON struct {
+	Scheme string `json:"scheme"`
+	PK     string `json:"pk"`
+}
+
+// ringMemberJSON 公钥环成员的 JSON 表示
+type ringMemberJSON struct {
+	Label string `json:"label,omitempty"`
+	PK    string `json:"pk"`
+}
+
+// ringJSON 公钥环的 JSON 表示
+type ringJSON struct {
+	Scheme  string           `json:"scheme"`
+	Members []ringMemberJSON `json:"members"`
+}
+
+// RingMember 公钥环中的一个成员，Label 为可选的标签
+type RingMember struct {
+	Label     string
+	PublicKey *bn256.G1
+}
+
+// Ring 公钥环文档，成员的顺序即 PKList 的顺序
+type Ring struct {
+	Members []RingMember
+}
+
+// PKList 返回公钥环文档中按顺序排列的公钥列表
+func (r *Ring) PKList() []*bn256.G1 {
+	PKList := make([]*bn256.G1, len(r.Members))
+	for i, m := range r.Members {
+		PKList[i] = m.PublicKey
+	}
+	return PKList
+}
+
+// MarshalJSON 将签名编码为 JSON，实现 json.Marshaler
+func (s *Sigma) MarshalJSON() ([]byte, error) {
+	if err := CheckSigma(s); err != nil {
+		return nil, err
+	}
+
+	out := sigmaJSON{
+		Scheme:  SchemeName,
+		Version: uint8(s.Version),
+		RM:      hex.EncodeToString(encodePoint(s.RM)),
+		UI:      make([]string, len(s.UI)),
+		V:       hex.EncodeToString(appendScalar(nil, s.V)),
+		C:       hex.EncodeToString(appendScalar(nil, s.C)),
+		T:       hex.EncodeToString(encodePoint(s.T)),
+		Pi:      hex.EncodeToString(appendScalar(nil, s.Pi)),
+	}
+	for i, v := range s.UI {
+		out.UI[i] = hex.EncodeToString(encodePoint(v))
+	}
+	if s.KeyImage != nil {
+		out.KeyImage = hex.EncodeToString(encodePoint(s.KeyImage))
+		out.LC = hex.EncodeToString(appendScalar(nil, s.LC))
+		out.LR = encodeHexScalars(s.LR)
+	}
+	if s.TE1 != nil {
+		out.TE1 = hex.EncodeToString(encodePoint(s.TE1))
+		out.TE2 = hex.EncodeToString(encodePoint(s.TE2))
+		out.TC = hex.EncodeToString(appendScalar(nil, s.TC))
+		out.TZ = encodeHexScalars(s.TZ)
+		out.TY = encodeHexScalars(s.TY)
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 从 JSON 解码签名，实现 json.Unmarshaler，并按 CheckSigma 拒绝无效点，失败时 s 保持不变
+func (s *Sigma) UnmarshalJSON(data []byte) error {
+	var in sigmaJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+	version := TranscriptVersion(in.Version)
+	if !version.Supported() {
+		return fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, version)
+	}
+
+	RM, err := decodeHexPoint(in.RM)
+	if err != nil {
+		return err
+	}
+	UI, err := decodeHexPoints(in.UI)
+	if err != nil {
+		return err
+	}
+	V, err := decodeHexScalar(in.V)
+	if err != nil {
+		return err
+	}
+	C, err := decodeHexScalar(in.C)
+	if err != nil {
+		return err
+	}
+	T, err := decodeHexPoint(in.T)
+	if err != nil {
+		return err
+	}
+	Pi, err := decodeHexScalar(in.Pi)
+	if err != nil {
+		return err
+	}
+
+	sig := Sigma{RM: RM, UI: UI, V: V, C: C, T: T, Pi: Pi, Version: version}
+	if in.KeyImage != "" || in.LC != "" || in.LR != nil {
+		if sig.KeyImage, err = decodeHexPoint(in.KeyImage); err != nil {
+			return fmt.Errorf("key_image: %w", err)
+		}
+		if sig.LC, err = decodeHexScalar(in.LC); err != nil {
+			return fmt.Errorf("lc: %w", err)
+		}
+		if sig.LR, err = decodeHexScalars("LR", in.LR); err != nil {
+			return err
+		}
+	}
+	if in.TE1 != "" || in.TE2 != "" || in.TC != "" || in.TZ != nil || in.TY != nil {
+		if sig.TE1, err = decodeHexPoint(in.TE1); err != nil {
+			return fmt.Errorf("te1: %w", err)
+		}
+		if sig.TE2, err = decodeHexPoint(in.TE2); err != nil {
+			return fmt.Errorf("te2: %w", err)
+		}
+		if sig.TC, err = decodeHexScalar(in.TC); err != nil {
+			return fmt.Errorf("tc: %w", err)
+		}
+		if sig.TZ, err = decodeHexScalars("TZ", in.TZ); err != nil {
+			return err
+		}
+		if sig.TY, err = decodeHexScalars("TY", in.TY); err != nil {
+			return err
+		}
+	}
+	if err := CheckSigma(&sig); err != nil {
+		return err
+	}
+	*s = sig
+	return nil
+}
+
+// MarshalJSON 只编码签名者的公钥部分，私钥永远不会写入 JSON，实现 json.Marshaler
+func (s *Signer) MarshalJSON() ([]byte, error) {
+	if s.PublicKey == nil {
+		return nil, ErrNilPublicKey
+	}
+	return json.Marshal(publicKeyJSON{Scheme: SchemeName, PK: hex.EncodeToString(encodePoint(s.PublicKey))})
+}
+
+// UnmarshalJSON 解码签名者的公钥部分，实现 json.Unmarshaler，公钥不能是无穷远点，解码后 PrivateKey 为 nil
+func (s *Signer) UnmarshalJSON(data []byte) error {
+	var in publicKeyJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+	pk, err := decodeHexPoint(in.PK)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidatePoint(pk); err != nil {
+		return err
+	}
+
+	*s = Signer{PublicKey: pk}
+	return nil
+}
+
+// MarshalJSON 将公钥环文档编码为 JSON，实现 json.Marshaler
+func (r *Ring) MarshalJSON() ([]byte, error) {
+	if _, err := ValidateRing(r.PKList()); err != nil {
+		return nil, err
+	}
+
+	out := ringJSON{Scheme: SchemeName, Members: make([]ringMemberJSON, len(r.Members))}
+	for i, m := range r.Members {
+		out.Members[i] = ringMemberJSON{Label: m.Label, PK: hex.EncodeToString(encodePoint(m.PublicKey))}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 从 JSON 解码公钥环文档，实现 json.Unmarshaler
+// 除逐个校验公钥外，还会按 ValidateRing 拒绝空环、无穷远点与重复公钥，失败时 r 保持不变
+func (r *Ring) UnmarshalJSON(data []byte) error {
+	var in ringJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+
+	members := make([]RingMember, len(in.Members))
+	for i, m := range in.Members {
+		pk, err := decodeHexPoint(m.PK)
+		if err != nil {
+			return fmt.Errorf("成员 %d: %w", i, err)
+		}
+		members[i] = RingMember{Label: m.Label, PublicKey: pk}
+	}
+
+	ring := Ring{Members: members}
+	if _, err := ValidateRing(ring.PKList()); err != nil {
+		return err
+	}
+	*r = ring
+	return nil
+}
+
+// decodeStrict 解码 JSON 并拒绝未知字段
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return nil
+}
+
+// checkScheme 校验 JSON 文档中的方案名称
+func checkScheme(scheme string) error {
+	if scheme != SchemeName {
+		return fmt.Errorf("%w: 方案 %q 不是 %s", ErrInvalidEncoding, scheme, SchemeName)
+	}
+	return nil
+}
+
+// decodeHexPoint 解码十六进制表示的 G1 点
+func decodeHexPoint(str string) (*bn256.G1, error) {
+	b, err := hex.DecodeString(str)
+	if err != nil || len(b) != PointSize {
+		return nil, fmt.Errorf("%w: 点须为 %d 字节的十六进制字符串", ErrInvalidEncoding, PointSize)
+	}
+	p, _, err := readPoint(b)
+	return p, err
+}
+
+// decodeHexPoints 解码十六进制表示的 U_i 列表，列表不能为空
+func decodeHexPoints(strs []string) ([]*bn256.G1, error) {
+	if len(strs) == 0 {
+		return nil, fmt.Errorf("%w: 缺少 ui", ErrInvalidEncoding)
+	}
+	points := make([]*bn256.G1, len(strs))
+	for i, str := range strs {
+		p, err := decodeHexPoint(str)
+		if err != nil {
+			return nil, fmt.Errorf("U_%d: %w", i, err)
+		}
+		points[i] = p
+	}
+	return points, nil
+}
+
+// decodeHexScalar 解码十六进制表示的定长标量
+func decodeHexScalar(str string) (*big.Int, error) {
+	b, err := hex.DecodeString(str)
+	if err != nil || len(b) != ScalarSize {
+		return nil, fmt.Errorf("%w: 标量须为 %d 字节的十六进制字符串", ErrInvalidEncoding, ScalarSize)
+	}
+	k, _, err := readScalar(b)
+	return k, err
+}
+
+// encodeHexScalars 把标量列表编码为十六进制字符串列表
+func encodeHexScalars(scalars []*big.Int) []string {
+	strs := make([]string, len(scalars))
+	for i, k := range scalars {
+		strs[i] = hex.EncodeToString(appendScalar(nil, k))
+	}
+	return strs
+}
+
+// decodeHexScalars 解码十六进制表示的标量列表，name 为出错时报告的字段名，如 LR、TZ
+func decodeHexScalars(name string, strs []string) ([]*big.Int, error) {
+	scalars := make([]*big.Int, len(strs))
+	for i, str := range strs {
+		k, err := decodeHexScalar(str)
+		if err != nil {
+			return nil, fmt.Errorf("%s_%d: %w", name, i, err)
+		}
+		scalars[i] = k
+	}
+	return scalars, nil
+}
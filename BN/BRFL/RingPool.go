@@ -0,0 +1,231 @@
+package BRFL
+
+import (
+	bn256 "BRFL/BN/BN256"
+	"context"
+	"io"
+	"math/big"
+	"sync/atomic"
+)
+
+// PoolEntry 一组与消息无关的预计算随机量：r_M、r_S、t 及 R_M、R_S、T，以及若干诱饵点 U_i = k_i \cdot P
+// 每个条目只能用于一次签名，签名结束后其中的随机数被清零；诱饵个数少于环大小减一时，不足的部分在签名时补充
+type PoolEntry struct {
+	rM, rS, t *big.Int
+	// pRM、pRS、pT、pUI 为对应的点，nil 表示尚未计算
+	pRM, pRS, pT *bn256.G1
+	ks           []*big.Int
+	pUI          []*bn256.G1
+
+	used atomic.Bool
+}
+
+// NewPoolEntry 离线阶段：从 opts 指定的随机数来源读取随机数，并预先计算 decoys 个诱饵点
+// 不能与 WithDeterministic 同时使用；WithWorkers 指定的协程数用于点乘
+func NewPoolEntry(decoys int, opts ...Option) (*PoolEntry, error) {
+	return newPoolEntry(context.Background(), decoys, NewConfig(opts...))
+}
+
+// newPoolEntry NewPoolEntry 的实现，ctx 被取消时清零已读取的随机数并返回 ctx.Err()
+func newPoolEntry(ctx context.Context, decoys int, cfg *Config) (*PoolEntry, error) {
+	if cfg.Deterministic {
+		return nil, ErrPoolDeterministic
+	}
+	if decoys < 0 {
+		return nil, ErrInvalidPoolSize
+	}
+	e, err := readPoolEntry(cfg.Random, decoys)
+	if err != nil {
+		return nil, err
+	}
+
+	err = parallelFor(ctx, cfg.Workers, len(e.ks)+3, func(i int) {
+		switch i {
+		case 0:
+			e.pRM = new(bn256.G1).ScalarBaseMult(e.rM)
+		case 1:
+			e.pRS = new(bn256.G1).ScalarBaseMult(e.rS)
+		case 2:
+			e.pT = new(bn256.G1).ScalarBaseMult(e.t)
+		default:
+			e.pUI[i-3] = new(bn256.G1).ScalarBaseMult(e.ks[i-3])
+		}
+	})
+	if err != nil {
+		e.zeroize()
+		return nil, err
+	}
+	return e, nil
+}
+
+// readPoolEntry 按 r_M、r_S、t、诱饵标量的顺序从 r 读取随机数，点留待之后计算
+// 该顺序与 Sign 消费随机数的顺序相同，因此不使用预计算时签名结果不变
+func readPoolEntry(r io.Reader, decoys int) (*PoolEntry, error) {
+	e := &PoolEntry{}
+	for _, k := range []**big.Int{&e.rM, &e.rS, &e.t} {
+		var err error
+		if *k, err = RandomZqFrom(r); err != nil {
+			e.zeroize()
+			return nil, err
+		}
+	}
+	if err := e.extend(r, decoys); err != nil {
+		e.zeroize()
+		return nil, err
+	}
+	return e, nil
+}
+
+// extend 从 r 读取随机数，把诱饵标量补足到 decoys 个，新增诱饵的点为 nil，留待签名时计算
+func (e *PoolEntry) extend(r io.Reader, decoys int) error {
+	for len(e.ks) < decoys {
+		k, err := RandomZqFrom(r)
+		if err != nil {
+			return err
+		}
+		e.ks = append(e.ks, k)
+		e.pUI = append(e.pUI, nil)
+	}
+	return nil
+}
+
+// Decoys 返回条目中预计算的诱饵个数
+func (e *PoolEntry) Decoys() int {
+	return len(e.ks)
+}
+
+// take 把条目标记为已使用，条目为 nil 或已被使用时返回 ErrPoolEntryUsed
+func (e *PoolEntry) take() error {
+	if e == nil || !e.used.CompareAndSwap(false, true) {
+		return ErrPoolEntryUsed
+	}
+	return nil
+}
+
+// zeroize 清零条目中的全部随机数并丢弃预计算的点
+func (e *PoolEntry) zeroize() {
+	for _, k := range append([]*big.Int{e.rM, e.rS, e.t}, e.ks...) {
+		zeroizeInt(k)
+	}
+	e.rM, e.rS, e.t, e.ks = nil, nil, nil, nil
+	e.pRM, e.pRS, e.pT, e.pUI = nil, nil, nil, nil
+}
+
+// zeroizeInt 覆盖 k 的底层字后将其置零
+func zeroizeInt(k *big.Int) {
+	if k == nil {
+		return
+	}
+	clear(k.Bits())
+	k.SetInt64(0)
+}
+
+// baseMulOr 返回预计算的点 p，p 为 nil 时计算 k \cdot P
+func baseMulOr(p *bn256.G1, k *big.Int) *bn256.G1 {
+	if p != nil {
+		return p
+	}
+	return new(bn256.G1).ScalarBaseMult(k)
+}
+
+// SignOnline 在线阶段：消费一个预计算条目完成签名，只需计算与消息及签名者相关的部分
+// 条目在签名前被标记为已使用，无论签名成功与否都会被清零；不能与 WithDeterministic 同时使用
+func SignOnline(ctx context.Context, Message []byte, rc *RingContext, SignerS *Signer, entry *PoolEntry, opts ...Option) (*Sigma, error) {
+	if err := entry.take(); err != nil {
+		return nil, err
+	}
+	defer entry.zeroize()
+	return signWithRing(ctx, Message, rc, SignerS, NewConfig(opts...), entry)
+}
+
+// Pool 有界的预计算池，由后台协程持续生成 PoolEntry 直到池满
+// NewPool 的 WithRandom 指定的随机数来源只由后台协程读取；可被多个协程同时使用
+type Pool struct {
+	entries chan *PoolEntry
+	cancel  context.CancelFunc
+	done    chan struct{}
+	// err 后台协程因错误退出的原因，entries 关闭后才可读取
+	err error
+}
+
+// NewPool 创建容量为 size、每个条目预计算 decoys 个诱饵点的预计算池，并启动后台协程
+// 使用完毕后需调用 Close 停止后台协程并清零池中剩余的条目
+func NewPool(size, decoys int, opts ...Option) (*Pool, error) {
+	cfg := NewConfig(opts...)
+	if cfg.Deterministic {
+		return nil, ErrPoolDeterministic
+	}
+	if size < 1 || decoys < 0 {
+		return nil, ErrInvalidPoolSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		entries: make(chan *PoolEntry, size),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go p.fill(ctx, decoys, cfg)
+	return p, nil
+}
+
+// fill 后台协程：持续生成条目放入池中，ctx 被取消或生成失败时关闭 entries 并退出
+func (p *Pool) fill(ctx context.Context, decoys int, cfg *Config) {
+	defer close(p.done)
+	defer close(p.entries)
+	for {
+		e, err := newPoolEntry(ctx, decoys, cfg)
+		if err != nil {
+			if ctx.Err() == nil {
+				p.err = err
+			}
+			return
+		}
+		select {
+		case p.entries <- e:
+		case <-ctx.Done():
+			e.zeroize()
+			return
+		}
+	}
+}
+
+// Len 返回池中当前可用的条目数
+func (p *Pool) Len() int {
+	return len(p.entries)
+}
+
+// Get 从池中取出一个条目，池为空时等待后台协程生成
+// 池已关闭时返回 ErrPoolClosed，后台协程读取随机数失败时返回该错误，ctx 被取消时返回 ctx.Err()
+func (p *Pool) Get(ctx context.Context) (*PoolEntry, error) {
+	select {
+	case e, ok := <-p.entries:
+		if !ok {
+			if p.err != nil {
+				return nil, p.err
+			}
+			return nil, ErrPoolClosed
+		}
+		return e, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Sign 从池中取出一个条目并调用 SignOnline 签名
+func (p *Pool) Sign(ctx context.Context, Message []byte, rc *RingContext, SignerS *Signer, opts ...Option) (*Sigma, error) {
+	e, err := p.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return SignOnline(ctx, Message, rc, SignerS, e, opts...)
+}
+
+// Close 停止后台协程并清零池中剩余的条目，可重复调用
+func (p *Pool) Close() {
+	p.cancel()
+	<-p.done
+	for e := range p.entries {
+		e.zeroize()
+	}
+}
@@ -1,34 +1,82 @@
 package BRFL
 
 import (
-	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/google"
+	bn256 "BRFL/BN/BN256"
+	"context"
+	"fmt"
 	"math/big"
 	"sync"
 )
 
-func Verify(Message []byte, PKList []*bn256.G1, SignerResult *Sigma) (Verify bool) {
+// Verify 验证环签名，签名合法时返回 true
+func Verify(Message []byte, PKList []*bn256.G1, SignerResult *Sigma, opts ...Option) (Verify bool) {
+	return VerifyDetailed(Message, PKList, SignerResult, opts...) == nil
+}
+
+// VerifyDetailed 验证环签名，签名合法时返回 nil，否则返回拒绝的原因
+// 对于格式错误的输入只返回错误，不会 panic；旧版编码的签名需通过 WithLegacyTranscript 显式允许
+func VerifyDetailed(Message []byte, PKList []*bn256.G1, SignerResult *Sigma, opts ...Option) error {
+	return VerifyContext(context.Background(), Message, PKList, SignerResult, opts...)
+}
+
+// VerifyContext 与 VerifyDetailed 相同，但可通过 ctx 取消，取消时返回 ctx.Err()
+// WithWorkers 指定的协程数用于 H_i 的计算与环求和
+func VerifyContext(ctx context.Context, Message []byte, PKList []*bn256.G1, SignerResult *Sigma, opts ...Option) error {
+	// 0. 校验公钥环
+	rc, err := newRingContext(ctx, PKList)
+	if err != nil {
+		return err
+	}
+	return verifyWithRing(ctx, Message, rc, SignerResult, NewConfig(opts...))
+}
+
+// VerifyWithRing 使用预先构造的 RingContext 验证环签名，签名合法时返回 true
+func VerifyWithRing(Message []byte, rc *RingContext, SignerResult *Sigma, opts ...Option) bool {
+	return VerifyDetailedWithRing(Message, rc, SignerResult, opts...) == nil
+}
+
+// VerifyDetailedWithRing 使用预先构造的 RingContext 验证环签名，省去每次校验公钥环的开销
+func VerifyDetailedWithRing(Message []byte, rc *RingContext, SignerResult *Sigma, opts ...Option) error {
+	return verifyWithRing(context.Background(), Message, rc, SignerResult, NewConfig(opts...))
+}
+
+// verifyWithRing 校验签名结构与转录版本后重新计算挑战值
+func verifyWithRing(ctx context.Context, Message []byte, rc *RingContext, SignerResult *Sigma, cfg *Config) error {
+	// 0. 校验签名结构、转录版本与可链接模式
+	if err := checkVerifyInput(cfg, rc, SignerResult); err != nil {
+		return err
+	}
+	return verifyChallenge(ctx, cfg.Workers, Message, rc, SignerResult, cfg.Tracing)
+}
+
+// verifyChallenge 在公钥环与签名结构均已校验的前提下，重新计算挑战值并与签名中的 C 比较
+// H_i 的计算与环求和由 workers 个协程完成，ctx 被取消时返回 ctx.Err()；可追踪签名的密文证明以 mpk 为管理者公钥校验
+func verifyChallenge(ctx context.Context, workers int, Message []byte, rc *RingContext, SignerResult *Sigma, mpk *bn256.G1) error {
+	version := SignerResult.Version
 
 	// 1. 计算 Hi 列表
-	HiList := make([]*big.Int, len(PKList))
-	for i, v := range SignerResult.UI {
-		HiList[i] = HashToZq(Message, PKList, v)
+	tr := newTranscript(version, Message, rc)
+	HiList := make([]*big.Int, rc.Len())
+	err := parallelFor(ctx, workers, len(HiList), func(i int) {
+		HiList[i] = tr.Hi(SignerResult.UI[i])
+	})
+	if err != nil {
+		return err
 	}
 
 	// 2. 计算 e、S_{\text{sum}}、S_{\text{pt}}
-	e := HashToZq(PKList, Message, SignerResult.T, SignerResult.C)
-
-	sSum := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
-	for i, v := range SignerResult.UI {
-		// 计算 tmp2 = H_i \cdot \mathit{pk}_i
-		tmp1 := ScalarMulG1(PKList[i], HiList[i])
-		// 计算 tmpSumPart = U_i + tm1
-		tmpSumPart := AddG1(v, tmp1)
+	e := tr.E(SignerResult.T, SignerResult.C)
 
-		sSum = AddG1(sSum, tmpSumPart)
+	sSum, err := rc.computeSumContext(ctx, workers, HiList, SignerResult.UI, -1)
+	if err != nil {
+		return err
 	}
 
 	tmp2 := ScalarMulG1(SignerResult.RM, SignerResult.C)
 	tmp3 := InvZq(e)
+	if tmp3 == nil {
+		return fmt.Errorf("%w: e 在 Z_q 中不可逆", ErrMalformedSignature)
+	}
 	tmp4 := new(bn256.G1).ScalarBaseMult(SignerResult.Pi)
 	tmp5 := SubG1(SignerResult.T, tmp4)
 	tmp6 := ScalarMulG1(tmp5, tmp3)
@@ -36,27 +84,109 @@ func Verify(Message []byte, PKList []*bn256.G1, SignerResult *Sigma) (Verify boo
 
 	// 3. 比较 C_{\text{check}} = H\bigl(V \cdot S_{\text{sum}} \;\|\; S_{\text{pt}}\bigr)
 	tmp7 := ScalarMulG1(sSum, SignerResult.V)
-	cCheck := HashToZq(tmp7, sPt)
+	cCheck := HashTranscript(version, TagC, tmp7, sPt)
 
-	Verify = CompareBigInts(SignerResult.C, cCheck)
-	return
+	if !CompareBigInts(SignerResult.C, cCheck) {
+		return ErrChallengeMismatch
+	}
+
+	// 4. 可链接签名还需校验密钥镜像的环证明，可追踪签名还需校验密文的环证明
+	if SignerResult.KeyImage != nil {
+		if err := verifyLinkable(tr, rc, SignerResult); err != nil {
+			return err
+		}
+	}
+	if SignerResult.TE1 != nil {
+		return verifyTrace(tr, rc, SignerResult, mpk)
+	}
+	return nil
 }
 
 // Sign 签名函数
-func Sign(Message []byte, PKList []*bn256.G1, SignerS *Signer) *Sigma {
+// 若签名者不在环中、环为空、环中存在 nil 或重复公钥，则返回对应错误
+// 可通过 WithRandom 指定随机数来源，读取失败时返回错误；通过 WithDeterministic 启用确定性签名
+func Sign(Message []byte, PKList []*bn256.G1, SignerS *Signer, opts ...Option) (*Sigma, error) {
+	return SignContext(context.Background(), Message, PKList, SignerS, opts...)
+}
+
+// SignContext 与 Sign 相同，但可通过 ctx 取消，取消时返回 ctx.Err()
+// WithWorkers 指定的协程数用于 U_i 的生成、H_i 的计算与环求和；随机数仍按顺序读取，签名结果与协程数无关
+func SignContext(ctx context.Context, Message []byte, PKList []*bn256.G1, SignerS *Signer, opts ...Option) (*Sigma, error) {
+	// 0. 校验公钥环
+	rc, err := newRingContext(ctx, PKList)
+	if err != nil {
+		return nil, err
+	}
+	return signWithRing(ctx, Message, rc, SignerS, NewConfig(opts...), nil)
+}
+
+// SignWithRing 使用预先构造的 RingContext 签名，省去每次校验公钥环与线性查找签名者的开销
+func SignWithRing(Message []byte, rc *RingContext, SignerS *Signer, opts ...Option) (*Sigma, error) {
+	return signWithRing(context.Background(), Message, rc, SignerS, NewConfig(opts...), nil)
+}
+
+// signWithRing 签名的实现，cfg 为已应用全部选项的配置
+// entry 为 nil 时从 cfg.Random 读取全部随机数，否则使用预计算条目中的随机数与点
+func signWithRing(ctx context.Context, Message []byte, rc *RingContext, SignerS *Signer, cfg *Config, entry *PoolEntry) (*Sigma, error) {
+	PKList := rc.pkList
+
+	// 0. 找到签名者公钥在 PKList 中的下标
+	flag, err := rc.FindSigner(SignerS)
+	if err != nil {
+		return nil, err
+	}
+	version := cfg.Transcript
+	if !version.Supported() {
+		return nil, fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, version)
+	}
+	if cfg.Linkable && version != TranscriptV2 {
+		return nil, fmt.Errorf("%w: 可链接模式须使用 v2 转录编码", ErrUnsupportedTranscript)
+	}
+	if cfg.Tracing != nil {
+		if version != TranscriptV2 {
+			return nil, fmt.Errorf("%w: 可追踪模式须使用 v2 转录编码", ErrUnsupportedTranscript)
+		}
+		if err := ValidatePoint(cfg.Tracing); err != nil {
+			return nil, fmt.Errorf("%w: 管理者公钥", err)
+		}
+	}
+
+	// 确定性模式下，所有随机量均由 HMAC-DRBG 派生
+	if cfg.Deterministic {
+		if entry != nil {
+			return nil, ErrPoolDeterministic
+		}
+		cfg.Random = NewNonceReader(SignerS.PrivateKey, Message, PKList, cfg)
+	}
+	tr := newTranscript(version, Message, rc)
+
+	// 未使用预计算时，按相同的顺序读取 r_M、r_S、t 与诱饵标量，点在下面计算
+	if entry == nil {
+		entry, err = readPoolEntry(cfg.Random, len(PKList)-1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// 签名结束后清零条目中的随机数
+	defer entry.zeroize()
+	if err := entry.extend(cfg.Random, len(PKList)-1); err != nil {
+		return nil, err
+	}
 
 	// 1.生成随机数 $r_M$ 并计算 $R_M = r_M \cdot P$，以混淆后续签名的可追踪性
-	rM := RandomZq()
-	RM := new(bn256.G1).ScalarBaseMult(rM)
+	rM := entry.rM
+	RM := baseMulOr(entry.pRM, rM)
 
 	// 2. 生成随机数 $r_S$ ，得到中间值 $R_S = r_S \cdot P$ ，并基于哈希函数计算 $C_S$ 和 $S_S$
-	rS := RandomZq()
-	RS := new(bn256.G1).ScalarBaseMult(rS)
-	CS := ComputeCS(rS, SignerS.PrivateKey, SignerS.PublicKey, RS)
+	rS := entry.rS
+	RS := baseMulOr(entry.pRS, rS)
+	CS := ComputeCS(rS, SignerS.PrivateKey, SignerS.PublicKey, RS, version)
 	SS := ComputeSS(rS, CS, rM)
 
+	// 随机数 t 已在主协程中读取，保证随机数来源被顺序消费
+	t := entry.t
+
 	var wg sync.WaitGroup
-	var t *big.Int
 	var T *bn256.G1
 	var C *big.Int
 	var e *big.Int
@@ -65,48 +195,77 @@ func Sign(Message []byte, PKList []*bn256.G1, SignerS *Signer) *Sigma {
 	go func() {
 		defer wg.Done()
 		// 执行任务
-		t = RandomZq()
-		T = new(bn256.G1).ScalarBaseMult(t)
-		C = ComputeC(rS, SignerS.PrivateKey, SignerS.PublicKey, CS, RM, SS)
-		e = HashToZq(PKList, Message, T, C)
+		T = baseMulOr(entry.pT, t)
+		C = ComputeC(rS, SignerS.PrivateKey, SignerS.PublicKey, CS, RM, SS, version)
+		e = tr.E(T, C)
 		Pi = ComputePi(t, e, SS)
 	}()
 
 	// 3. 为环内其他成员（ $i \neq s$ ）随机分配辅助量 $U_i \in G$ ，并计算 H_i
+	// 第 j 个诱饵分配给跳过签名者后的第 j 个成员，点乘与哈希交由 cfg.Workers 个协程完成
 	UiList := make([]*bn256.G1, len(PKList))
 	HiList := make([]*big.Int, len(PKList))
-	var flag int // 记住公钥位置下标
-	for i, v := range PKList {
-		if CompareG1(v, SignerS.PublicKey) {
-			flag = i
-			continue
+	err = parallelFor(ctx, cfg.Workers, len(PKList), func(i int) {
+		if i == flag {
+			return
+		}
+		j := i
+		if i > flag {
+			j--
 		}
-		Ui := RandomPointG1()
-		UiList[i] = Ui
-		HiList[i] = HashToZq(Message, PKList, Ui)
+		UiList[i] = baseMulOr(entry.pUI[j], entry.ks[j])
+		HiList[i] = tr.Hi(UiList[i])
+	})
+	if err != nil {
+		wg.Wait()
+		return nil, err
 	}
 
 	// 4. 选择一个随机数 $r'_s \in (Z_q)^*$ ，计算  $U_s$ 和 $H_s$ 用于构造签名者自身的环量，并计算 V
-	rS_ := RandomZq()
-	US := ComputeUS(rS_, SignerS.PublicKey, UiList, HiList, PKList, flag)
+	rS_, err := RandomZqFrom(cfg.Random)
+	if err != nil {
+		wg.Wait()
+		return nil, err
+	}
+	// U_s = r'_s \cdot pk_s - \sum_{i \ne s}(U_i + H_i \cdot pk_i)，与 ComputeUS 相同，环求和可使用预计算表
+	sum, err := rc.computeSumContext(ctx, cfg.Workers, HiList, UiList, flag)
+	if err != nil {
+		wg.Wait()
+		return nil, err
+	}
+	US := SubG1(ScalarMulG1(SignerS.PublicKey, rS_), sum)
 	UiList[flag] = US
-	HS := HashToZq(Message, PKList, US)
+	HS := tr.Hi(US)
 	V := ComputeV(rS, SignerS.PrivateKey, rS_, HS)
 
 	// 5. 通过再一次随机数 $t \in (Z_q)^*$ 构造 $T = t \cdot P$ ，并计算 C、e、Pi
 	wg.Wait() // 阻塞，直到全部任务完成
-	//t := RandomZq()
-	//T := new(bn256.G1).ScalarBaseMult(t)
-	//C := ComputeC(rS, SignerS.PrivateKey, SignerS.PublicKey, CS, RM, SS)
-	//e := HashToZq(PKList, Message, T, C)
-	//Pi := ComputePi(t, e, SS)
 
-	return &Sigma{
+	sigma := &Sigma{
 		RM: RM,
 		UI: UiList,
 		V:  V,
 		C:  C,
 		T:  T,
 		Pi: Pi,
+
+		Version: version,
+	}
+
+	// 6. 可链接模式下附带密钥镜像及其环证明，随机数在普通签名的随机数之后读取
+	if cfg.Linkable {
+		sigma.KeyImage, sigma.LC, sigma.LR, err = signLinkable(cfg.Random, tr, rc, SignerS, flag, C)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 7. 可追踪模式下把签名者公钥加密给管理者并附带环证明，随机数在可链接部分之后读取
+	if cfg.Tracing != nil {
+		sigma.TE1, sigma.TE2, sigma.TC, sigma.TZ, sigma.TY, err = signTrace(cfg.Random, tr, rc, SignerS, flag, C, cfg.Tracing)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return sigma, nil
 }
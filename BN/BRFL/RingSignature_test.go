@@ -1,14 +1,47 @@
 package BRFL
 
 import (
+	bn256 "BRFL/BN/BN256"
+	"BRFL/KeyPEM"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/google"
+	"io"
+	"math/big"
+	mrand "math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 var MessageTrue = []byte("这是用来正确签名的信息。")
 var MessageFalse = []byte("这是用来错误验证的信息。")
 
+// newRing 生成 n 个签名者及对应的公钥环
+func newRing(t testing.TB, n int, opts ...Option) ([]*Signer, []*bn256.G1) {
+	t.Helper()
+	L := make([]*Signer, n)
+	List := make([]*bn256.G1, n)
+	for i := range L {
+		signer, err := NewSigner(opts...)
+		if err != nil {
+			t.Fatalf("生成签名者失败: %v", err)
+		}
+		L[i] = signer
+		List[i] = signer.PublicKey
+	}
+	return L, List
+}
+
 // 测试 BRFL 环签名的签名与验证
 func TestRingSignature(t *testing.T) {
 	fmt.Println("=== 开始测试 BRFL 环签名方案 ===")
@@ -19,14 +52,20 @@ func TestRingSignature(t *testing.T) {
 	var List []*bn256.G1 // 环签名的公钥列表
 	SignerS := 2
 	for i := 0; i < n; i++ {
-		signer := NewSigner()
+		signer, err := NewSigner()
+		if err != nil {
+			t.Fatalf("生成签名者失败: %v", err)
+		}
 		L = append(L, signer)
 		List = append(List, signer.PublicKey)
 	}
 	fmt.Printf("已生成 %d 个签名者\n", n)
 
 	// 开始签名
-	SignerResult := Sign(MessageTrue, List, L[SignerS])
+	SignerResult, err := Sign(MessageTrue, List, L[SignerS])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
 
 	//fmt.Println("签名结果 Sigma 为：")
 	//fmt.Println("RM 为：", SignerResult.RM)
@@ -42,3 +81,1535 @@ func TestRingSignature(t *testing.T) {
 	Verify2 := Verify(MessageFalse, List, SignerResult)
 	fmt.Println(Verify2)
 }
+
+// sameSigma 判断两个签名是否完全相同
+func sameSigma(a, b *Sigma) bool {
+	for i := range a.UI {
+		if !CompareG1(a.UI[i], b.UI[i]) {
+			return false
+		}
+	}
+	if (a.KeyImage == nil) != (b.KeyImage == nil) || len(a.LR) != len(b.LR) {
+		return false
+	}
+	if a.KeyImage != nil {
+		if !CompareG1(a.KeyImage, b.KeyImage) || !CompareBigInts(a.LC, b.LC) {
+			return false
+		}
+		for i := range a.LR {
+			if !CompareBigInts(a.LR[i], b.LR[i]) {
+				return false
+			}
+		}
+	}
+	if (a.TE1 == nil) != (b.TE1 == nil) || len(a.TZ) != len(b.TZ) || len(a.TY) != len(b.TY) {
+		return false
+	}
+	if a.TE1 != nil {
+		if !CompareG1(a.TE1, b.TE1) || !CompareG1(a.TE2, b.TE2) || !CompareBigInts(a.TC, b.TC) {
+			return false
+		}
+		for i := range a.TZ {
+			if !CompareBigInts(a.TZ[i], b.TZ[i]) || !CompareBigInts(a.TY[i], b.TY[i]) {
+				return false
+			}
+		}
+	}
+	return CompareG1(a.RM, b.RM) && CompareG1(a.T, b.T) &&
+		CompareBigInts(a.V, b.V) && CompareBigInts(a.C, b.C) && CompareBigInts(a.Pi, b.Pi)
+}
+
+// 测试签名时对非法公钥环的检查
+func TestSignInvalidRing(t *testing.T) {
+	_, List := newRing(t, 4)
+	others, _ := newRing(t, 1)
+	outsider := others[0]
+	member := &Signer{PrivateKey: nil, PublicKey: List[0]}
+
+	cases := []struct {
+		name   string
+		ring   []*bn256.G1
+		signer *Signer
+		want   error
+	}{
+		{"空环", nil, outsider, ErrEmptyRing},
+		{"签名者不在环中", List, outsider, ErrSignerNotInRing},
+		{"环中存在 nil 公钥", append([]*bn256.G1{outsider.PublicKey, nil}, List...), outsider, ErrNilPublicKey},
+		{"环中存在重复公钥", append([]*bn256.G1{outsider.PublicKey}, append(List, List[1])...), outsider, ErrDuplicatePublicKey},
+		{"签名者为 nil", List, nil, ErrNilSigner},
+		{"签名者私钥为 nil", List, member, ErrNilSigner},
+		{"私钥与公钥不匹配", List, &Signer{PrivateKey: outsider.PrivateKey, PublicKey: List[0]}, ErrKeyMismatch},
+	}
+
+	for _, c := range cases {
+		sigma, err := Sign(MessageTrue, c.ring, c.signer)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if sigma != nil {
+			t.Errorf("%s: 出错时不应返回签名", c.name)
+		}
+	}
+}
+
+// 测试 VerifyDetailed 返回的拒绝原因
+func TestVerifyDetailed(t *testing.T) {
+	n := 4
+	L, List := newRing(t, n)
+	sigma, err := Sign(MessageTrue, List, L[1])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	short := *sigma
+	short.UI = sigma.UI[:n-1]
+	nilUI := *sigma
+	nilUI.UI = append([]*bn256.G1(nil), sigma.UI...)
+	nilUI.UI[0] = nil
+	noPi := *sigma
+	noPi.Pi = nil
+	bigC := *sigma
+	bigC.C = new(big.Int).Add(sigma.C, bn256.Order)
+
+	cases := []struct {
+		name    string
+		message []byte
+		ring    []*bn256.G1
+		sigma   *Sigma
+		want    error
+	}{
+		{"合法签名", MessageTrue, List, sigma, nil},
+		{"消息不匹配", MessageFalse, List, sigma, ErrChallengeMismatch},
+		{"空环", MessageTrue, nil, sigma, ErrEmptyRing},
+		{"环大小不一致", MessageTrue, List, &short, ErrRingSizeMismatch},
+		{"签名为 nil", MessageTrue, List, nil, ErrMalformedSignature},
+		{"U_i 为 nil", MessageTrue, List, &nilUI, ErrMalformedSignature},
+		{"缺少 Pi", MessageTrue, List, &noPi, ErrMalformedSignature},
+		{"标量超出范围", MessageTrue, List, &bigC, ErrMalformedSignature},
+	}
+
+	for _, c := range cases {
+		err := VerifyDetailed(c.message, c.ring, c.sigma)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if Verify(c.message, c.ring, c.sigma) != (c.want == nil) {
+			t.Errorf("%s: Verify 与 VerifyDetailed 结果不一致", c.name)
+		}
+	}
+}
+
+// 测试通过 WithRandom 注入随机数来源
+func TestWithRandom(t *testing.T) {
+	// 相同种子的随机数来源应得到完全相同的密钥与签名
+	L1, List1 := newRing(t, 3, WithRandom(mrand.New(mrand.NewSource(1))))
+	L2, List2 := newRing(t, 3, WithRandom(mrand.New(mrand.NewSource(1))))
+	for i := range List1 {
+		if !CompareG1(List1[i], List2[i]) {
+			t.Fatalf("相同随机数来源生成的公钥 %d 不一致", i)
+		}
+	}
+
+	sigma1, err := Sign(MessageTrue, List1, L1[0], WithRandom(mrand.New(mrand.NewSource(2))))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma2, err := Sign(MessageTrue, List2, L2[0], WithRandom(mrand.New(mrand.NewSource(2))))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !sameSigma(sigma1, sigma2) {
+		t.Errorf("相同随机数来源生成的签名不一致")
+	}
+	if !Verify(MessageTrue, List1, sigma1) {
+		t.Errorf("注入随机数来源生成的签名验证失败")
+	}
+
+	// 随机数来源读取失败时应返回错误而不是 panic
+	broken := WithRandom(iotest.ErrReader(errors.New("熵源不可用")))
+	if _, err := NewSigner(broken); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("NewSigner: 期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+	if _, err := Sign(MessageTrue, List1, L1[0], broken); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("Sign: 期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+}
+
+// 测试确定性签名模式：相同输入两次签名的结果逐字节相同
+func TestDeterministicSign(t *testing.T) {
+	L, List := newRing(t, 4, WithRandom(mrand.New(mrand.NewSource(3))))
+
+	sigma1, err := Sign(MessageTrue, List, L[2], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	// 确定性模式下不读取 Random，即使来源已损坏也应得到相同结果
+	broken := WithRandom(iotest.ErrReader(errors.New("熵源不可用")))
+	sigma2, err := Sign(MessageTrue, List, L[2], broken, WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !sameSigma(sigma1, sigma2) {
+		t.Errorf("确定性模式下两次签名结果不一致")
+	}
+	if !Verify(MessageTrue, List, sigma1) {
+		t.Errorf("确定性签名验证失败")
+	}
+
+	// 消息或额外熵不同时，派生出的签名应不同
+	sigma3, err := Sign(MessageFalse, List, L[2], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma4, err := Sign(MessageTrue, List, L[2], WithDeterministic([]byte("额外熵")))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if sameSigma(sigma1, sigma3) || sameSigma(sigma1, sigma4) {
+		t.Errorf("不同输入派生出了相同的签名")
+	}
+	if !Verify(MessageTrue, List, sigma4) {
+		t.Errorf("混入额外熵的确定性签名验证失败")
+	}
+
+	// 转录版本不同时派生出的随机数也不同，否则同一消息的两个签名会泄露私钥
+	seen := make(map[string]TranscriptVersion)
+	for _, v := range []TranscriptVersion{TranscriptLegacy, TranscriptV1, TranscriptV2} {
+		nonce := make([]byte, 32)
+		if _, err := io.ReadFull(NewNonceReader(L[2].PrivateKey, MessageTrue, List, NewConfig(WithTranscript(v))), nonce); err != nil {
+			t.Fatalf("读取随机数失败: %v", err)
+		}
+		if u, ok := seen[string(nonce)]; ok {
+			t.Errorf("版本 %d 与 %d 派生出了相同的随机数", v, u)
+		}
+		seen[string(nonce)] = v
+	}
+
+	// 已知答案测试：固定的密钥环与消息在各转录版本下的确定性签名编码，随机数的派生方式改变时此处会失败
+	for _, c := range []struct {
+		version TranscriptVersion
+		want    string
+	}{
+		{
+			version: TranscriptLegacy,
+			want: "0101000d588c796a1c35643c5e1e10dc0f32027b63f60db7247437b5be05a4b3d40e7a07228be52a1ec86ba2e19050a9" +
+				"8dd623b79e2b09f36088effdf0525eb9c533ab000000041aa275154d637f8693e0ca60aeee6545c4fbf48db455c3204c" +
+				"4e7e64fb755479239f8b131384dd935b08ad882fc94672b2920f5943c13a382ecdf67bf87447e2162db89b47e7cc2bd6" +
+				"83734215e23a1134442829cbb0d3a7df06caa4fce38ba705321f6e2cf593090f4b47a7f91ca6fdc2c9b65094ac5c9667" +
+				"8eacfd8c0213be0536a556f85311895da1a8184e706b66916b8071df05662cdaac66035bdb729016fd2323607ae07b1a" +
+				"bb43d00823cd458d5608c86bc18284f7c646d7218f27fa0dcad6b9def996ca2b675420784d11ffaba06cd9a85e1590bc" +
+				"f449cc4377e034254680700fef0078bef8674749714bc9037fa87103c4fcdab29a674ba65c023225eaaff0eecea1c84f" +
+				"302d5cda957370ecae0bbef926e8e6967252ba628567f20f48b769437d19c8a28dafd961148729cfa38fee51b35a8c2b" +
+				"49727c3f2075b50c017f4f52d1a609efaf35a8affb3f1f684d407eca10852962d7220a25b8bea90917b5a7582636459b" +
+				"11b6c5d4c7f261624b749dfc7c17088da59fc9ab5183c407f593bf914d57e70e6f4a8a500a212c5fd0afa7f4da0f8b0b" +
+				"f1bd644277c46a",
+		},
+		{
+			version: TranscriptV1,
+			want: "0101012f581315350ee89eb4411b7da6d40238a107ccc9545f2b93093a0fbceda8e74c0eb0e4f348c2df3c62d4523568" +
+				"55b43ebf238d961667aeca5c4380a790aa5418000000041e790a85a192eaebae18974d3c19755d24746137cb01af19d4" +
+				"70a070b4ae1f95233493c0fc2435e35a22c89bfb981e54217328bce3b10e35f783c204cf43306420cb7a1b867e400dd0" +
+				"54aaaf68888da18746bc1261790da3f5c853e4494a72ce2acda3e51e7b4c60d98af17238e4ca68a19a9e38bb74e24407" +
+				"677d58a0bd379103c12b106f4c95c087dbf5fecb6b5a733537b9e9345632d7a9bf3e2e3fcb4243302a463438a2f570b2" +
+				"7b48d0b6b0c9e17fceded0715b2d381ffedc8e1910b2490b416c173dcc1791af201a723431a2b48d234a7b9609664e0d" +
+				"7cf1f736e40f0e2fc59781a21fdd0e5d220d05d73b15744d101f28771543655d767923517bc4b70fc807c6d992acfa67" +
+				"3cbe2c7cd4353d745a2acc658b843eb278ec782bee29c62739ca74a5450a9e4e9b8715d0acd330b094416e4af914d7d5" +
+				"2943d852bb0db101a6d5c1b35a02dd50ff833fe04045349c3fd0fe18fe80e5fee4d7a275b6282427a241e2a59757a44b" +
+				"d5aec11b9035e36d4f05331aa3eef0b08687ec015711801f4e56706b51909b3ed716a862facd9eae9eb78152899c5b34" +
+				"a1f094fba0c8a6",
+		},
+		{
+			version: TranscriptV2,
+			want: "0101020834ee656148c601ff553d55b3193dcfa6544681cc4c2b43c35444dabe2298a707f77b7ff761f7f56b6875a1fe" +
+				"81c59062ba43d162ef97cb9de299b009e00a6b0000000422a51255fd1507580273ce4940d26063173a9fc9fd90f26e47" +
+				"0c963c325ccb0c16943d3dee99312080cfafacc1c6161c426af1aa4a06ab82626c6e4431cae9b7238d4633c30d95a925" +
+				"2a063b8294b9faeeba6dca432dfb04b2b0c9000ce922b107c5aa9b86b6d538d9a234fc2fc090b8fc6eb8fee4bb33a450" +
+				"434a9bb06186cb25971e60d28911ebec1f4f3f0e9f0ba736b402a9225b2308bc0b7579483abcde1394873f2708e59702" +
+				"b34b2fd2ece7499881300c0399c39ba7331afb9f6d742d03a37c85b173a2027c800ac1ba6df9e078cb5d2442b2343f45" +
+				"31de2c67611a8c1be39adee17700f78855c886dec1e7597c32c8a50860478d501e0d9f630edb2d2e63cc9036e12a9817" +
+				"7ab53ea6fd1cd25b925cc9a675f05563cc605c8aa70cd429ee1bb91d34458b3076978f255d26e043dcf4fb6d137969d0" +
+				"38b69336444fe82187546946c4844667c512bf8429fa67243d98c3682365bf1e718f84851050f200ba7017cae1c7bf28" +
+				"9e2f5ba2cb2c828b197662583ae55a714447d7437962622b4a4a108e4bc4fe96a48a0db10657e6f354260bf8b3832466" +
+				"39ebb183d429ff",
+		},
+	} {
+		sigma, err := Sign(MessageTrue, List, L[2], WithTranscript(c.version), WithDeterministic(nil))
+		if err != nil {
+			t.Fatalf("版本 %d: 签名失败: %v", c.version, err)
+		}
+		data, err := sigma.MarshalBinary()
+		if err != nil {
+			t.Fatalf("版本 %d: 编码失败: %v", c.version, err)
+		}
+		if got := hex.EncodeToString(data); got != c.want {
+			t.Errorf("版本 %d: 签名编码与已知答案不同: %s", c.version, got)
+		}
+	}
+}
+
+// 测试哈希转录编码的版本选择、域分离与旧版兼容
+func TestTranscriptVersions(t *testing.T) {
+	L, List := newRing(t, 3)
+
+	// 旧版编码的签名需要显式允许才能通过验证
+	legacy, err := Sign(MessageTrue, List, L[0], WithTranscript(TranscriptLegacy))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, legacy); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, legacy, WithLegacyTranscript()); err != nil {
+		t.Errorf("旧版签名验证失败: %v", err)
+	}
+
+	// v1 编码的签名无需额外选项即可通过验证，但改写为 v2 后不再合法
+	v1, err := Sign(MessageTrue, List, L[0], WithTranscript(TranscriptV1))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, v1); err != nil {
+		t.Errorf("v1 签名验证失败: %v", err)
+	}
+	upgraded := *v1
+	upgraded.Version = TranscriptV2
+	if Verify(MessageTrue, List, &upgraded) {
+		t.Errorf("篡改版本号的签名通过了验证")
+	}
+
+	// 篡改版本号后签名不再合法
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if sigma.Version != TranscriptV2 {
+		t.Errorf("默认转录版本为 %d，期望 %d", sigma.Version, TranscriptV2)
+	}
+	downgraded := *sigma
+	downgraded.Version = TranscriptLegacy
+	if Verify(MessageTrue, List, &downgraded, WithLegacyTranscript()) {
+		t.Errorf("篡改版本号的签名通过了验证")
+	}
+
+	// 未知版本
+	if _, err := Sign(MessageTrue, List, L[0], WithTranscript(9)); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("Sign: 期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+	unknown := *sigma
+	unknown.Version = 9
+	if err := VerifyDetailed(MessageTrue, List, &unknown); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("VerifyDetailed: 期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+
+	// v1 编码带长度前缀与域分离标签，旧版编码则会发生碰撞
+	if !CompareBigInts(HashToZq([]byte("ab"), []byte("c")), HashToZq([]byte("a"), []byte("bc"))) {
+		t.Errorf("旧版编码应当对拼接结果相同的输入给出相同哈希")
+	}
+	if CompareBigInts(HashToZqV1(TagHi, []byte("ab"), []byte("c")), HashToZqV1(TagHi, []byte("a"), []byte("bc"))) {
+		t.Errorf("v1 编码对不同输入给出了相同哈希")
+	}
+	if CompareBigInts(HashToZqV1(TagHi, MessageTrue), HashToZqV1(TagE, MessageTrue)) {
+		t.Errorf("不同标签给出了相同哈希")
+	}
+
+	// v2 与 v1 的域分离前缀不同；环摘要依赖公钥顺序
+	if CompareBigInts(HashToZqV1(TagHi, MessageTrue), HashToZqV2(TagHi, MessageTrue)) {
+		t.Errorf("v1 与 v2 编码给出了相同哈希")
+	}
+	swapped := append([]*bn256.G1{List[1], List[0]}, List[2:]...)
+	if bytes.Equal(RingDigest(List), RingDigest(swapped)) {
+		t.Errorf("交换公钥顺序后环摘要不变")
+	}
+}
+
+// 测试签名二进制编码的往返与非法输入
+func TestMarshalBinary(t *testing.T) {
+	L, List := newRing(t, 5)
+	sigma, err := Sign(MessageTrue, List, L[3])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	data, err := sigma.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if len(data) != EncodedSize(len(List)) {
+		t.Errorf("编码长度为 %d，期望 %d", len(data), EncodedSize(len(List)))
+	}
+
+	decoded := new(Sigma)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if !sameSigma(sigma, decoded) || decoded.Version != sigma.Version {
+		t.Errorf("解码结果与原签名不一致")
+	}
+	if err := VerifyDetailed(MessageTrue, List, decoded); err != nil {
+		t.Errorf("解码后的签名验证失败: %v", err)
+	}
+	again, _ := decoded.MarshalBinary()
+	if !bytes.Equal(data, again) {
+		t.Errorf("重新编码的结果不一致")
+	}
+
+	// 非法输入
+	badPoint := append([]byte(nil), data...)
+	badPoint[headerSize+PointSize+4+PointSize-1] ^= 0x01
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"空数据", nil},
+		{"截断", data[:len(data)-1]},
+		{"多余字节", append(append([]byte(nil), data...), 0x00)},
+		{"编码版本错误", append([]byte{EncodingVersion + 1}, data[1:]...)},
+		{"方案标识错误", append([]byte{EncodingVersion, SchemeID + 1}, data[2:]...)},
+		{"转录版本未知", append([]byte{EncodingVersion, SchemeID, 9}, data[3:]...)},
+		{"点不在曲线上", badPoint},
+	}
+
+	// 标量超出 Z_q 范围：将 Pi 改写为全 0xff
+	bigScalar := append([]byte(nil), data...)
+	for i := len(bigScalar) - ScalarSize; i < len(bigScalar); i++ {
+		bigScalar[i] = 0xff
+	}
+	cases = append(cases, struct {
+		name string
+		data []byte
+	}{"标量超出范围", bigScalar})
+
+	for _, c := range cases {
+		out := new(Sigma)
+		if err := out.UnmarshalBinary(c.data); err == nil {
+			t.Errorf("%s: 非法编码被成功解码", c.name)
+		}
+		if out.UI != nil {
+			t.Errorf("%s: 解码失败时修改了签名", c.name)
+		}
+	}
+
+	if _, err := (&Sigma{}).MarshalBinary(); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+}
+
+// 测试签名、公钥与公钥环文档的 JSON 编码
+func TestJSON(t *testing.T) {
+	L, List := newRing(t, 3)
+	sigma, err := Sign(MessageTrue, List, L[1])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	// 签名：字段名固定，不依赖 Go 结构体即可读取
+	data, err := json.Marshal(sigma)
+	if err != nil {
+		t.Fatalf("编码签名失败: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("解析 JSON 失败: %v", err)
+	}
+	for _, name := range []string{"scheme", "version", "rm", "ui", "v", "c", "t", "pi"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("签名 JSON 缺少字段 %q", name)
+		}
+	}
+	if fields["scheme"] != SchemeName {
+		t.Errorf("签名 JSON 的方案为 %v，期望 %s", fields["scheme"], SchemeName)
+	}
+
+	var decoded Sigma
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("解码签名失败: %v", err)
+	}
+	if !sameSigma(sigma, &decoded) || !Verify(MessageTrue, List, &decoded) {
+		t.Errorf("解码后的签名与原签名不一致")
+	}
+
+	// 公钥：私钥不得写入 JSON
+	data, err = json.Marshal(L[0])
+	if err != nil {
+		t.Fatalf("编码公钥失败: %v", err)
+	}
+	if bytes.Contains(data, []byte(L[0].PrivateKey.Text(16))) {
+		t.Errorf("公钥 JSON 中包含私钥")
+	}
+	var pub Signer
+	if err := json.Unmarshal(data, &pub); err != nil {
+		t.Fatalf("解码公钥失败: %v", err)
+	}
+	if pub.PrivateKey != nil || !CompareG1(pub.PublicKey, L[0].PublicKey) {
+		t.Errorf("解码后的公钥不一致")
+	}
+
+	// 公钥环文档
+	ring := &Ring{Members: []RingMember{
+		{Label: "alice", PublicKey: List[0]},
+		{PublicKey: List[1]},
+		{Label: "carol", PublicKey: List[2]},
+	}}
+	data, err = json.Marshal(ring)
+	if err != nil {
+		t.Fatalf("编码公钥环失败: %v", err)
+	}
+	var decodedRing Ring
+	if err := json.Unmarshal(data, &decodedRing); err != nil {
+		t.Fatalf("解码公钥环失败: %v", err)
+	}
+	if decodedRing.Members[0].Label != "alice" || decodedRing.Members[1].Label != "" {
+		t.Errorf("公钥环标签不一致")
+	}
+	if !Verify(MessageTrue, decodedRing.PKList(), sigma) {
+		t.Errorf("使用解码后的公钥环验证失败")
+	}
+
+	// 非法输入
+	pk := hex.EncodeToString(encodePoint(List[0]))
+	cases := []struct {
+		name string
+		data string
+		into interface{}
+	}{
+		{"方案不符", `{"scheme":"other","pk":"` + pk + `"}`, new(Signer)},
+		{"未知字段", `{"scheme":"` + SchemeName + `","pk":"` + pk + `","sk":"00"}`, new(Signer)},
+		{"非十六进制", `{"scheme":"` + SchemeName + `","pk":"zz"}`, new(Signer)},
+		{"点长度错误", `{"scheme":"` + SchemeName + `","pk":"` + pk[2:] + `"}`, new(Signer)},
+		{"空环", `{"scheme":"` + SchemeName + `","members":[]}`, new(Ring)},
+		{"重复公钥", `{"scheme":"` + SchemeName + `","members":[{"pk":"` + pk + `"},{"pk":"` + pk + `"}]}`, new(Ring)},
+		{"签名缺少 ui", `{"scheme":"` + SchemeName + `","version":1}`, new(Sigma)},
+	}
+	for _, c := range cases {
+		if err := json.Unmarshal([]byte(c.data), c.into); err == nil {
+			t.Errorf("%s: 非法 JSON 被成功解码", c.name)
+		}
+	}
+}
+
+// 测试密钥的 PEM 导出与导入
+func TestPEM(t *testing.T) {
+	L, List := newRing(t, 3)
+	passphrase := []byte("口令")
+
+	data, err := L[0].MarshalPrivateKeyPEM(passphrase, WithKDFIterations(1000))
+	if err != nil {
+		t.Fatalf("导出私钥失败: %v", err)
+	}
+	signer, err := ParsePrivateKeyPEM(data, passphrase)
+	if err != nil {
+		t.Fatalf("导入私钥失败: %v", err)
+	}
+	if !CompareBigInts(signer.PrivateKey, L[0].PrivateKey) || !CompareG1(signer.PublicKey, L[0].PublicKey) {
+		t.Errorf("导入的密钥与原密钥不一致")
+	}
+	sigma, err := Sign(MessageTrue, List, signer)
+	if err != nil || !Verify(MessageTrue, List, sigma) {
+		t.Errorf("使用导入的私钥签名失败: %v", err)
+	}
+	if _, err := ParsePrivateKeyPEM(data, []byte("错误口令")); !errors.Is(err, KeyPEM.ErrDecryption) {
+		t.Errorf("期望错误 %v，实际为 %v", KeyPEM.ErrDecryption, err)
+	}
+
+	data, err = L[1].MarshalPublicKeyPEM()
+	if err != nil {
+		t.Fatalf("导出公钥失败: %v", err)
+	}
+	pk, err := ParsePublicKeyPEM(data)
+	if err != nil || !CompareG1(pk, L[1].PublicKey) {
+		t.Errorf("导入的公钥与原公钥不一致: %v", err)
+	}
+	if _, err := ParsePrivateKeyPEM(data, passphrase); !errors.Is(err, KeyPEM.ErrInvalidPEM) {
+		t.Errorf("期望错误 %v，实际为 %v", KeyPEM.ErrInvalidPEM, err)
+	}
+}
+
+// 测试对无效点与无穷远点的拒绝
+func TestPointValidation(t *testing.T) {
+	L, List := newRing(t, 3)
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	zero := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+
+	if err := ValidatePoint(List[0]); err != nil {
+		t.Errorf("有效公钥未通过校验: %v", err)
+	}
+	if err := ValidatePoint(new(bn256.G1)); !errors.Is(err, ErrInvalidPoint) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrInvalidPoint, err)
+	}
+
+	// 1. 公钥环中的无穷远点与未初始化的点
+	for _, c := range []struct {
+		name string
+		pk   *bn256.G1
+		want error
+	}{
+		{"无穷远点", zero, ErrIdentityPoint},
+		{"未初始化的点", new(bn256.G1), ErrInvalidPoint},
+	} {
+		ring := []*bn256.G1{List[0], List[1], c.pk}
+		if _, err := Sign(MessageTrue, ring, L[0]); !errors.Is(err, c.want) {
+			t.Errorf("签名 %s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+		if err := VerifyDetailed(MessageTrue, ring, sigma); !errors.Is(err, c.want) {
+			t.Errorf("验证 %s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+	}
+
+	// 2. 签名中的无穷远点
+	for _, c := range []struct {
+		name   string
+		mutate func(s *Sigma)
+		want   error
+	}{
+		{"R_M 为无穷远点", func(s *Sigma) { s.RM = zero }, ErrIdentityPoint},
+		{"T 为无穷远点", func(s *Sigma) { s.T = zero }, ErrIdentityPoint},
+		{"U_i 未初始化", func(s *Sigma) { s.UI = []*bn256.G1{sigma.UI[0], new(bn256.G1), sigma.UI[2]} }, ErrInvalidPoint},
+	} {
+		forged := *sigma
+		c.mutate(&forged)
+		if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+	}
+
+	// 3. 解码器拒绝无穷远点
+	data, err := sigma.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	copy(data[headerSize:], encodePoint(zero))
+	if err := new(Sigma).UnmarshalBinary(data); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("二进制解码: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+
+	data, err = json.Marshal(sigma)
+	if err != nil {
+		t.Fatalf("JSON 编码失败: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("JSON 解码失败: %v", err)
+	}
+	fields["t"] = hex.EncodeToString(encodePoint(zero))
+	data, _ = json.Marshal(fields)
+	if err := json.Unmarshal(data, new(Sigma)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码签名: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+
+	identity := hex.EncodeToString(encodePoint(zero))
+	data = []byte(fmt.Sprintf(`{"scheme":%q,"pk":%q}`, SchemeName, identity))
+	if err := json.Unmarshal(data, new(Signer)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码公钥: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+	data = []byte(fmt.Sprintf(`{"scheme":%q,"members":[{"label":"a","pk":%q}]}`, SchemeName, identity))
+	if err := json.Unmarshal(data, new(Ring)); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("JSON 解码公钥环: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+
+	data = KeyPEM.EncodePublicKey(SchemeName, CurveName, encodePoint(zero))
+	if _, err := ParsePublicKeyPEM(data); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("PEM 解码公钥: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+}
+
+// naiveSumG1 逐个标量乘后累加，作为 MultiScalarMulG1 的对照
+func naiveSumG1(points []*bn256.G1, scalars []*big.Int) *bn256.G1 {
+	sum := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+	for i, p := range points {
+		sum = AddG1(sum, ScalarMulG1(p, new(big.Int).Mod(scalars[i], bn256.Order)))
+	}
+	return sum
+}
+
+// randomMSMInput 生成 n 个随机点与随机标量
+func randomMSMInput(n int) ([]*bn256.G1, []*big.Int) {
+	points := make([]*bn256.G1, n)
+	scalars := make([]*big.Int, n)
+	for i := range points {
+		points[i] = RandomPointG1()
+		scalars[i] = RandomZq()
+	}
+	return points, scalars
+}
+
+// 测试多标量乘法与逐个标量乘的结果一致
+func TestMultiScalarMulG1(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 31, 32, 100} {
+		points, scalars := randomMSMInput(n)
+		if n >= 5 {
+			// 相同的点与标量落入同一个桶、零标量、负标量、超出 Order 的标量、无穷远点
+			points[1], scalars[1] = points[0], new(big.Int).Set(scalars[0])
+			scalars[2] = big.NewInt(0)
+			scalars[3] = big.NewInt(-5)
+			scalars[4] = new(big.Int).Add(bn256.Order, big.NewInt(7))
+			points[n-1] = new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+		}
+		if !CompareG1(MultiScalarMulG1(points, scalars), naiveSumG1(points, scalars)) {
+			t.Errorf("n = %d: 多标量乘法结果与逐个计算不一致", n)
+		}
+	}
+}
+
+// 对比逐个标量乘与多标量乘法在不同环大小下的耗时
+func BenchmarkMultiScalarMulG1(b *testing.B) {
+	for _, n := range []int{16, 256, 4096} {
+		points, scalars := randomMSMInput(n)
+		b.Run(fmt.Sprintf("naive/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				naiveSumG1(points, scalars)
+			}
+		})
+		b.Run(fmt.Sprintf("msm/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiScalarMulG1(points, scalars)
+			}
+		})
+	}
+}
+
+// batchInput 在两个公钥环上生成 n 个签名，前一半共用第一个环，后一半共用第二个环
+func batchInput(t testing.TB, ringSize, n int) ([][]byte, [][]*bn256.G1, []*Sigma) {
+	var Messages [][]byte
+	var PKLists [][]*bn256.G1
+	var Sigmas []*Sigma
+	for r := 0; r < 2; r++ {
+		L := make([]*Signer, ringSize)
+		ring := make([]*bn256.G1, ringSize)
+		for i := range L {
+			L[i], _ = NewSigner()
+			ring[i] = L[i].PublicKey
+		}
+		for j := 0; j < n/2; j++ {
+			msg := []byte(fmt.Sprintf("批量消息 %d-%d", r, j))
+			sigma, err := Sign(msg, ring, L[j%ringSize])
+			if err != nil {
+				t.Fatalf("签名失败: %v", err)
+			}
+			Messages = append(Messages, msg)
+			PKLists = append(PKLists, ring)
+			Sigmas = append(Sigmas, sigma)
+		}
+	}
+	return Messages, PKLists, Sigmas
+}
+
+// 测试批量验证的逐签名结果与单独验证一致
+func TestVerifyBatch(t *testing.T) {
+	Messages, PKLists, Sigmas := batchInput(t, 4, 8)
+
+	results, err := VerifyBatch(Messages, PKLists, Sigmas)
+	if err != nil {
+		t.Fatalf("批量验证出错: %v", err)
+	}
+	for j, r := range results {
+		if r != nil {
+			t.Errorf("签名 %d 验证失败: %v", j, r)
+		}
+	}
+
+	// 篡改消息、篡改挑战值、签名缺失、公钥环为空
+	Messages[1] = MessageFalse
+	forged := *Sigmas[2]
+	forged.C = AddZq(forged.C, big.NewInt(1))
+	Sigmas[2] = &forged
+	Sigmas[5] = nil
+	PKLists[6] = nil
+	want := map[int]error{1: ErrChallengeMismatch, 2: ErrChallengeMismatch, 5: ErrMalformedSignature, 6: ErrEmptyRing}
+	// 结果与 WithWorkers 指定的协程数无关
+	for _, workers := range []int{1, 3, 0} {
+		results, err = VerifyBatch(Messages, PKLists, Sigmas, WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("批量验证出错: %v", err)
+		}
+		for j, r := range results {
+			if !errors.Is(r, want[j]) {
+				t.Errorf("workers = %d: 签名 %d: 期望错误 %v，实际为 %v", workers, j, want[j], r)
+			}
+			if single := VerifyDetailed(Messages[j], PKLists[j], Sigmas[j]); fmt.Sprint(single) != fmt.Sprint(r) {
+				t.Errorf("workers = %d: 签名 %d: 批量结果 %v 与单独验证结果 %v 不一致", workers, j, r, single)
+			}
+		}
+	}
+
+	if _, err := VerifyBatch(Messages[:2], PKLists, Sigmas); !errors.Is(err, ErrBatchLengthMismatch) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrBatchLengthMismatch, err)
+	}
+}
+
+// 对比循环调用 Verify 与 VerifyBatch 的每核吞吐量
+func BenchmarkVerifyBatch(b *testing.B) {
+	Messages, PKLists, Sigmas := batchInput(b, 16, 32)
+	report := func(b *testing.B) {
+		perCore := float64(b.N*len(Sigmas)) / b.Elapsed().Seconds() / float64(runtime.GOMAXPROCS(0))
+		b.ReportMetric(perCore, "sig/s/core")
+	}
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range Sigmas {
+				Verify(Messages[j], PKLists[j], Sigmas[j])
+			}
+		}
+		report(b)
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			VerifyBatch(Messages, PKLists, Sigmas, WithWorkers(0))
+		}
+		report(b)
+	})
+}
+
+// 测试 RingContext 的构造与签名者查找，以及带预计算表时与 Sign/Verify 的互通
+func TestRingContext(t *testing.T) {
+	L, List := newRing(t, 5)
+	others, _ := newRing(t, 1)
+
+	if _, err := NewRingContext(nil); !errors.Is(err, ErrEmptyRing) {
+		t.Errorf("空环: 期望错误 %v，实际为 %v", ErrEmptyRing, err)
+	}
+	if _, err := NewRingContext(append(List, List[0])); !errors.Is(err, ErrDuplicatePublicKey) {
+		t.Errorf("重复公钥: 期望错误 %v，实际为 %v", ErrDuplicatePublicKey, err)
+	}
+
+	for _, precompute := range []bool{false, true} {
+		var opts []Option
+		if precompute {
+			opts = append(opts, WithPrecompute())
+		}
+		rc, err := NewRingContext(List, opts...)
+		if err != nil {
+			t.Fatalf("构造 RingContext 失败: %v", err)
+		}
+		if rc.Len() != len(List) || rc.Precomputed() != precompute {
+			t.Errorf("环大小为 %d，预计算状态为 %v", rc.Len(), rc.Precomputed())
+		}
+		if !bytes.Equal(rc.Digest(), RingDigest(List)) {
+			t.Errorf("环摘要与 RingDigest 不一致")
+		}
+		for i, pk := range List {
+			if j, ok := rc.Index(pk); !ok || j != i {
+				t.Errorf("公钥 %d 的下标为 %d", i, j)
+			}
+		}
+		if _, err := SignWithRing(MessageTrue, rc, others[0]); !errors.Is(err, ErrSignerNotInRing) {
+			t.Errorf("期望错误 %v，实际为 %v", ErrSignerNotInRing, err)
+		}
+
+		// 使用 RingContext 的签名与普通签名可以互相验证
+		sigma, err := SignWithRing(MessageTrue, rc, L[2])
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		if err := VerifyDetailed(MessageTrue, List, sigma); err != nil {
+			t.Errorf("SignWithRing 的签名验证失败: %v", err)
+		}
+		plain, err := Sign(MessageTrue, List, L[4], WithTranscript(TranscriptV1))
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		if err := VerifyDetailedWithRing(MessageTrue, rc, plain); err != nil {
+			t.Errorf("VerifyDetailedWithRing 验证失败: %v", err)
+		}
+		if VerifyWithRing(MessageFalse, rc, plain) {
+			t.Errorf("错误消息通过了验证")
+		}
+
+		// 环求和与 ComputeSum 一致
+		HiList := make([]*big.Int, len(List))
+		UiList := make([]*bn256.G1, len(List))
+		for i := range List {
+			HiList[i] = RandomZq()
+			UiList[i] = RandomPointG1()
+		}
+		for _, skip := range []int{-1, 3} {
+			if !CompareG1(rc.computeSum(HiList, UiList, skip), ComputeSum(HiList, List, UiList, skip)) {
+				t.Errorf("预计算状态为 %v 时环求和结果不一致，排除下标 %d", precompute, skip)
+			}
+		}
+	}
+}
+
+// 比较 Verify 与复用 RingContext（含预计算表）的验证开销
+func BenchmarkVerifyWithRing(b *testing.B) {
+	L := make([]*Signer, 256)
+	List := make([]*bn256.G1, len(L))
+	for i := range L {
+		L[i], _ = NewSigner()
+		List[i] = L[i].PublicKey
+	}
+	sigma, err := Sign(MessageTrue, List, L[0])
+	if err != nil {
+		b.Fatalf("签名失败: %v", err)
+	}
+
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Verify(MessageTrue, List, sigma)
+		}
+	})
+	for _, precompute := range []bool{false, true} {
+		var opts []Option
+		if precompute {
+			opts = append(opts, WithPrecompute())
+		}
+		rc, _ := NewRingContext(List, opts...)
+		b.Run(fmt.Sprintf("RingContext/precompute=%v", precompute), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				VerifyWithRing(MessageTrue, rc, sigma)
+			}
+		})
+	}
+}
+
+// 测试不同协程数下 SignContext 与 VerifyContext 的结果一致，以及 ctx 取消后的返回值
+func TestSignContext(t *testing.T) {
+	L, List := newRing(t, 9)
+
+	// 协程数不影响确定性签名的结果
+	want, err := SignContext(context.Background(), MessageTrue, List, L[4], WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	for _, workers := range []int{2, 4, 16, 0} {
+		for _, precompute := range []bool{false, true} {
+			opts := []Option{WithDeterministic(nil), WithWorkers(workers)}
+			if precompute {
+				opts = append(opts, WithPrecompute())
+			}
+			rc, err := NewRingContext(List, opts...)
+			if err != nil {
+				t.Fatalf("构造 RingContext 失败: %v", err)
+			}
+			sigma, err := SignWithRing(MessageTrue, rc, L[4], opts...)
+			if err != nil {
+				t.Fatalf("workers=%d: 签名失败: %v", workers, err)
+			}
+			if !sameSigma(want, sigma) {
+				t.Errorf("workers=%d, precompute=%v: 签名与单协程结果不同", workers, precompute)
+			}
+			if err := VerifyContext(context.Background(), MessageTrue, List, sigma, WithWorkers(workers)); err != nil {
+				t.Errorf("workers=%d: 验证失败: %v", workers, err)
+			}
+			if err := VerifyContext(context.Background(), MessageFalse, List, sigma, WithWorkers(workers)); err == nil {
+				t.Errorf("workers=%d: 错误消息验证通过", workers)
+			}
+		}
+	}
+
+	// 已取消的 ctx 直接返回 ctx.Err()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, workers := range []int{1, 4} {
+		if _, err := SignContext(ctx, MessageTrue, List, L[4], WithWorkers(workers)); !errors.Is(err, context.Canceled) {
+			t.Errorf("workers=%d: 签名期望错误 %v，实际为 %v", workers, context.Canceled, err)
+		}
+		if err := VerifyContext(ctx, MessageTrue, List, want, WithWorkers(workers)); !errors.Is(err, context.Canceled) {
+			t.Errorf("workers=%d: 验证期望错误 %v，实际为 %v", workers, context.Canceled, err)
+		}
+	}
+}
+
+// newLargeRing 以 pk_i = (i+1)·P 快速构造大小为 n 的环，返回下标为 index 的签名者，仅用于测试取消
+func newLargeRing(n, index int) (*Signer, []*bn256.G1) {
+	P := new(bn256.G1).ScalarBaseMult(big.NewInt(1))
+	List := make([]*bn256.G1, n)
+	List[0] = P
+	for i := 1; i < n; i++ {
+		List[i] = AddG1(List[i-1], P)
+	}
+	return &Signer{PrivateKey: big.NewInt(int64(index + 1)), PublicKey: List[index]}, List
+}
+
+// 测试签名与验证进行中 ctx 超时：应返回 context.DeadlineExceeded，且远早于未取消时完成
+func TestContextCancelMidway(t *testing.T) {
+	if testing.Short() {
+		t.Skip("大环测试耗时较长")
+	}
+	signer, List := newLargeRing(4096, 100)
+
+	// 签名逐个生成 U_i，每个成员处理前检查 ctx
+	for _, workers := range []int{1, 4} {
+		start := time.Now()
+		if _, err := SignContext(context.Background(), MessageTrue, List[:256], signer, WithWorkers(workers)); err != nil {
+			t.Fatalf("workers=%d: 签名失败: %v", workers, err)
+		}
+		full := time.Since(start)
+
+		ctx, cancel := context.WithTimeout(context.Background(), full/20)
+		start = time.Now()
+		_, err := SignContext(ctx, MessageTrue, List[:256], signer, WithWorkers(workers))
+		elapsed := time.Since(start)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("workers=%d: 签名期望错误 %v，实际为 %v", workers, context.DeadlineExceeded, err)
+		}
+		if elapsed > full/2 {
+			t.Errorf("workers=%d: 取消后签名耗时 %v，未取消时为 %v", workers, elapsed, full)
+		}
+	}
+
+	// 验证的环求和按 sumChunk 分段，段与段之间检查 ctx；大环签名的 U_i 直接取环中的点，
+	// 结构合法但挑战值不符，未取消时完整计算后返回 ErrChallengeMismatch
+	small, err := Sign(MessageTrue, List[100:102], signer)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sigma := *small
+	sigma.UI = List
+	start := time.Now()
+	if err := VerifyContext(context.Background(), MessageTrue, List, &sigma); !errors.Is(err, ErrChallengeMismatch) {
+		t.Fatalf("期望错误 %v，实际为 %v", ErrChallengeMismatch, err)
+	}
+	full := time.Since(start)
+
+	ctx, cancel := context.WithTimeout(context.Background(), full/20)
+	defer cancel()
+	start = time.Now()
+	err = VerifyContext(ctx, MessageTrue, List, &sigma)
+	elapsed := time.Since(start)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("验证期望错误 %v，实际为 %v", context.DeadlineExceeded, err)
+	}
+	if elapsed > full/2 {
+		t.Errorf("取消后验证耗时 %v，未取消时为 %v", elapsed, full)
+	}
+}
+
+// benchRingSizes 基准测试中扫描的环大小
+var benchRingSizes = []int{2, 8, 32, 128}
+
+// 不同环大小下的签名开销，sig-bytes 为签名编码后的字节数
+func BenchmarkSign(b *testing.B) {
+	for _, n := range benchRingSizes {
+		L, List := newRing(b, n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Sign(MessageTrue, List, L[i%n]); err != nil {
+					b.Fatalf("签名失败: %v", err)
+				}
+			}
+			b.ReportMetric(float64(EncodedSize(n)), "sig-bytes")
+		})
+	}
+}
+
+// 不同环大小下的验证开销
+func BenchmarkVerify(b *testing.B) {
+	for _, n := range benchRingSizes {
+		L, List := newRing(b, n)
+		sigma, err := Sign(MessageTrue, List, L[0])
+		if err != nil {
+			b.Fatalf("签名失败: %v", err)
+		}
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := VerifyDetailed(MessageTrue, List, sigma); err != nil {
+					b.Fatalf("验证失败: %v", err)
+				}
+			}
+			b.ReportMetric(float64(EncodedSize(n)), "sig-bytes")
+		})
+	}
+}
+
+// 测试预计算条目与预计算池：结果与直接签名一致、条目只能使用一次且使用后被清零
+func TestPool(t *testing.T) {
+	L, List := newRing(t, 6)
+	rc, err := NewRingContext(List)
+	if err != nil {
+		t.Fatalf("构造 RingContext 失败: %v", err)
+	}
+
+	// 离线与在线阶段依次读取同一随机数来源时，签名与直接签名完全相同
+	r := mrand.New(mrand.NewSource(3))
+	entry, err := NewPoolEntry(len(List)-1, WithRandom(r))
+	if err != nil {
+		t.Fatalf("预计算失败: %v", err)
+	}
+	rM := entry.rM
+	got, err := SignOnline(context.Background(), MessageTrue, rc, L[2], entry, WithRandom(r))
+	if err != nil {
+		t.Fatalf("在线签名失败: %v", err)
+	}
+	want, err := Sign(MessageTrue, List, L[2], WithRandom(mrand.New(mrand.NewSource(3))))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !sameSigma(got, want) {
+		t.Errorf("在线签名与直接签名的结果不同")
+	}
+	if err := VerifyDetailed(MessageTrue, List, got); err != nil {
+		t.Errorf("在线签名验证失败: %v", err)
+	}
+	if rM.Sign() != 0 || entry.rM != nil || entry.ks != nil {
+		t.Errorf("使用后的条目未被清零")
+	}
+	if _, err := SignOnline(context.Background(), MessageTrue, rc, L[2], entry); !errors.Is(err, ErrPoolEntryUsed) {
+		t.Errorf("重复使用: 期望错误 %v，实际为 %v", ErrPoolEntryUsed, err)
+	}
+	if _, err := SignOnline(context.Background(), MessageTrue, rc, L[2], nil); !errors.Is(err, ErrPoolEntryUsed) {
+		t.Errorf("nil 条目: 期望错误 %v，实际为 %v", ErrPoolEntryUsed, err)
+	}
+
+	// 诱饵个数少于或多于所需时仍能得到合法签名
+	for _, decoys := range []int{0, 2, len(List) + 3} {
+		entry, err := NewPoolEntry(decoys, WithWorkers(2))
+		if err != nil {
+			t.Fatalf("预计算失败: %v", err)
+		}
+		sigma, err := SignOnline(context.Background(), MessageTrue, rc, L[0], entry)
+		if err != nil {
+			t.Fatalf("decoys=%d: 在线签名失败: %v", decoys, err)
+		}
+		if err := VerifyDetailed(MessageTrue, List, sigma); err != nil {
+			t.Errorf("decoys=%d: 验证失败: %v", decoys, err)
+		}
+	}
+
+	// 确定性签名与非法参数
+	if _, err := NewPool(4, 1, WithDeterministic(nil)); !errors.Is(err, ErrPoolDeterministic) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrPoolDeterministic, err)
+	}
+	entry, _ = NewPoolEntry(len(List) - 1)
+	if _, err := SignOnline(context.Background(), MessageTrue, rc, L[0], entry, WithDeterministic(nil)); !errors.Is(err, ErrPoolDeterministic) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrPoolDeterministic, err)
+	}
+	if _, err := NewPool(0, 1); !errors.Is(err, ErrInvalidPoolSize) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrInvalidPoolSize, err)
+	}
+
+	// 多个协程同时从池中取条目签名
+	pool, err := NewPool(4, len(List)-1)
+	if err != nil {
+		t.Fatalf("创建预计算池失败: %v", err)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, 12)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sigma, err := pool.Sign(context.Background(), MessageTrue, rc, L[i%len(L)])
+			if err == nil {
+				err = VerifyDetailed(MessageTrue, List, sigma)
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("签名 %d: %v", i, err)
+		}
+	}
+	pool.Close()
+	pool.Close()
+	if _, err := pool.Get(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrPoolClosed, err)
+	}
+
+	// 后台协程读取随机数失败时，Get 返回该错误
+	broken, err := NewPool(1, 1, WithRandom(iotest.ErrReader(errors.New("熵源不可用"))))
+	if err != nil {
+		t.Fatalf("创建预计算池失败: %v", err)
+	}
+	defer broken.Close()
+	if _, err := broken.Get(context.Background()); !errors.Is(err, ErrRandomSource) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrRandomSource, err)
+	}
+}
+
+// 对比直接签名与从预计算池取条目的在线签名延迟
+func BenchmarkSignOnline(b *testing.B) {
+	L, List := newRing(b, 32)
+	rc, _ := NewRingContext(List)
+	b.Run("Sign", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			SignWithRing(MessageTrue, rc, L[0])
+		}
+	})
+	b.Run("SignOnline", func(b *testing.B) {
+		entries := make([]*PoolEntry, b.N)
+		for i := range entries {
+			entries[i], _ = NewPoolEntry(len(List) - 1)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			SignOnline(context.Background(), MessageTrue, rc, L[0], entries[i])
+		}
+	})
+}
+
+// updateVectors 为 true 时以当前实现重新生成 testdata 中的跨实现测试向量
+var updateVectors = flag.Bool("update", false, "重新生成 testdata/backend_vectors.json")
+
+// backendVector 跨实现测试向量：由 Seed 派生的密钥环、Signer 下标处签名者对 Message 的确定性签名
+type backendVector struct {
+	Seed      int64  `json:"seed"`
+	Signer    int    `json:"signer"`
+	Message   string `json:"message"`
+	Ring      *Ring  `json:"ring"`
+	Signature *Sigma `json:"signature"`
+}
+
+// backendVectors 以当前实现生成跨实现测试向量
+func backendVectors(t *testing.T) []backendVector {
+	var vectors []backendVector
+	for seed, n := range map[int64]int{1: 1, 2: 3, 3: 8} {
+		L, List := newRing(t, n, WithRandom(mrand.New(mrand.NewSource(seed))))
+		v := backendVector{Seed: seed, Signer: int(seed) % n, Message: fmt.Sprintf("跨实现测试向量 %d", seed), Ring: &Ring{}}
+		for _, pk := range List {
+			v.Ring.Members = append(v.Ring.Members, RingMember{PublicKey: pk})
+		}
+		sigma, err := Sign([]byte(v.Message), List, L[v.Signer], WithDeterministic(nil))
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		v.Signature = sigma
+		vectors = append(vectors, v)
+	}
+	sort.Slice(vectors, func(i, j int) bool { return vectors[i].Seed < vectors[j].Seed })
+	return vectors
+}
+
+// 测试 testdata 中由 google 实现生成的密钥与签名在当前实现下可以验证，且当前实现生成的结果与之逐字节相同
+// 分别以默认构建与 -tags bn256cloudflare 运行，即可验证两种实现之间的互通
+func TestBackendVectors(t *testing.T) {
+	got := backendVectors(t)
+	path := filepath.Join("testdata", "backend_vectors.json")
+	if *updateVectors {
+		data, err := json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			t.Fatalf("编码测试向量失败: %v", err)
+		}
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取测试向量失败: %v", err)
+	}
+	var want []backendVector
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("解析测试向量失败: %v", err)
+	}
+	if len(want) != len(got) {
+		t.Fatalf("测试向量共 %d 个，期望 %d 个", len(want), len(got))
+	}
+	for i, v := range want {
+		List := v.Ring.PKList()
+		if err := VerifyDetailed([]byte(v.Message), List, v.Signature); err != nil {
+			t.Errorf("%s: 向量 %d 验证失败: %v", bn256.Backend, v.Seed, err)
+		}
+		if Verify(MessageFalse, List, v.Signature) {
+			t.Errorf("%s: 向量 %d 对错误消息验证通过", bn256.Backend, v.Seed)
+		}
+		for j, pk := range got[i].Ring.PKList() {
+			if !CompareG1(pk, List[j]) {
+				t.Errorf("%s: 向量 %d 的公钥 %d 不同", bn256.Backend, v.Seed, j)
+			}
+		}
+		if !sameSigma(got[i].Signature, v.Signature) {
+			t.Errorf("%s: 向量 %d 的签名不同", bn256.Backend, v.Seed)
+		}
+	}
+}
+
+// 测试可链接模式：同一私钥的签名可被链接，密钥镜像与环证明须一致，编码往返后仍可验证
+func TestLinkable(t *testing.T) {
+	L, List := newRing(t, 5)
+
+	sig1, err := Sign(MessageTrue, List, L[1], WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sig2, err := Sign(MessageFalse, List, L[1], WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sig3, err := Sign(MessageTrue, List, L[3], WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	plain, err := Sign(MessageTrue, List, L[1])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	for _, c := range []struct {
+		msg   []byte
+		sigma *Sigma
+	}{{MessageTrue, sig1}, {MessageFalse, sig2}, {MessageTrue, sig3}} {
+		if err := VerifyDetailed(c.msg, List, c.sigma, WithLinkable()); err != nil {
+			t.Errorf("可链接签名验证失败: %v", err)
+		}
+	}
+	if !Link(sig1, sig2) {
+		t.Errorf("同一签名者的两个签名未被链接")
+	}
+	if Link(sig1, sig3) || Link(sig1, plain) || Link(nil, sig1) {
+		t.Errorf("不同签名者或非可链接签名被链接")
+	}
+	if I, err := KeyImage(L[1]); err != nil || !CompareG1(I, sig1.KeyImage) {
+		t.Errorf("KeyImage 与签名中的密钥镜像不同: %v", err)
+	}
+
+	// 普通验证接受可链接签名，可链接验证拒绝普通签名
+	if err := VerifyDetailed(MessageTrue, List, plain); err != nil {
+		t.Errorf("普通签名验证失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, plain, WithLinkable()); !errors.Is(err, ErrNotLinkable) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNotLinkable, err)
+	}
+	if _, err := Sign(MessageTrue, List, L[1], WithLinkable(), WithTranscript(TranscriptV1)); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+
+	// 替换密钥镜像或篡改环证明后验证失败
+	forged := *sig1
+	forged.KeyImage = sig3.KeyImage
+	if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, ErrLinkMismatch) {
+		t.Errorf("替换密钥镜像: 期望错误 %v，实际为 %v", ErrLinkMismatch, err)
+	}
+	forged = *sig1
+	forged.LR = append([]*big.Int{AddZq(sig1.LR[0], big.NewInt(1))}, sig1.LR[1:]...)
+	if err := VerifyDetailed(MessageTrue, List, &forged); !errors.Is(err, ErrLinkMismatch) {
+		t.Errorf("篡改环证明: 期望错误 %v，实际为 %v", ErrLinkMismatch, err)
+	}
+	forged = *sig1
+	forged.LR = sig1.LR[1:]
+	if err := CheckSigma(&forged); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("环证明长度错误: 期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+	forged = *plain
+	forged.LC = sig1.LC
+	if err := CheckSigma(&forged); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("缺少密钥镜像: 期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+
+	// 二进制与 JSON 编码往返
+	data, err := sig1.MarshalBinary()
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if len(data) != LinkableEncodedSize(len(List)) {
+		t.Errorf("编码长度为 %d，期望 %d", len(data), LinkableEncodedSize(len(List)))
+	}
+	var decoded Sigma
+	if err := decoded.UnmarshalBinary(data); err != nil || !sameSigma(&decoded, sig1) {
+		t.Errorf("二进制解码结果与原签名不同: %v", err)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("截断的编码: 期望错误 %v，实际为 %v", ErrInvalidEncoding, err)
+	}
+	js, err := json.Marshal(sig1)
+	if err != nil {
+		t.Fatalf("JSON 编码失败: %v", err)
+	}
+	decoded = Sigma{}
+	if err := json.Unmarshal(js, &decoded); err != nil || !sameSigma(&decoded, sig1) {
+		t.Errorf("JSON 解码结果与原签名不同: %v", err)
+	}
+	if js, _ := json.Marshal(plain); bytes.Contains(js, []byte("key_image")) {
+		t.Errorf("普通签名的 JSON 中出现了 key_image")
+	}
+
+	// 批量验证与单成员环
+	results, err := VerifyBatch([][]byte{MessageTrue, MessageTrue}, [][]*bn256.G1{List, List}, []*Sigma{sig1, plain}, WithLinkable())
+	if err != nil || results[0] != nil || !errors.Is(results[1], ErrNotLinkable) {
+		t.Errorf("批量验证结果为 %v, %v", results, err)
+	}
+	single := []*bn256.G1{L[0].PublicKey}
+	sigma, err := Sign(MessageTrue, single, L[0], WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, single, sigma); err != nil {
+		t.Errorf("单成员环验证失败: %v", err)
+	}
+}
+
+// 测试可追踪模式：管理者打开签名得到签名者下标，任何人都可以验证打开结果
+func TestTracing(t *testing.T) {
+	L, List := newRing(t, 5)
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("生成管理者密钥失败: %v", err)
+	}
+	other, err := NewManager()
+	if err != nil {
+		t.Fatalf("生成管理者密钥失败: %v", err)
+	}
+
+	sig, err := Sign(MessageTrue, List, L[2], WithTracing(m.PublicKey))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	both, err := Sign(MessageTrue, List, L[4], WithTracing(m.PublicKey), WithLinkable())
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	plain, err := Sign(MessageTrue, List, L[2])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	for _, sigma := range []*Sigma{sig, both} {
+		if err := VerifyDetailed(MessageTrue, List, sigma, WithTracing(m.PublicKey)); err != nil {
+			t.Errorf("可追踪签名验证失败: %v", err)
+		}
+	}
+
+	// 确定性模式下，同一消息为不同管理者签名时使用不同的随机数
+	det1, err := Sign(MessageTrue, List, L[2], WithTracing(m.PublicKey), WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	det2, err := Sign(MessageTrue, List, L[2], WithTracing(other.PublicKey), WithDeterministic(nil))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, det2, WithTracing(other.PublicKey)); err != nil {
+		t.Errorf("确定性可追踪签名验证失败: %v", err)
+	}
+	if CompareG1(det1.TE1, det2.TE1) {
+		t.Errorf("不同管理者的确定性签名复用了随机数")
+	}
+
+	// 批量验证只校验一次管理者公钥，非法时整批返回错误
+	if _, err := VerifyBatch([][]byte{MessageTrue}, [][]*bn256.G1{List}, []*Sigma{sig}, WithTracing(new(bn256.G1).ScalarBaseMult(big.NewInt(0)))); !errors.Is(err, ErrIdentityPoint) {
+		t.Errorf("非法管理者公钥: 期望错误 %v，实际为 %v", ErrIdentityPoint, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, both, WithTracing(m.PublicKey), WithLinkable()); err != nil {
+		t.Errorf("可链接且可追踪的签名验证失败: %v", err)
+	}
+
+	// 打开签名并验证打开结果
+	for _, c := range []struct {
+		sigma *Sigma
+		index int
+	}{{sig, 2}, {both, 4}} {
+		proof, err := Open(m, c.sigma, List)
+		if err != nil {
+			t.Fatalf("打开签名失败: %v", err)
+		}
+		if proof.Index != c.index {
+			t.Errorf("打开得到的下标为 %d，期望 %d", proof.Index, c.index)
+		}
+		if err := VerifyOpen(m.PublicKey, c.sigma, List, proof); err != nil {
+			t.Errorf("打开证明验证失败: %v", err)
+		}
+
+		// 声称其他成员、换用其他管理者公钥或篡改响应后验证失败
+		forged := *proof
+		forged.Index = (c.index + 1) % len(List)
+		if err := VerifyOpen(m.PublicKey, c.sigma, List, &forged); !errors.Is(err, ErrInvalidOpenProof) {
+			t.Errorf("冒名的打开结果: 期望错误 %v，实际为 %v", ErrInvalidOpenProof, err)
+		}
+		if err := VerifyOpen(other.PublicKey, c.sigma, List, proof); !errors.Is(err, ErrInvalidOpenProof) {
+			t.Errorf("其他管理者公钥: 期望错误 %v，实际为 %v", ErrInvalidOpenProof, err)
+		}
+		forged = *proof
+		forged.Z = AddZq(proof.Z, big.NewInt(1))
+		if err := VerifyOpen(m.PublicKey, c.sigma, List, &forged); !errors.Is(err, ErrInvalidOpenProof) {
+			t.Errorf("篡改响应: 期望错误 %v，实际为 %v", ErrInvalidOpenProof, err)
+		}
+	}
+
+	// 其他管理者无法打开，普通签名无法打开
+	if _, err := Open(other, sig, List); !errors.Is(err, ErrOpenFailed) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrOpenFailed, err)
+	}
+	if _, err := Open(m, plain, List); !errors.Is(err, ErrNotTraceable) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNotTraceable, err)
+	}
+	if _, err := Open(nil, sig, List); !errors.Is(err, ErrNilManager) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNilManager, err)
+	}
+
+	// 验证时的管理者公钥须与签名一致，且可追踪验证拒绝普通签名
+	if err := VerifyDetailed(MessageTrue, List, sig, WithTracing(other.PublicKey)); !errors.Is(err, ErrTraceMismatch) {
+		t.Errorf("其他管理者公钥: 期望错误 %v，实际为 %v", ErrTraceMismatch, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, sig); !errors.Is(err, ErrTracingKeyRequired) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrTracingKeyRequired, err)
+	}
+	if err := VerifyDetailed(MessageTrue, List, plain, WithTracing(m.PublicKey)); !errors.Is(err, ErrNotTraceable) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrNotTraceable, err)
+	}
+	if _, err := Sign(MessageTrue, List, L[1], WithTracing(m.PublicKey), WithTranscript(TranscriptV1)); !errors.Is(err, ErrUnsupportedTranscript) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnsupportedTranscript, err)
+	}
+
+	// 把密文换成另一个成员公钥的加密后验证失败：签名者无法冒充他人
+	forged := *sig
+	rho := big.NewInt(7)
+	forged.TE1 = new(bn256.G1).ScalarBaseMult(rho)
+	forged.TE2 = AddG1(List[0], ScalarMulG1(m.PublicKey, rho))
+	if err := VerifyDetailed(MessageTrue, List, &forged, WithTracing(m.PublicKey)); !errors.Is(err, ErrTraceMismatch) {
+		t.Errorf("替换密文: 期望错误 %v，实际为 %v", ErrTraceMismatch, err)
+	}
+	forged = *sig
+	forged.TY = sig.TY[1:]
+	if err := CheckSigma(&forged); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("环证明长度错误: 期望错误 %v，实际为 %v", ErrMalformedSignature, err)
+	}
+
+	// 二进制与 JSON 编码往返
+	for _, c := range []struct {
+		sigma *Sigma
+		size  int
+	}{
+		{sig, TraceableEncodedSize(len(List))},
+		{both, TraceableEncodedSize(len(List)) + LinkableEncodedSize(len(List)) - EncodedSize(len(List))},
+	} {
+		data, err := c.sigma.MarshalBinary()
+		if err != nil {
+			t.Fatalf("编码失败: %v", err)
+		}
+		if len(data) != c.size {
+			t.Errorf("编码长度为 %d，期望 %d", len(data), c.size)
+		}
+		var decoded Sigma
+		if err := decoded.UnmarshalBinary(data); err != nil || !sameSigma(&decoded, c.sigma) {
+			t.Errorf("二进制解码结果与原签名不同: %v", err)
+		}
+		js, err := json.Marshal(c.sigma)
+		if err != nil {
+			t.Fatalf("JSON 编码失败: %v", err)
+		}
+		decoded = Sigma{}
+		if err := json.Unmarshal(js, &decoded); err != nil || !sameSigma(&decoded, c.sigma) {
+			t.Errorf("JSON 解码结果与原签名不同: %v", err)
+		}
+	}
+
+	// 批量验证与单成员环
+	results, err := VerifyBatch([][]byte{MessageTrue, MessageTrue, MessageTrue}, [][]*bn256.G1{List, List, List},
+		[]*Sigma{sig, both, plain}, WithTracing(m.PublicKey))
+	if err != nil || results[0] != nil || results[1] != nil || !errors.Is(results[2], ErrNotTraceable) {
+		t.Errorf("批量验证结果为 %v, %v", results, err)
+	}
+	single := []*bn256.G1{L[0].PublicKey}
+	sigma, err := Sign(MessageTrue, single, L[0], WithTracing(m.PublicKey))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyDetailed(MessageTrue, single, sigma, WithTracing(m.PublicKey)); err != nil {
+		t.Errorf("单成员环验证失败: %v", err)
+	}
+	if proof, err := Open(m, sigma, single); err != nil || proof.Index != 0 {
+		t.Errorf("单成员环打开失败: %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package BRFL
+
+import (
+	bn256 "BRFL/BN/BN256"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// -------------------- 可追踪模式 --------------------
+//
+// 可追踪签名把签名者公钥以 ElGamal 加密给追踪管理者：E_1 = ρ \cdot P，E_2 = pk_s + ρ \cdot mpk，其中 mpk = x \cdot P 为管理者公钥。
+// 签名同时附带一个环证明，证明存在某个成员 i，签名者既知道 sk_i，又知道 ρ 使得 E_1 = ρ \cdot P、E_2 - pk_i = ρ \cdot mpk：
+//
+//	A_i = z_i \cdot P + c_i \cdot pk_i,  B_i = y_i \cdot P + c_i \cdot E_1,  D_i = y_i \cdot mpk + c_i \cdot (E_2 - pk_i)
+//	c_{i+1} = H(context, mpk, E_1, E_2, C, A_i, B_i, D_i)
+//
+// 因此密文中只能是签名者自己的公钥，其他成员无法被冒名。管理者用 x 解密得到 pk_s = E_2 - x \cdot E_1，
+// 再附带 Chaum-Pedersen 证明 log_P(mpk) = log_{E_1}(E_2 - pk_s)，任何人都可以据此确认打开结果，而无需知道 x。
+
+// Manager 追踪管理者的密钥对，PublicKey = PrivateKey \cdot P
+type Manager struct {
+	PrivateKey *big.Int
+	PublicKey  *bn256.G1
+}
+
+// OpenProof Open 的结果：签名者在环中的下标，以及解密正确性的 Chaum-Pedersen 证明 (C, Z)
+type OpenProof struct {
+	Index int
+	C     *big.Int
+	Z     *big.Int
+}
+
+// NewManager 生成追踪管理者的密钥对，可通过 WithRandom 指定随机数来源
+func NewManager(opts ...Option) (*Manager, error) {
+	s, err := NewSigner(opts...)
+	if err != nil {
+		return nil, err
+	}
+	// 转换为仿射坐标，之后并发验证时不会再修改该点
+	s.PublicKey.Marshal()
+	return &Manager{PrivateKey: s.PrivateKey, PublicKey: s.PublicKey}, nil
+}
+
+// signTrace 把下标 flag 处签名者的公钥加密给 mpk 并生成环证明，C 为签名的挑战值，随机数从 r 读取
+// 返回 E_1、E_2、起始挑战值 c_0 与各成员的响应 z_i、y_i
+func signTrace(r io.Reader, tr *transcript, rc *RingContext, SignerS *Signer, flag int, C *big.Int, mpk *bn256.G1) (*bn256.G1, *bn256.G1, *big.Int, []*big.Int, []*big.Int, error) {
+	n := rc.Len()
+
+	// 1. 按 ρ、α、β 的顺序读取随机数
+	var rho, alpha, beta *big.Int
+	for _, k := range []**big.Int{&rho, &alpha, &beta} {
+		var err error
+		if *k, err = RandomZqFrom(r); err != nil {
+			zeroizeInt(rho)
+			zeroizeInt(alpha)
+			return nil, nil, nil, nil, nil, err
+		}
+	}
+	defer zeroizeInt(rho)
+	defer zeroizeInt(alpha)
+	defer zeroizeInt(beta)
+
+	// 2. 加密：E_1 = ρ \cdot P，E_2 = pk_s + ρ \cdot mpk
+	E1 := new(bn256.G1).ScalarBaseMult(rho)
+	E2 := AddG1(SignerS.PublicKey, ScalarMulG1(mpk, rho))
+	E1.Marshal()
+	E2.Marshal()
+
+	// 3. 签名者位置：A_s = α \cdot P，B_s = β \cdot P，D_s = β \cdot mpk
+	c := make([]*big.Int, n)
+	c[(flag+1)%n] = tr.Trace(mpk, E1, E2, C,
+		new(bn256.G1).ScalarBaseMult(alpha), new(bn256.G1).ScalarBaseMult(beta), ScalarMulG1(mpk, beta))
+
+	// 4. 其余成员依次取随机响应 z_i、y_i，沿环计算挑战值
+	Z := make([]*big.Int, n)
+	Y := make([]*big.Int, n)
+	for k := 1; k < n; k++ {
+		i := (flag + k) % n
+		var err error
+		if Z[i], err = RandomZqFrom(r); err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		if Y[i], err = RandomZqFrom(r); err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		A, B, D := traceCommit(rc.pkList[i], mpk, E1, E2, c[i], Z[i], Y[i])
+		c[(i+1)%n] = tr.Trace(mpk, E1, E2, C, A, B, D)
+	}
+
+	// 5. 闭合：z_s = α - c_s \cdot sk_s，y_s = β - c_s \cdot ρ
+	Z[flag] = SubZq(alpha, MulZq(c[flag], SignerS.PrivateKey))
+	Y[flag] = SubZq(beta, MulZq(c[flag], rho))
+	return E1, E2, c[0], Z, Y, nil
+}
+
+// traceCommit 由挑战值 c 与响应 z、y 还原成员 pk 处的 A、B、D
+func traceCommit(pk, mpk, E1, E2 *bn256.G1, c, z, y *big.Int) (*bn256.G1, *bn256.G1, *bn256.G1) {
+	A := AddG1(new(bn256.G1).ScalarBaseMult(z), ScalarMulG1(pk, c))
+	B := AddG1(new(bn256.G1).ScalarBaseMult(y), ScalarMulG1(E1, c))
+	D := AddG1(ScalarMulG1(mpk, y), ScalarMulG1(SubG1(E2, pk), c))
+	return A, B, D
+}
+
+// verifyTrace 校验签名中密文的环证明，mpk 为验证者指定的管理者公钥，调用前签名结构已由 CheckSigma 校验
+func verifyTrace(tr *transcript, rc *RingContext, SignerResult *Sigma, mpk *bn256.G1) error {
+	E1, E2 := SignerResult.TE1, SignerResult.TE2
+	c := SignerResult.TC
+	for i, pk := range rc.pkList {
+		A, B, D := traceCommit(pk, mpk, E1, E2, c, SignerResult.TZ[i], SignerResult.TY[i])
+		c = tr.Trace(mpk, E1, E2, SignerResult.C, A, B, D)
+	}
+	if !CompareBigInts(c, SignerResult.TC) {
+		return ErrTraceMismatch
+	}
+	return nil
+}
+
+// Open 由管理者打开可追踪签名：解密得到签名者公钥，返回其在 PKList 中的下标以及任何人都可验证的证明
+// Open 不重新验证签名，调用前应先以 WithTracing(m.PublicKey) 验证签名；解密结果不在环中时返回 ErrOpenFailed
+// 可通过 WithRandom 指定生成证明时使用的随机数来源
+func Open(m *Manager, SignerResult *Sigma, PKList []*bn256.G1, opts ...Option) (*OpenProof, error) {
+	if m == nil || m.PrivateKey == nil || m.PublicKey == nil {
+		return nil, ErrNilManager
+	}
+	ring, err := openInput(SignerResult, PKList)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. 解密：pk = E_2 - x \cdot E_1，再查找其在环中的下标
+	E1, E2 := SignerResult.TE1, SignerResult.TE2
+	pk := SubG1(E2, ScalarMulG1(E1, m.PrivateKey))
+	index, ok := ring[string(pk.Marshal())]
+	if !ok {
+		return nil, ErrOpenFailed
+	}
+
+	// 2. Chaum-Pedersen 证明：k 随机，c = H(ring, mpk, E_1, E_2, pk, k \cdot P, k \cdot E_1)，z = k - c \cdot x
+	k, err := RandomZqFrom(NewConfig(opts...).Random)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroizeInt(k)
+	c := openChallenge(PKList, m.PublicKey, E1, E2, pk, new(bn256.G1).ScalarBaseMult(k), ScalarMulG1(E1, k))
+	return &OpenProof{Index: index, C: c, Z: SubZq(k, MulZq(c, m.PrivateKey))}, nil
+}
+
+// VerifyOpen 验证 Open 的结果：签名中的密文确实是 PKList[proof.Index] 在 mpk 下的加密，合法时返回 nil
+func VerifyOpen(mpk *bn256.G1, SignerResult *Sigma, PKList []*bn256.G1, proof *OpenProof) error {
+	if err := ValidatePoint(mpk); err != nil {
+		return fmt.Errorf("%w: 管理者公钥", err)
+	}
+	if _, err := openInput(SignerResult, PKList); err != nil {
+		return err
+	}
+	if proof == nil || proof.C == nil || proof.Z == nil || proof.Index < 0 || proof.Index >= len(PKList) {
+		return fmt.Errorf("%w: 打开证明不完整", ErrInvalidOpenProof)
+	}
+
+	// A_1 = z \cdot P + c \cdot mpk，A_2 = z \cdot E_1 + c \cdot (E_2 - pk)
+	E1, E2 := SignerResult.TE1, SignerResult.TE2
+	pk := PKList[proof.Index]
+	A1 := AddG1(new(bn256.G1).ScalarBaseMult(proof.Z), ScalarMulG1(mpk, proof.C))
+	A2 := AddG1(ScalarMulG1(E1, proof.Z), ScalarMulG1(SubG1(E2, pk), proof.C))
+	if !CompareBigInts(proof.C, openChallenge(PKList, mpk, E1, E2, pk, A1, A2)) {
+		return ErrInvalidOpenProof
+	}
+	return nil
+}
+
+// openInput Open 与 VerifyOpen 的公共检查：公钥环合法，签名结构合法且是可追踪签名，返回 ValidateRing 得到的下标映射
+func openInput(SignerResult *Sigma, PKList []*bn256.G1) (map[string]int, error) {
+	index, err := ValidateRing(PKList)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateSigma(PKList, SignerResult); err != nil {
+		return nil, err
+	}
+	if SignerResult.TE1 == nil {
+		return nil, ErrNotTraceable
+	}
+	return index, nil
+}
+
+// openChallenge 计算打开证明的挑战值，绑定公钥环、管理者公钥、密文与解密得到的公钥
+func openChallenge(PKList []*bn256.G1, mpk, E1, E2, pk, A1, A2 *bn256.G1) *big.Int {
+	return HashToZqV2(TagOpen, RingDigest(PKList), mpk, E1, E2, pk, A1, A2)
+}
+
+// checkTrace 校验可追踪部分的结构：五个字段须同时存在或同时为空，且只能与 TranscriptV2 一起使用
+func checkTrace(SignerResult *Sigma) error {
+	if SignerResult.TE1 == nil {
+		if SignerResult.TE2 != nil || SignerResult.TC != nil || SignerResult.TZ != nil || SignerResult.TY != nil {
+			return fmt.Errorf("%w: 缺少追踪密文", ErrMalformedSignature)
+		}
+		return nil
+	}
+	if SignerResult.Version != TranscriptV2 {
+		return fmt.Errorf("%w: 可追踪签名须使用 v2 转录编码", ErrMalformedSignature)
+	}
+	if err := ValidatePoint(SignerResult.TE1); err != nil {
+		return fmt.Errorf("%w: E_1", err)
+	}
+	if SignerResult.TE2 == nil {
+		return fmt.Errorf("%w: 缺少 E_2", ErrMalformedSignature)
+	}
+	if err := checkSubgroup(SignerResult.TE2); err != nil {
+		return fmt.Errorf("%w: E_2", err)
+	}
+	n := len(SignerResult.UI)
+	if SignerResult.TC == nil || len(SignerResult.TZ) != n || len(SignerResult.TY) != n {
+		return fmt.Errorf("%w: 追踪密文的环证明不完整", ErrMalformedSignature)
+	}
+	for _, k := range append(append([]*big.Int{SignerResult.TC}, SignerResult.TZ...), SignerResult.TY...) {
+		if k == nil || k.Sign() < 0 || k.Cmp(bn256.Order) >= 0 {
+			return fmt.Errorf("%w: 标量超出 Z_q 范围", ErrMalformedSignature)
+		}
+	}
+	return nil
+}
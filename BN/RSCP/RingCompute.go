@@ -1,47 +1,43 @@
 package RSCP
 
 import (
-	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/google"
+	bn256 "BRFL/BN/BN256"
+	"BRFL/DRBG"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"math/big"
 )
 
 // ComputeSum 排除 signer_index 后计算 H_i * PK_i + U_i 的和
+// 其中 \sum H_i * PK_i 部分通过 MultiScalarMulG1 一次算出，U_i 直接累加
 func ComputeSum(H_i []*big.Int, PK_List []*bn256.G1, U_i []*bn256.G1, signer_index int) *bn256.G1 {
-	// 初始化结果为 G1 群中的零点
-	sum := new(bn256.G1)
-
-	// 遍历所有 H_i, PK_List 和 U_i
-	for i := 0; i < len(H_i); i++ {
+	points := make([]*bn256.G1, 0, len(H_i))
+	scalars := make([]*big.Int, 0, len(H_i))
+	sum := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+	for i := range H_i {
 		if i == signer_index {
-			// 跳过 signer_index
 			continue
 		}
-
-		// 计算 H_i * PK_i
-		H_PK := new(bn256.G1).ScalarMult(PK_List[i], H_i[i])
-
-		// 计算 H_i * PK_i + U_i
-		partialSum := new(bn256.G1).Add(H_PK, U_i[i])
-
-		// 将结果累加到 sum
-		sum.Add(sum, partialSum)
+		points = append(points, PK_List[i])
+		scalars = append(scalars, H_i[i])
+		sum = AddG1(sum, U_i[i])
 	}
-
-	return sum
+	return AddG1(sum, MultiScalarMulG1(points, scalars))
 }
 
 // VerifyPairing 验证 e(P, V) 是否等于 e(Sum, Q)
+// 等价于检查 e(P, V) \cdot e(-Sum, Q) = 1，两个 Miller 循环共用一次最终幂运算
 func VerifyPairing(Sum *bn256.G1, V *bn256.G2) bool {
-	// 计算 e(P, V)，其中 P 是 G1 的生成元
-	P := new(bn256.G1).ScalarBaseMult(big.NewInt(1)) // G1 的生成元
-	pairing1 := bn256.Pair(P, V)
+	// P、Q 分别是 G1、G2 的生成元
+	P := new(bn256.G1).ScalarBaseMult(big.NewInt(1))
+	Q := new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	negSum := new(bn256.G1).Neg(Sum)
 
-	// 计算 e(Sum, Q)，其中 Q 是 G2 的生成元
-	Q := new(bn256.G2).ScalarBaseMult(big.NewInt(1)) // G2 的生成元
-	pairing2 := bn256.Pair(Sum, Q)
-
-	// 比较两者是否相等
-	return pairing1.String() == pairing2.String()
+	return bn256.PairingCheck([]*bn256.G1{P, negSum}, []*bn256.G2{V, Q})
 }
 
 func ComputeV(R, H_s, SK_S *big.Int) *bn256.G2 {
@@ -66,21 +62,203 @@ func ComputeUS(r *big.Int, HiList []*big.Int, PKList []*bn256.G1, UiList []*bn25
 	tmp1 := new(bn256.G1).ScalarBaseMult(r)
 
 	// 2. 计算 tmpSum = \sum_{i \ne s}\Bigl(U_i + H_i \cdot \mathit{pk}_i\Bigr)
-	tmpSum := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
-	for i, v := range UiList {
-		if flag == i {
-			continue
-		}
-		// 计算 tmp2 = H_i \cdot \mathit{pk}_i
-		tmp2 := ScalarMulG1(PKList[i], HiList[i])
-		// 计算 tmpSumPart = U_i + tmp2
-		tmpSumPart := AddG1(v, tmp2)
-
-		tmpSum = AddG1(tmpSum, tmpSumPart)
-	}
+	tmpSum := ComputeSum(HiList, PKList, UiList, flag)
 
 	// 3. 计算 tmp1 - tmpSum
 	US = SubG1(tmp1, tmpSum)
 
 	return
 }
+
+// ValidatePoint 校验 G1 点：不能为 nil，也不能是无穷远点
+// bn256 的 G1 余因子为 1，且 Unmarshal 已校验点在曲线上，因此无需额外的子群校验
+func ValidatePoint(p *bn256.G1) error {
+	if err := checkSubgroup(p); err != nil {
+		return err
+	}
+	if isIdentity(p.Marshal()) {
+		return ErrIdentityPoint
+	}
+	return nil
+}
+
+// checkSubgroup 校验 G1 点属于素数阶子群，允许无穷远点；G1 余因子为 1，已初始化的点即满足要求
+func checkSubgroup(p *bn256.G1) error {
+	if p == nil || *p == (bn256.G1{}) {
+		return ErrInvalidPoint
+	}
+	return nil
+}
+
+// ValidateG2Point 校验 G2 点：不能为 nil，也不能是无穷远点
+// bn256 的 G2 Unmarshal 在校验曲线方程的同时检查 Order \cdot V = O，因此无需重复子群校验
+func ValidateG2Point(p *bn256.G2) error {
+	if p == nil || *p == (bn256.G2{}) {
+		return ErrInvalidPoint
+	}
+	if isIdentity(p.Marshal()) {
+		return ErrIdentityPoint
+	}
+	return nil
+}
+
+// isIdentity 判断 Marshal 的结果是否为无穷远点的编码（全零）
+func isIdentity(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateRing 校验公钥环：不能为空、不能包含 nil 公钥、无效点或无穷远点、不能包含重复公钥
+// 返回公钥序列化结果到下标的映射，便于后续定位签名者
+func ValidateRing(PKList []*bn256.G1) (index map[string]int, err error) {
+	return validateRing(context.Background(), PKList)
+}
+
+// validateRing ValidateRing 的实现，每 sumChunk 个公钥检查一次 ctx，ctx 被取消时返回 ctx.Err()
+func validateRing(ctx context.Context, PKList []*bn256.G1) (index map[string]int, err error) {
+	if len(PKList) == 0 {
+		return nil, ErrEmptyRing
+	}
+
+	index = make(map[string]int, len(PKList))
+	for i, v := range PKList {
+		if i%sumChunk == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if v == nil {
+			return nil, fmt.Errorf("%w: 下标 %d", ErrNilPublicKey, i)
+		}
+		if err := ValidatePoint(v); err != nil {
+			return nil, fmt.Errorf("%w: 下标 %d", err, i)
+		}
+		key := string(v.Marshal())
+		if j, ok := index[key]; ok {
+			return nil, fmt.Errorf("%w: 下标 %d 与 %d", ErrDuplicatePublicKey, j, i)
+		}
+		index[key] = i
+	}
+	return
+}
+
+// FindSigner 校验公钥环与签名者的密钥对，并返回签名者公钥在 PKList 中的下标
+func FindSigner(PKList []*bn256.G1, SignerS *Signer) (flag int, err error) {
+	if SignerS == nil || SignerS.PrivateKey == nil || SignerS.PublicKey == nil {
+		return -1, ErrNilSigner
+	}
+
+	index, err := ValidateRing(PKList)
+	if err != nil {
+		return -1, err
+	}
+
+	flag, ok := index[string(SignerS.PublicKey.Marshal())]
+	if !ok {
+		return -1, ErrSignerNotInRing
+	}
+	if !matchKeyPair(SignerS) {
+		return -1, ErrKeyMismatch
+	}
+	return flag, nil
+}
+
+// matchKeyPair 判断签名者的公钥是否等于 PrivateKey \cdot P，密钥不匹配时签名无法通过验证
+func matchKeyPair(SignerS *Signer) bool {
+	return CompareG1(new(bn256.G1).ScalarBaseMult(SignerS.PrivateKey), SignerS.PublicKey)
+}
+
+// CheckSigma 校验签名结构本身：U_i 与 V 不能为 nil，且须为素数阶子群中的有效点，V 不能是无穷远点
+func CheckSigma(SignerResult *Sigma) error {
+	if SignerResult == nil || SignerResult.V == nil {
+		return fmt.Errorf("%w: 缺少签名字段", ErrMalformedSignature)
+	}
+
+	if err := ValidateG2Point(SignerResult.V); err != nil {
+		return fmt.Errorf("%w: V", err)
+	}
+
+	for i, v := range SignerResult.UI {
+		if v == nil {
+			return fmt.Errorf("%w: U_%d 为 nil", ErrMalformedSignature, i)
+		}
+		if err := checkSubgroup(v); err != nil {
+			return fmt.Errorf("%w: U_%d", err, i)
+		}
+	}
+	if err := checkLinkable(SignerResult); err != nil {
+		return err
+	}
+	return checkThreshold(SignerResult)
+}
+
+// ValidateSigma 校验签名结构，并要求 U_i 的个数与环大小一致
+func ValidateSigma(PKList []*bn256.G1, SignerResult *Sigma) error {
+	if err := CheckSigma(SignerResult); err != nil {
+		return err
+	}
+	if len(SignerResult.UI) != len(PKList) {
+		return fmt.Errorf("%w: U_i 个数为 %d，环大小为 %d", ErrRingSizeMismatch, len(SignerResult.UI), len(PKList))
+	}
+	return nil
+}
+
+// checkVerifyInput 验证前的公共检查：签名结构、cfg 是否接受签名的转录版本，可链接模式下签名的范围，以及要求的最小门限；公钥环已由 NewRingContext 校验
+func checkVerifyInput(cfg *Config, rc *RingContext, SignerResult *Sigma) error {
+	if err := ValidateSigma(rc.pkList, SignerResult); err != nil {
+		return err
+	}
+	if !cfg.AcceptsTranscript(SignerResult.Version) {
+		return fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, SignerResult.Version)
+	}
+	if cfg.Linkable {
+		if SignerResult.Tag == nil {
+			return ErrNotLinkable
+		}
+		if !bytes.Equal(SignerResult.Scope, cfg.Scope) {
+			return ErrScopeMismatch
+		}
+	}
+	// 普通签名相当于门限为 1 的签名
+	if t := max(SignerResult.Threshold, 1); t < cfg.Threshold {
+		return fmt.Errorf("%w: 签名门限为 %d，要求 %d", ErrThresholdTooLow, t, cfg.Threshold)
+	}
+	return nil
+}
+
+// NewNonceReader 构造确定性签名模式下的随机数来源
+// HMAC-DRBG 以定长编码的私钥为熵，以签名选项、消息与公钥环的摘要为 nonce，cfg.ExtraEntropy 追加在个性化字符串之后
+// 影响挑战值的选项都写入 nonce，同一消息在不同选项下签名时不会复用随机数，否则由两个签名即可解出私钥
+func NewNonceReader(sk *big.Int, Message []byte, PKList []*bn256.G1, cfg *Config) io.Reader {
+	// 1. 私钥编码为定长 32 字节
+	entropy := new(big.Int).Mod(sk, bn256.Order).FillBytes(make([]byte, 32))
+
+	// 2. nonce = SHA256(options || len(Message) || Message || pk_1 || ... || pk_n)
+	h := sha256.New()
+	h.Write(nonceOptions(cfg))
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(Message)))
+	h.Write(length[:])
+	h.Write(Message)
+	for _, pk := range PKList {
+		h.Write(pk.Marshal())
+	}
+
+	personalization := append([]byte(NonceDomain), cfg.ExtraEntropy...)
+	return DRBG.New(entropy, h.Sum(nil), personalization)
+}
+
+// nonceOptions 编码影响挑战值的签名选项，作为确定性签名 nonce 的前缀：
+// 转录版本 (1) || 是否可链接 (1) [ || 范围长度 (8) || Scope ]
+// 范围写入 nonce 后，同一消息在不同范围内的签名不会复用随机数，否则两个 V 之差会泄露 sk \cdot Q
+func nonceOptions(cfg *Config) []byte {
+	if !cfg.Linkable {
+		return []byte{byte(cfg.Transcript), 0}
+	}
+	buf := binary.BigEndian.AppendUint64([]byte{byte(cfg.Transcript), 1}, uint64(len(cfg.Scope)))
+	return append(buf, cfg.Scope...)
+}
@@ -0,0 +1,439 @@
+package RSCP
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	bn256 "BRFL/BN/BN256"
+)
+
+// -------------------- JSON 编码 --------------------
+//
+// 点与标量均编码为十六进制字符串，字节格式与二进制编码相同（见 RingEncoding.go）。
+// 每个 JSON 文档都带有 scheme 字段，解码时会拒绝未知字段并完整校验所有取值。
+
+// sigmaJSON Sigma 的 JSON 表示
+type sigmaJSON struct {
+	Scheme  string   `json:"scheme"`
+	Version uint8    `json:"version"`
+	UI      []string `json:"ui"`
+	V       string   `json:"v"`
+	// 以下字段仅出现在可链接签名中，scope 为十六进制编码的范围
+	Scope *string  `json:"scope,omitempty"`
+	Tag   string   `json:"tag,omitempty"`
+	WI    []string `json:"wi,omitempty"`
+	// 以下字段仅出现在门限签名中
+	Threshold int      `json:"threshold,omitempty"`
+	F         []string `json:"f,omitempty"`
+}
+
+// publicKeyJSON 公钥的 JSON 表示
+type publicKeyJSON struct {
+	Scheme string `json:"scheme"`
+	PK     string `json:"pk"`
+}
+
+// ringMemberJSON 公钥环成员的 JSON 表示
+type ringMemberJSON struct {
+	Label string `json:"label,omitempty"`
+	PK    string `json:"pk"`
+}
+
+// ringJSON 公钥环的 JSON 表示
+type ringJSON struct {
+	Scheme  string           `json:"scheme"`
+	Members []ringMemberJSON `json:"members"`
+}
+
+// thresholdCommitmentJSON ThresholdCommitment 的 JSON 表示
+type thresholdCommitmentJSON struct {
+	Scheme string `json:"scheme"`
+	Index  int    `json:"index"`
+	U      string `json:"u"`
+}
+
+// thresholdContributionJSON ThresholdContribution 的 JSON 表示
+type thresholdContributionJSON struct {
+	Scheme string `json:"scheme"`
+	Index  int    `json:"index"`
+	V      string `json:"v"`
+}
+
+// thresholdSessionJSON ThresholdSession 的 JSON 表示，message 为十六进制编码的消息
+type thresholdSessionJSON struct {
+	Scheme    string   `json:"scheme"`
+	Message   string   `json:"message"`
+	Threshold int      `json:"threshold"`
+	Signers   []int    `json:"signers"`
+	UI        []string `json:"ui"`
+	F         []string `json:"f"`
+}
+
+// RingMember 公钥环中的一个成员，Label 为可选的标签
+type RingMember struct {
+	Label     string
+	PublicKey *bn256.G1
+}
+
+// Ring 公钥环文档，成员的顺序即 PKList 的顺序
+type Ring struct {
+	Members []RingMember
+}
+
+// PKList 返回公钥环文档中按顺序排列的公钥列表
+func (r *Ring) PKList() []*bn256.G1 {
+	PKList := make([]*bn256.G1, len(r.Members))
+	for i, m := range r.Members {
+		PKList[i] = m.PublicKey
+	}
+	return PKList
+}
+
+// MarshalJSON 将签名编码为 JSON，实现 json.Marshaler
+func (s *Sigma) MarshalJSON() ([]byte, error) {
+	if err := CheckSigma(s); err != nil {
+		return nil, err
+	}
+
+	out := sigmaJSON{
+		Scheme:  SchemeName,
+		Version: uint8(s.Version),
+		UI:      make([]string, len(s.UI)),
+		V:       hex.EncodeToString(encodeG2Point(s.V)),
+	}
+	for i, v := range s.UI {
+		out.UI[i] = hex.EncodeToString(encodePoint(v))
+	}
+	if s.Tag != nil {
+		scope := hex.EncodeToString(s.Scope)
+		out.Scope = &scope
+		out.Tag = hex.EncodeToString(encodePoint(s.Tag))
+		out.WI = make([]string, len(s.WI))
+		for i, w := range s.WI {
+			out.WI[i] = hex.EncodeToString(encodePoint(w))
+		}
+	}
+	if s.Threshold > 0 {
+		out.Threshold = s.Threshold
+		out.F = make([]string, len(s.F))
+		for i, k := range s.F {
+			out.F[i] = hex.EncodeToString(appendScalar(nil, k))
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 从 JSON 解码签名，实现 json.Unmarshaler，并按 CheckSigma 拒绝无效点，失败时 s 保持不变
+func (s *Sigma) UnmarshalJSON(data []byte) error {
+	var in sigmaJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+	version := TranscriptVersion(in.Version)
+	if !version.Supported() {
+		return fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, version)
+	}
+
+	UI, err := decodeHexPoints(in.UI)
+	if err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(in.V)
+	if err != nil {
+		return fmt.Errorf("%w: v 不是合法的十六进制字符串", ErrInvalidEncoding)
+	}
+	V, err := readG2Point(b)
+	if err != nil {
+		return err
+	}
+
+	sig := Sigma{UI: UI, V: V, Version: version}
+	if in.Scope != nil || in.Tag != "" || in.WI != nil {
+		if in.Scope == nil {
+			return fmt.Errorf("%w: 缺少 scope", ErrInvalidEncoding)
+		}
+		if sig.Scope, err = hex.DecodeString(*in.Scope); err != nil {
+			return fmt.Errorf("%w: scope 不是合法的十六进制字符串", ErrInvalidEncoding)
+		}
+		if sig.Tag, err = decodeHexPoint(in.Tag); err != nil {
+			return fmt.Errorf("tag: %w", err)
+		}
+		if sig.WI, err = decodeHexPoints(in.WI); err != nil {
+			return fmt.Errorf("wi: %w", err)
+		}
+	}
+	if in.Threshold != 0 || in.F != nil {
+		sig.Threshold = in.Threshold
+		if sig.F, err = decodeHexScalars(in.F); err != nil {
+			return err
+		}
+	}
+	if err := CheckSigma(&sig); err != nil {
+		return err
+	}
+	*s = sig
+	return nil
+}
+
+// MarshalJSON 只编码签名者的公钥部分，私钥永远不会写入 JSON，实现 json.Marshaler
+func (s *Signer) MarshalJSON() ([]byte, error) {
+	if s.PublicKey == nil {
+		return nil, ErrNilPublicKey
+	}
+	return json.Marshal(publicKeyJSON{Scheme: SchemeName, PK: hex.EncodeToString(encodePoint(s.PublicKey))})
+}
+
+// UnmarshalJSON 解码签名者的公钥部分，实现 json.Unmarshaler，公钥不能是无穷远点，解码后 PrivateKey 为 nil
+func (s *Signer) UnmarshalJSON(data []byte) error {
+	var in publicKeyJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+	pk, err := decodeHexPoint(in.PK)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidatePoint(pk); err != nil {
+		return err
+	}
+
+	*s = Signer{PublicKey: pk}
+	return nil
+}
+
+// MarshalJSON 将公钥环文档编码为 JSON，实现 json.Marshaler
+func (r *Ring) MarshalJSON() ([]byte, error) {
+	if _, err := ValidateRing(r.PKList()); err != nil {
+		return nil, err
+	}
+
+	out := ringJSON{Scheme: SchemeName, Members: make([]ringMemberJSON, len(r.Members))}
+	for i, m := range r.Members {
+		out.Members[i] = ringMemberJSON{Label: m.Label, PK: hex.EncodeToString(encodePoint(m.PublicKey))}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 从 JSON 解码公钥环文档，实现 json.Unmarshaler
+// 除逐个校验公钥外，还会按 ValidateRing 拒绝空环、无穷远点与重复公钥，失败时 r 保持不变
+func (r *Ring) UnmarshalJSON(data []byte) error {
+	var in ringJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+
+	members := make([]RingMember, len(in.Members))
+	for i, m := range in.Members {
+		pk, err := decodeHexPoint(m.PK)
+		if err != nil {
+			return fmt.Errorf("成员 %d: %w", i, err)
+		}
+		members[i] = RingMember{Label: m.Label, PublicKey: pk}
+	}
+
+	ring := Ring{Members: members}
+	if _, err := ValidateRing(ring.PKList()); err != nil {
+		return err
+	}
+	*r = ring
+	return nil
+}
+
+// MarshalJSON 将承诺编码为 JSON，实现 json.Marshaler
+func (c *ThresholdCommitment) MarshalJSON() ([]byte, error) {
+	if c.Index < 0 {
+		return nil, fmt.Errorf("%w: 成员下标为负", ErrInvalidThreshold)
+	}
+	if err := ValidatePoint(c.U); err != nil {
+		return nil, err
+	}
+	return json.Marshal(thresholdCommitmentJSON{Scheme: SchemeName, Index: c.Index, U: hex.EncodeToString(encodePoint(c.U))})
+}
+
+// UnmarshalJSON 从 JSON 解码承诺，实现 json.Unmarshaler，U 不能是无穷远点，失败时 c 保持不变
+func (c *ThresholdCommitment) UnmarshalJSON(data []byte) error {
+	var in thresholdCommitmentJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+	if in.Index < 0 {
+		return fmt.Errorf("%w: 成员下标为负", ErrInvalidEncoding)
+	}
+	U, err := decodeHexPoint(in.U)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePoint(U); err != nil {
+		return err
+	}
+	*c = ThresholdCommitment{Index: in.Index, U: U}
+	return nil
+}
+
+// MarshalJSON 将贡献编码为 JSON，实现 json.Marshaler
+func (c *ThresholdContribution) MarshalJSON() ([]byte, error) {
+	if c.Index < 0 {
+		return nil, fmt.Errorf("%w: 成员下标为负", ErrInvalidContribution)
+	}
+	if err := ValidateG2Point(c.V); err != nil {
+		return nil, err
+	}
+	return json.Marshal(thresholdContributionJSON{Scheme: SchemeName, Index: c.Index, V: hex.EncodeToString(encodeG2Point(c.V))})
+}
+
+// UnmarshalJSON 从 JSON 解码贡献，实现 json.Unmarshaler，V 不能是无穷远点，失败时 c 保持不变
+func (c *ThresholdContribution) UnmarshalJSON(data []byte) error {
+	var in thresholdContributionJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+	if in.Index < 0 {
+		return fmt.Errorf("%w: 成员下标为负", ErrInvalidEncoding)
+	}
+	b, err := hex.DecodeString(in.V)
+	if err != nil {
+		return fmt.Errorf("%w: v 不是合法的十六进制字符串", ErrInvalidEncoding)
+	}
+	V, err := readG2Point(b)
+	if err != nil {
+		return err
+	}
+	if err := ValidateG2Point(V); err != nil {
+		return err
+	}
+	*c = ThresholdContribution{Index: in.Index, V: V}
+	return nil
+}
+
+// MarshalJSON 将公开的会话编码为 JSON，实现 json.Marshaler
+func (s *ThresholdSession) MarshalJSON() ([]byte, error) {
+	if err := s.check(len(s.UI)); err != nil {
+		return nil, err
+	}
+
+	out := thresholdSessionJSON{
+		Scheme:    SchemeName,
+		Message:   hex.EncodeToString(s.Message),
+		Threshold: s.Threshold,
+		Signers:   s.Signers,
+		UI:        make([]string, len(s.UI)),
+		F:         make([]string, len(s.F)),
+	}
+	for i, U := range s.UI {
+		out.UI[i] = hex.EncodeToString(encodePoint(U))
+	}
+	for i, k := range s.F {
+		out.F[i] = hex.EncodeToString(appendScalar(nil, k))
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 从 JSON 解码公开的会话，实现 json.Unmarshaler，并校验各字段的长度与签名者下标，失败时 s 保持不变
+// 会话是否对应签名者的公钥环由 ThresholdContribute 校验
+func (s *ThresholdSession) UnmarshalJSON(data []byte) error {
+	var in thresholdSessionJSON
+	if err := decodeStrict(data, &in); err != nil {
+		return err
+	}
+	if err := checkScheme(in.Scheme); err != nil {
+		return err
+	}
+
+	Message, err := hex.DecodeString(in.Message)
+	if err != nil {
+		return fmt.Errorf("%w: message 不是合法的十六进制字符串", ErrInvalidEncoding)
+	}
+	UI, err := decodeHexPoints(in.UI)
+	if err != nil {
+		return err
+	}
+	F, err := decodeHexScalars(in.F)
+	if err != nil {
+		return err
+	}
+
+	session := ThresholdSession{Message: Message, Threshold: in.Threshold, Signers: in.Signers, UI: UI, F: F}
+	if err := session.check(len(UI)); err != nil {
+		return err
+	}
+	*s = session
+	return nil
+}
+
+// decodeStrict 解码 JSON 并拒绝未知字段
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return nil
+}
+
+// checkScheme 校验 JSON 文档中的方案名称
+func checkScheme(scheme string) error {
+	if scheme != SchemeName {
+		return fmt.Errorf("%w: 方案 %q 不是 %s", ErrInvalidEncoding, scheme, SchemeName)
+	}
+	return nil
+}
+
+// decodeHexPoint 解码十六进制表示的 G1 点
+func decodeHexPoint(str string) (*bn256.G1, error) {
+	b, err := hex.DecodeString(str)
+	if err != nil || len(b) != PointSize {
+		return nil, fmt.Errorf("%w: 点须为 %d 字节的十六进制字符串", ErrInvalidEncoding, PointSize)
+	}
+	p, _, err := readPoint(b)
+	return p, err
+}
+
+// decodeHexPoints 解码十六进制表示的 U_i 列表，列表不能为空
+func decodeHexPoints(strs []string) ([]*bn256.G1, error) {
+	if len(strs) == 0 {
+		return nil, fmt.Errorf("%w: 缺少 ui", ErrInvalidEncoding)
+	}
+	points := make([]*bn256.G1, len(strs))
+	for i, str := range strs {
+		p, err := decodeHexPoint(str)
+		if err != nil {
+			return nil, fmt.Errorf("U_%d: %w", i, err)
+		}
+		points[i] = p
+	}
+	return points, nil
+}
+
+// decodeHexScalars 解码十六进制表示的多项式系数 F_i 列表，每个系数为定长标量
+func decodeHexScalars(strs []string) ([]*big.Int, error) {
+	scalars := make([]*big.Int, len(strs))
+	for i, str := range strs {
+		b, err := hex.DecodeString(str)
+		if err != nil || len(b) != ScalarSize {
+			return nil, fmt.Errorf("F_%d: %w: 标量须为 %d 字节的十六进制字符串", i, ErrInvalidEncoding, ScalarSize)
+		}
+		if scalars[i], _, err = readScalar(b); err != nil {
+			return nil, fmt.Errorf("F_%d: %w", i, err)
+		}
+	}
+	return scalars, nil
+}
@@ -0,0 +1,131 @@
+package RSCP
+
+import (
+	bn256 "BRFL/BN/BN256"
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// -------------------- 可链接模式 --------------------
+//
+// 可链接签名在范围 scope 下附带标签 Tag = sk_s \cdot B，其中 B = H_p(scope)。每个成员除 U_i 外还有一个以 B 为基的 W_i，
+// H_i = H(context, scope, Tag, U_i, W_i)，签名者取 W_s = r \cdot B - \sum_{i \ne s}(W_i + H_i \cdot Tag)，于是
+//
+//	\sum_i (U_i + H_i \cdot pk_i) = v \cdot P,  \sum_i (W_i + H_i \cdot Tag) = v \cdot B,  V = v \cdot Q
+//
+// 第一个等式即原有的 e(P, V) = e(Sum, Q)，第二个等式由额外的一个配对等式 e(B, V) = e(Sum_W, Q) 验证。
+// 两个等式共用同一组 H_i 与同一个 v，只有知道 pk_s 对应私钥且 Tag = sk_s \cdot B 的成员才能同时满足；
+// 同一私钥在同一范围内的签名具有相同的 Tag，由 Link 判断，不同范围的标签之间无法关联。
+
+// HashToPointG1 将 data 哈希为 G1 上离散对数未知的点，即可链接模式中的 H_p
+// 采用试探递增法：x = SHA-512(HashDomainV2 + TagHp || ctr || data) mod p，直到 x^3 + 3 为二次剩余，取 y = \sqrt{x^3 + 3}
+func HashToPointG1(data []byte) *bn256.G1 {
+	three := big.NewInt(3)
+	for ctr := uint32(0); ; ctr++ {
+		buf := appendBytes(nil, []byte(HashDomainV2+TagHp))
+		buf = binary.BigEndian.AppendUint32(buf, ctr)
+		buf = appendBytes(buf, data)
+		hash := sha512.Sum512(buf)
+
+		x := new(big.Int).SetBytes(hash[:])
+		x.Mod(x, bn256.P)
+		y2 := new(big.Int).Exp(x, three, bn256.P)
+		y2.Add(y2, three)
+		y := new(big.Int).ModSqrt(y2.Mod(y2, bn256.P), bn256.P)
+		if y == nil {
+			continue
+		}
+
+		p := new(bn256.G1)
+		if _, err := p.Unmarshal(append(x.FillBytes(make([]byte, 32)), y.FillBytes(make([]byte, 32))...)); err == nil {
+			return p
+		}
+	}
+}
+
+// LinkTag 计算签名者在范围 scope 下的标签 sk \cdot H_p(scope)
+func LinkTag(SignerS *Signer, scope []byte) (*bn256.G1, error) {
+	if SignerS == nil || SignerS.PrivateKey == nil {
+		return nil, ErrNilSigner
+	}
+	return ScalarMulG1(HashToPointG1(scope), SignerS.PrivateKey), nil
+}
+
+// Link 判断两个可链接签名是否由同一私钥在同一范围内生成，任一签名不是可链接签名时返回 false
+// 只比较范围与标签，调用前应先分别验证两个签名
+func Link(sig1, sig2 *Sigma) bool {
+	if sig1 == nil || sig2 == nil || sig1.Tag == nil || sig2.Tag == nil {
+		return false
+	}
+	return bytes.Equal(sig1.Scope, sig2.Scope) && CompareG1(sig1.Tag, sig2.Tag)
+}
+
+// VerifyTagPairing 验证 e(B, V) 是否等于 e(SumW, Q)，B 为 H_p(Scope)
+func VerifyTagPairing(B, SumW *bn256.G1, V *bn256.G2) bool {
+	Q := new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	negSum := new(bn256.G1).Neg(SumW)
+
+	return bn256.PairingCheck([]*bn256.G1{B, negSum}, []*bn256.G2{V, Q})
+}
+
+// verifyTag 在 H_i 已计算的前提下校验可链接签名的第二个等式
+func verifyTag(SignerResult *Sigma, HiList []*big.Int) error {
+	if !VerifyTagPairing(HashToPointG1(SignerResult.Scope), sumW(SignerResult.WI, HiList, SignerResult.Tag, -1), SignerResult.V) {
+		return ErrTagMismatch
+	}
+	return nil
+}
+
+// sumW 排除 signer_index 后计算 \sum_i (W_i + H_i \cdot Tag)
+func sumW(WI []*bn256.G1, HiList []*big.Int, Tag *bn256.G1, signer_index int) *bn256.G1 {
+	sum := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+	h := new(big.Int)
+	for i, W := range WI {
+		if i == signer_index {
+			continue
+		}
+		sum = AddG1(sum, W)
+		h.Add(h, HiList[i])
+	}
+	return AddG1(sum, ScalarMulG1(Tag, h.Mod(h, bn256.Order)))
+}
+
+// sigmaTranscript 构造验证 SignerResult 使用的哈希上下文，可链接签名的 H_i 绑定其范围与标签
+func sigmaTranscript(Message []byte, rc *RingContext, SignerResult *Sigma) *transcript {
+	tr := newTranscript(SignerResult.Version, Message, rc)
+	if SignerResult.Tag != nil {
+		tr.bindTag(SignerResult.Scope, SignerResult.Tag)
+	}
+	return tr
+}
+
+// checkLinkable 校验可链接部分的结构：Tag 与 W_i 须同时存在或同时为空，且只能与 TranscriptV2 一起使用
+func checkLinkable(SignerResult *Sigma) error {
+	if SignerResult.Tag == nil {
+		if SignerResult.WI != nil || SignerResult.Scope != nil {
+			return fmt.Errorf("%w: 缺少标签", ErrMalformedSignature)
+		}
+		return nil
+	}
+	if SignerResult.Version != TranscriptV2 {
+		return fmt.Errorf("%w: 可链接签名须使用 v2 转录编码", ErrMalformedSignature)
+	}
+	if err := ValidatePoint(SignerResult.Tag); err != nil {
+		return fmt.Errorf("%w: 标签", err)
+	}
+	if len(SignerResult.WI) != len(SignerResult.UI) {
+		return fmt.Errorf("%w: W_i 个数为 %d，U_i 个数为 %d", ErrMalformedSignature, len(SignerResult.WI), len(SignerResult.UI))
+	}
+	for i, w := range SignerResult.WI {
+		if w == nil {
+			return fmt.Errorf("%w: W_%d 为 nil", ErrMalformedSignature, i)
+		}
+		if err := checkSubgroup(w); err != nil {
+			return fmt.Errorf("%w: W_%d", err, i)
+		}
+	}
+	return nil
+}
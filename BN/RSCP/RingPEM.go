@@ -0,0 +1,81 @@
+package RSCP
+
+import (
+	"BRFL/KeyPEM"
+	"fmt"
+	"math/big"
+
+	bn256 "BRFL/BN/BN256"
+)
+
+// -------------------- PEM 密钥文件 --------------------
+
+// CurveName PEM 头部中标识曲线的名称
+const CurveName = "BN254"
+
+// MarshalPrivateKeyPEM 使用口令加密私钥并编码为 PEM，头部注明方案与曲线
+// 可通过 WithRandom 指定生成盐与 nonce 的随机数来源，通过 WithKDFIterations 指定 PBKDF2 迭代次数
+func (s *Signer) MarshalPrivateKeyPEM(passphrase []byte, opts ...Option) ([]byte, error) {
+	if s.PrivateKey == nil {
+		return nil, ErrNilSigner
+	}
+	cfg := NewConfig(opts...)
+
+	sk := new(big.Int).Mod(s.PrivateKey, bn256.Order).FillBytes(make([]byte, 32))
+	return KeyPEM.EncryptPrivateKey(SchemeName, CurveName, sk, passphrase, cfg.KDFIterations, cfg.Random)
+}
+
+// MarshalPublicKeyPEM 将公钥编码为不加密的 PEM
+func (s *Signer) MarshalPublicKeyPEM() ([]byte, error) {
+	if s.PublicKey == nil {
+		return nil, ErrNilPublicKey
+	}
+	return KeyPEM.EncodePublicKey(SchemeName, CurveName, encodePoint(s.PublicKey)), nil
+}
+
+// ParsePrivateKeyPEM 使用口令解密 PEM 编码的私钥，并重新计算公钥得到完整的 Signer
+func ParsePrivateKeyPEM(data, passphrase []byte) (*Signer, error) {
+	b, err := KeyPEM.DecryptPrivateKey(data, SchemeName, CurveName, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(b)
+}
+
+// ParsePrivateKey 解析定长 32 字节的大端序私钥，即 PEM 中加密的内容，并重新计算公钥得到完整的 Signer
+func ParsePrivateKey(b []byte) (*Signer, error) {
+	sk := new(big.Int).SetBytes(b)
+	if len(b) != 32 || sk.Sign() == 0 || sk.Cmp(bn256.Order) >= 0 {
+		return nil, fmt.Errorf("%w: 私钥超出 Z_q 范围", ErrInvalidEncoding)
+	}
+	return &Signer{PrivateKey: sk, PublicKey: new(bn256.G1).ScalarBaseMult(sk)}, nil
+}
+
+// ParsePublicKeyPEM 解析 PEM 编码的公钥，拒绝无效点与无穷远点
+func ParsePublicKeyPEM(data []byte) (*bn256.G1, error) {
+	b, err := KeyPEM.DecodePublicKey(data, SchemeName, CurveName)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(b)
+}
+
+// ParsePublicKey 解析 PointSize 字节的公钥，编码与签名中的点相同，拒绝无效点与无穷远点
+func ParsePublicKey(b []byte) (*bn256.G1, error) {
+	if len(b) != PointSize {
+		return nil, fmt.Errorf("%w: 公钥长度为 %d，期望 %d", ErrInvalidEncoding, len(b), PointSize)
+	}
+	pk, _, err := readPoint(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidatePoint(pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// MarshalPublicKey 将公钥编码为 PointSize 字节，即 ParsePublicKey 接受的格式
+func MarshalPublicKey(pk *bn256.G1) []byte {
+	return encodePoint(pk)
+}
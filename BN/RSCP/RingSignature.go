@@ -1,61 +1,227 @@
 package RSCP
 
 import (
-	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/google"
+	bn256 "BRFL/BN/BN256"
+	"context"
+	"fmt"
 	"math/big"
 )
 
-func Verify(Message []byte, PKList []*bn256.G1, SignerResult *Sigma) bool {
+// Verify 验证环签名，签名合法时返回 true
+func Verify(Message []byte, PKList []*bn256.G1, SignerResult *Sigma, opts ...Option) bool {
+	return VerifyDetailed(Message, PKList, SignerResult, opts...) == nil
+}
+
+// VerifyDetailed 验证环签名，签名合法时返回 nil，否则返回拒绝的原因
+// 对于格式错误的输入只返回错误，不会 panic；旧版编码的签名需通过 WithLegacyTranscript 显式允许
+func VerifyDetailed(Message []byte, PKList []*bn256.G1, SignerResult *Sigma, opts ...Option) error {
+	return VerifyContext(context.Background(), Message, PKList, SignerResult, opts...)
+}
+
+// VerifyContext 与 VerifyDetailed 相同，但可通过 ctx 取消，取消时返回 ctx.Err()
+// WithWorkers 指定的协程数用于 H_i 的计算与环求和
+func VerifyContext(ctx context.Context, Message []byte, PKList []*bn256.G1, SignerResult *Sigma, opts ...Option) error {
+	// 0. 校验公钥环
+	rc, err := newRingContext(ctx, PKList)
+	if err != nil {
+		return err
+	}
+	return verifyWithRing(ctx, Message, rc, SignerResult, NewConfig(opts...))
+}
+
+// VerifyWithRing 使用预先构造的 RingContext 验证环签名，签名合法时返回 true
+func VerifyWithRing(Message []byte, rc *RingContext, SignerResult *Sigma, opts ...Option) bool {
+	return VerifyDetailedWithRing(Message, rc, SignerResult, opts...) == nil
+}
+
+// VerifyDetailedWithRing 使用预先构造的 RingContext 验证环签名，省去每次校验公钥环的开销
+func VerifyDetailedWithRing(Message []byte, rc *RingContext, SignerResult *Sigma, opts ...Option) error {
+	return verifyWithRing(context.Background(), Message, rc, SignerResult, NewConfig(opts...))
+}
+
+// verifyWithRing 验证的实现，H_i 的计算与环求和由 cfg.Workers 个协程完成，ctx 被取消时返回 ctx.Err()
+func verifyWithRing(ctx context.Context, Message []byte, rc *RingContext, SignerResult *Sigma, cfg *Config) error {
+	// 0. 校验签名结构、转录版本与可链接模式
+	if err := checkVerifyInput(cfg, rc, SignerResult); err != nil {
+		return err
+	}
 
 	// 1. 计算 Hi 列表
-	HiList := make([]*big.Int, len(PKList))
-	for i, v := range SignerResult.UI {
-		HiList[i] = HashToZq(v, Message, PKList)
+	tr := sigmaTranscript(Message, rc, SignerResult)
+	HiList, err := tr.hiList(ctx, cfg.Workers, SignerResult)
+	if err != nil {
+		return err
+	}
+	sum, err := rc.computeSumContext(ctx, cfg.Workers, HiList, SignerResult.UI, -1)
+	if err != nil {
+		return err
+	}
+
+	// 2. 验证 e(P, V) = e(Sum, Q)
+	if !VerifyPairing(sum, SignerResult.V) {
+		return ErrPairingMismatch
+	}
+
+	// 3. 可链接签名还需验证 e(H_p(Scope), V) = e(\sum_i (W_i + H_i \cdot Tag), Q)
+	if SignerResult.Tag != nil {
+		return verifyTag(SignerResult, HiList)
 	}
+	return nil
+}
 
-	return VerifyPairing(ComputeSum(HiList, PKList, SignerResult.UI, -1), SignerResult.V)
+// hiList 计算签名中全部成员的 H_i：门限签名的 H_i 由同一个挑战值与多项式导出，其余签名由 workers 个协程逐个调用 hiAt
+func (t *transcript) hiList(ctx context.Context, workers int, SignerResult *Sigma) ([]*big.Int, error) {
+	if SignerResult.Threshold > 0 {
+		return t.thresholdHi(SignerResult.Threshold, SignerResult.UI, SignerResult.F), ctx.Err()
+	}
+	HiList := make([]*big.Int, len(SignerResult.UI))
+	err := parallelFor(ctx, workers, len(HiList), func(i int) {
+		HiList[i] = t.hiAt(SignerResult, i)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return HiList, nil
 }
 
 // Sign 签名函数
-func Sign(Message []byte, PKList []*bn256.G1, SignerS *Signer) (SignResult *Sigma) {
+// 若签名者不在环中、环为空、环中存在 nil 或重复公钥，则返回对应错误
+// 可通过 WithRandom 指定随机数来源，读取失败时返回错误；通过 WithDeterministic 启用确定性签名
+func Sign(Message []byte, PKList []*bn256.G1, SignerS *Signer, opts ...Option) (SignResult *Sigma, err error) {
+	return SignContext(context.Background(), Message, PKList, SignerS, opts...)
+}
+
+// SignContext 与 Sign 相同，但可通过 ctx 取消，取消时返回 ctx.Err()
+// WithWorkers 指定的协程数用于 U_i 的生成、H_i 的计算与环求和；随机数仍按顺序读取，签名结果与协程数无关
+func SignContext(ctx context.Context, Message []byte, PKList []*bn256.G1, SignerS *Signer, opts ...Option) (*Sigma, error) {
+	// 0. 校验公钥环
+	rc, err := newRingContext(ctx, PKList)
+	if err != nil {
+		return nil, err
+	}
+	return signWithRing(ctx, Message, rc, SignerS, NewConfig(opts...))
+}
+
+// SignWithRing 使用预先构造的 RingContext 签名，省去每次校验公钥环与线性查找签名者的开销
+func SignWithRing(Message []byte, rc *RingContext, SignerS *Signer, opts ...Option) (*Sigma, error) {
+	return signWithRing(context.Background(), Message, rc, SignerS, NewConfig(opts...))
+}
+
+// signWithRing 签名的实现，cfg 为已应用全部选项的配置
+func signWithRing(ctx context.Context, Message []byte, rc *RingContext, SignerS *Signer, cfg *Config) (*Sigma, error) {
+	PKList := rc.pkList
+
+	// 0. 找到签名者公钥在 PKList 中的下标
+	flag, err := rc.FindSigner(SignerS)
+	if err != nil {
+		return nil, err
+	}
+	version := cfg.Transcript
+	if !version.Supported() {
+		return nil, fmt.Errorf("%w: 版本 %d", ErrUnsupportedTranscript, version)
+	}
+	if cfg.Linkable && version != TranscriptV2 {
+		return nil, fmt.Errorf("%w: 可链接模式须使用 v2 转录编码", ErrUnsupportedTranscript)
+	}
+
+	// 确定性模式下，所有随机量均由 HMAC-DRBG 派生
+	if cfg.Deterministic {
+		cfg.Random = NewNonceReader(SignerS.PrivateKey, Message, PKList, cfg)
+	}
+	tr := newTranscript(version, Message, rc)
+
+	// 可链接模式下计算 B = H_p(Scope) 与标签 Tag = sk_s \cdot B，之后的 H_i 绑定范围与标签
+	var B, Tag *bn256.G1
+	var WiList []*bn256.G1
+	if cfg.Linkable {
+		B = HashToPointG1(cfg.Scope)
+		Tag = ScalarMulG1(B, SignerS.PrivateKey)
+		Tag.Marshal()
+		tr.bindTag(cfg.Scope, Tag)
+		WiList = make([]*bn256.G1, len(PKList))
+	}
 
-	var flag int // 记住公钥位置下标
 	UiList := make([]*bn256.G1, len(PKList))
 	HiList := make([]*big.Int, len(PKList))
 
 	// 1、除了 i=s 以外，选择随机的 U_i 属于 G1
-
-	for i, v := range PKList {
-		if CompareG1(v, SignerS.PublicKey) {
-			flag = i
+	// 随机数按成员顺序串行读取，保证签名结果与协程数无关
+	ks := make([]*big.Int, len(PKList))
+	for i := range ks {
+		if i == flag {
 			continue
 		}
-		Ui := RandomPointG1()
-		UiList[i] = Ui
+		ks[i], err = RandomZqFrom(cfg.Random)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// 可链接模式下再为其他成员读取 W_i = w_i \cdot B 的随机数
+	var ws []*big.Int
+	if cfg.Linkable {
+		ws = make([]*big.Int, len(PKList))
+		for i := range ws {
+			if i == flag {
+				continue
+			}
+			if ws[i], err = RandomZqFrom(cfg.Random); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	for i, v := range PKList {
-		if CompareG1(v, SignerS.PublicKey) {
-			continue
+	// 2. 计算 H_i，点乘与哈希交由 cfg.Workers 个协程完成
+	err = parallelFor(ctx, cfg.Workers, len(PKList), func(i int) {
+		if i == flag {
+			return
+		}
+		UiList[i] = new(bn256.G1).ScalarBaseMult(ks[i])
+		if cfg.Linkable {
+			WiList[i] = ScalarMulG1(B, ws[i])
+			HiList[i] = tr.HiLink(UiList[i], WiList[i])
+			return
 		}
-		HiList[i] = HashToZq(UiList[i], Message, PKList)
+		HiList[i] = tr.Hi(UiList[i])
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 3. 生成随机数 $r$
-	r := RandomZq()
+	r, err := RandomZqFrom(cfg.Random)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := rc.computeSumContext(ctx, cfg.Workers, HiList, UiList, flag)
+	if err != nil {
+		return nil, err
+	}
 
-	// 4. 计算 US
-	US := ComputeUS(r, HiList, PKList, UiList, flag)
+	// 4. 计算 U_s = r \cdot P - \sum_{i \ne s}(U_i + H_i \cdot pk_i)，与 ComputeUS 相同，环求和可使用预计算表
+	US := SubG1(new(bn256.G1).ScalarBaseMult(r), sum)
 	UiList[flag] = US
 
-	// 5. 计算 hS
-	hS := HashToZq(US, Message, PKList)
+	// 5. 计算 hS，可链接模式下先计算 W_s = r \cdot B - \sum_{i \ne s}(W_i + H_i \cdot Tag)
+	var hS *big.Int
+	if cfg.Linkable {
+		WiList[flag] = SubG1(ScalarMulG1(B, r), sumW(WiList, HiList, Tag, flag))
+		hS = tr.HiLink(US, WiList[flag])
+	} else {
+		hS = tr.Hi(US)
+	}
 
 	// 6. 计算 V
 	V := ComputeV(r, hS, SignerS.PrivateKey)
 
-	return &Sigma{
+	sigma := &Sigma{
 		UI: UiList,
 		V:  V,
+
+		Version: version,
+	}
+	if cfg.Linkable {
+		sigma.Scope, sigma.Tag, sigma.WI = cfg.Scope, Tag, WiList
 	}
+	return sigma, nil
 }
@@ -1,20 +1,81 @@
 package RSCP
 
 import (
+	bn256 "BRFL/BN/BN256"
+	"BRFL/KeyPEM"
 	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
 	"fmt"
-	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/google"
+	"io"
+	"math"
 	"math/big"
+	"runtime"
 )
 
 // -------------------- 全局参数 --------------------
 
+// NonceDomain 确定性签名模式下 HMAC-DRBG 的个性化字符串
+const NonceDomain = "RSCP/BN254/nonce/v1"
+
+// HashDomain v1 转录编码的域分离前缀，与具体哈希用途的标签拼接后作为完整的域标签
+const HashDomain = "RSCP/BN254/hash/v1/"
+
+// HashDomainV2 v2 转录编码的域分离前缀
+const HashDomainV2 = "RSCP/BN254/hash/v2/"
+
+// 各哈希用途的域分离标签
+const (
+	// TagHi 计算 H_i（包括签名者的 h_s）时使用的标签
+	TagHi = "H_i"
+	// TagHp 可链接模式下把范围字符串哈希到 G1 时使用的标签
+	TagHp = "H_p"
+	// TagHiLink 可链接签名计算 H_i 时使用的标签，H_i 同时绑定范围、标签与 W_i
+	TagHiLink = "H_i/link"
+	// TagThreshold 门限签名计算挑战值 c = f(0) 时使用的标签
+	TagThreshold = "threshold"
+	// TagThresholdKey 门限签名计算公钥系数 a_i 时使用的标签
+	TagThresholdKey = "threshold/key"
+)
+
+// TranscriptVersion 哈希转录编码的版本
+type TranscriptVersion uint8
+
+const (
+	// TranscriptLegacy 旧版编码：直接拼接原始字节，仅用于兼容已有签名
+	TranscriptLegacy TranscriptVersion = 0
+	// TranscriptV1 带域分离标签、类型与长度前缀、定长标量的编码
+	TranscriptV1 TranscriptVersion = 1
+	// TranscriptV2 与 v1 编码规则相同，但先把消息与公钥环压缩为定长的上下文摘要，使每个 H_i 的计算与环大小无关
+	TranscriptV2 TranscriptVersion = 2
+)
+
+// Supported 判断当前实现是否支持该转录编码版本
+func (v TranscriptVersion) Supported() bool {
+	return v == TranscriptLegacy || v == TranscriptV1 || v == TranscriptV2
+}
+
 // Sigma 签名结果结构体
 type Sigma struct {
 	UI []*bn256.G1
 	V  *bn256.G2
+	// Version 计算签名时使用的哈希转录编码版本
+	Version TranscriptVersion
+
+	// Scope 可链接签名的范围字符串，同一私钥在同一范围内的签名具有相同的 Tag
+	Scope []byte
+	// Tag 可链接签名的标签 sk \cdot H_p(Scope)；非可链接签名为 nil
+	Tag *bn256.G1
+	// WI 与 U_i 一一对应、以 H_p(Scope) 为基的辅助量，用于证明 Tag 与环一致
+	WI []*bn256.G1
+
+	// Threshold 门限签名的门限 t，即至少有 t 个不同的成员参与了签名；普通签名为 0
+	Threshold int
+	// F 门限签名中多项式 f 的系数 F_1, ..., F_{n-t}，f(0) 由 U_i 哈希得到
+	F []*big.Int
 }
 
 // Signer 签名者结构体
@@ -23,6 +84,176 @@ type Signer struct {
 	PublicKey  *bn256.G1
 }
 
+// -------------------- 错误定义 --------------------
+
+var (
+	// ErrEmptyRing 环中没有任何公钥
+	ErrEmptyRing = errors.New("环为空")
+	// ErrNilPublicKey 环中存在 nil 公钥
+	ErrNilPublicKey = errors.New("环中存在 nil 公钥")
+	// ErrDuplicatePublicKey 环中存在重复的公钥
+	ErrDuplicatePublicKey = errors.New("环中存在重复的公钥")
+	// ErrNilSigner 签名者或其密钥为 nil
+	ErrNilSigner = errors.New("签名者密钥为空")
+	// ErrSignerNotInRing 签名者公钥不在环中
+	ErrSignerNotInRing = errors.New("签名者公钥不在环中")
+	// ErrKeyMismatch 签名者的公钥不等于 PrivateKey \cdot P
+	ErrKeyMismatch = errors.New("签名者私钥与公钥不匹配")
+	// ErrRandomSource 从随机数来源读取失败
+	ErrRandomSource = errors.New("读取随机数来源失败")
+	// ErrRingSizeMismatch 签名中 U_i 的个数与公钥环大小不一致
+	ErrRingSizeMismatch = errors.New("签名与公钥环大小不一致")
+	// ErrMalformedSignature 签名结构不完整或取值非法
+	ErrMalformedSignature = errors.New("签名格式错误")
+	// ErrUnsupportedTranscript 哈希转录编码版本未知或未被允许
+	ErrUnsupportedTranscript = errors.New("不支持的哈希转录编码版本")
+	// ErrInvalidEncoding 签名、公钥或公钥环的编码格式错误
+	ErrInvalidEncoding = errors.New("编码格式错误")
+	// ErrInvalidPoint 点不在曲线上或不属于素数阶子群
+	ErrInvalidPoint = errors.New("点不在曲线上或不属于素数阶子群")
+	// ErrIdentityPoint 公钥或签名中出现了不允许的无穷远点
+	ErrIdentityPoint = errors.New("点为无穷远点")
+	// ErrPairingMismatch 配对等式 e(P, V) = e(Sum, Q) 不成立
+	ErrPairingMismatch = errors.New("配对校验失败")
+	// ErrBatchLengthMismatch 批量验证时消息、公钥环与签名的个数不一致
+	ErrBatchLengthMismatch = errors.New("批量验证的输入个数不一致")
+	// ErrNotLinkable 要求可链接签名，但签名中没有标签
+	ErrNotLinkable = errors.New("签名不是可链接签名")
+	// ErrScopeMismatch 可链接签名的范围与验证时要求的范围不同
+	ErrScopeMismatch = errors.New("可链接签名的范围不符")
+	// ErrTagMismatch 配对等式 e(H_p(Scope), V) = e(\sum_i (W_i + H_i \cdot Tag), Q) 不成立
+	ErrTagMismatch = errors.New("标签的配对校验失败")
+	// ErrInvalidThreshold 门限为 0、超过环大小，或承诺的下标越界、重复
+	ErrInvalidThreshold = errors.New("门限或承诺非法")
+	// ErrThresholdTooLow 签名的门限低于验证时要求的门限
+	ErrThresholdTooLow = errors.New("签名的门限低于要求")
+	// ErrThresholdOption 门限签名不支持确定性模式与可链接模式
+	ErrThresholdOption = errors.New("门限签名不支持确定性模式与可链接模式")
+	// ErrNonceUsed 门限签名的一次性随机数为 nil 或已被使用
+	ErrNonceUsed = errors.New("一次性随机数已被使用")
+	// ErrSessionMismatch 门限签名会话不完整，或不包含签名者的承诺
+	ErrSessionMismatch = errors.New("门限签名会话与承诺不符")
+	// ErrInvalidContribution 签名者的贡献缺失、重复或校验失败
+	ErrInvalidContribution = errors.New("签名者的贡献校验失败")
+)
+
+// -------------------- 可选参数 --------------------
+
+// Config 保存 NewSigner、NewRingContext、Sign、Verify 与密钥导出使用的可选配置
+type Config struct {
+	// Random 随机数来源，默认为 crypto/rand.Reader
+	Random io.Reader
+	// Deterministic 为 true 时，Sign 中的所有随机量由 HMAC-DRBG 确定性派生，不再读取 Random
+	Deterministic bool
+	// ExtraEntropy 确定性模式下额外混入 HMAC-DRBG 的熵，可为空
+	ExtraEntropy []byte
+	// Transcript Sign 使用的哈希转录编码版本，默认为 TranscriptV2
+	Transcript TranscriptVersion
+	// AllowLegacy 为 true 时，Verify 接受使用 TranscriptLegacy 编码的旧签名
+	AllowLegacy bool
+	// KDFIterations 加密私钥 PEM 时 PBKDF2 的迭代次数，默认为 KeyPEM.DefaultIterations
+	KDFIterations int
+	// Precompute 为 true 时，NewRingContext 为每个公钥构造定点预计算表
+	Precompute bool
+	// Workers SignContext 与 VerifyContext 处理环成员时使用的协程数，默认为 1
+	Workers int
+	// Linkable 为 true 时签名附带范围 Scope 下的标签，验证时要求签名是同一范围下的可链接签名
+	Linkable bool
+	// Scope 可链接模式的范围字符串，例如投票或训练轮次的标识
+	Scope []byte
+	// Threshold 验证时要求的最小门限，普通签名视为门限 1；为 0 时不检查
+	Threshold int
+}
+
+// Option 以函数式选项的方式修改 Config
+type Option func(*Config)
+
+// WithRandom 指定生成密钥与签名时使用的随机数来源
+func WithRandom(r io.Reader) Option {
+	return func(c *Config) {
+		c.Random = r
+	}
+}
+
+// WithDeterministic 启用确定性签名模式（类似 RFC 6979）
+// 随机量由私钥、消息与公钥环派生，extra 不为空时作为额外熵混入
+func WithDeterministic(extra []byte) Option {
+	return func(c *Config) {
+		c.Deterministic = true
+		c.ExtraEntropy = extra
+	}
+}
+
+// WithTranscript 指定 Sign 使用的哈希转录编码版本
+func WithTranscript(v TranscriptVersion) Option {
+	return func(c *Config) {
+		c.Transcript = v
+	}
+}
+
+// WithLegacyTranscript 允许 Verify 接受旧版编码的签名
+func WithLegacyTranscript() Option {
+	return func(c *Config) {
+		c.AllowLegacy = true
+	}
+}
+
+// WithKDFIterations 指定加密私钥 PEM 时 PBKDF2 的迭代次数
+func WithKDFIterations(n int) Option {
+	return func(c *Config) {
+		c.KDFIterations = n
+	}
+}
+
+// WithPrecompute 令 NewRingContext 为每个公钥构造定点预计算表，适用于同一个环上的大量签名与验证
+func WithPrecompute() Option {
+	return func(c *Config) {
+		c.Precompute = true
+	}
+}
+
+// WithWorkers 指定处理环成员时使用的协程数，n <= 0 时使用 runtime.GOMAXPROCS(0)
+func WithWorkers(n int) Option {
+	return func(c *Config) {
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		c.Workers = n
+	}
+}
+
+// WithScope 启用范围为 scope 的可链接模式：签名附带标签，验证时拒绝不带标签或范围不同的签名，需使用 TranscriptV2
+func WithScope(scope []byte) Option {
+	return func(c *Config) {
+		c.Linkable = true
+		c.Scope = append([]byte{}, scope...)
+	}
+}
+
+// WithThreshold 要求签名至少由 t 个不同的成员参与，拒绝门限更低的门限签名；t > 1 时拒绝普通签名
+func WithThreshold(t int) Option {
+	return func(c *Config) {
+		c.Threshold = t
+	}
+}
+
+// NewConfig 以默认配置为基础依次应用 opts
+func NewConfig(opts ...Option) *Config {
+	c := &Config{Random: rand.Reader, Transcript: TranscriptV2, KDFIterations: KeyPEM.DefaultIterations, Workers: 1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// AcceptsTranscript 判断 Verify 是否接受版本为 v 的签名
+func (c *Config) AcceptsTranscript(v TranscriptVersion) bool {
+	if v == TranscriptLegacy {
+		return c.AllowLegacy
+	}
+	return v.Supported()
+}
+
 // -------------------- 工具函数 --------------------
 
 // SubG1 计算两个 G1 群元素 p1 和 p2 的差值 p1 - p2，等价于 p1 + (-p2)
@@ -41,10 +272,90 @@ func ScalarMulG1(p *bn256.G1, k *big.Int) *bn256.G1 {
 	return new(bn256.G1).ScalarMult(p, k)
 }
 
+// MultiScalarMulG1 计算多标量乘法 \sum_i k_i \cdot p_i，points 与 scalars 的长度须一致
+// 采用 Pippenger 分桶算法：标量按 c 位切分为窗口，窗口内把点累加进对应的桶，再用前缀和合并各桶
+func MultiScalarMulG1(points []*bn256.G1, scalars []*big.Int) *bn256.G1 {
+	if len(points) != len(scalars) {
+		panic("MultiScalarMulG1: points 与 scalars 长度不一致")
+	}
+	// bn256 的 Add 在两个参数相等且与结果共用内存时会得到错误的倍点，因此这里一律使用 AddG1 返回新对象
+	zero := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+	result := zero
+
+	// 1. 标量约减到 [0, Order)，窗口宽度 c 随点数增长，约为 ln(n)
+	ks := make([]*big.Int, len(scalars))
+	for i, k := range scalars {
+		if k.Sign() < 0 || k.Cmp(bn256.Order) >= 0 {
+			k = new(big.Int).Mod(k, bn256.Order)
+		}
+		ks[i] = k
+	}
+	c := msmWindow(len(points))
+
+	// 2. 从最高窗口开始处理，每个窗口开始前先把结果乘以 2^c
+	buckets := make([]*bn256.G1, 1<<c-1)
+	for w := (bn256.Order.BitLen()+c-1)/c - 1; w >= 0; w-- {
+		for j := 0; j < c; j++ {
+			result = AddG1(result, result)
+		}
+
+		for i := range buckets {
+			buckets[i] = nil
+		}
+		for i, k := range ks {
+			idx := 0
+			for j := c - 1; j >= 0; j-- {
+				idx = idx<<1 | int(k.Bit(w*c+j))
+			}
+			if idx == 0 {
+				continue
+			}
+			if buckets[idx-1] == nil {
+				buckets[idx-1] = points[i]
+			} else {
+				buckets[idx-1] = AddG1(buckets[idx-1], points[i])
+			}
+		}
+
+		// 3. \sum_j j \cdot B_j 通过从高到低的前缀和计算
+		sum, acc := zero, zero
+		for i := len(buckets) - 1; i >= 0; i-- {
+			if buckets[i] != nil {
+				sum = AddG1(sum, buckets[i])
+			}
+			acc = AddG1(acc, sum)
+		}
+		result = AddG1(result, acc)
+	}
+	return result
+}
+
+// msmWindow 根据点数选择 Pippenger 的窗口宽度
+func msmWindow(n int) int {
+	if n < 32 {
+		return 3
+	}
+	return int(math.Ceil(math.Log(float64(n))))
+}
+
+// RandomPointG1From 从随机数来源 r 中随机生成一个 G1 群元素。实现：随机标量 k * G
+func RandomPointG1From(r io.Reader) (*bn256.G1, error) {
+	k, err := RandomZqFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).ScalarBaseMult(k), nil
+}
+
 // RandomPointG1 随机生成一个 G1 群元素，返回点。实现：随机标量 k * G
 func RandomPointG1() *bn256.G1 {
-	k := RandomZq()
-	return new(bn256.G1).ScalarBaseMult(k)
+	return new(bn256.G1).ScalarBaseMult(RandomZq())
+}
+
+// MulZq 计算两个有限域标量 a 和 b 的乘积，并对 Order 取模
+func MulZq(a, b *big.Int) *big.Int {
+	prod := new(big.Int).Mul(a, b)
+	return prod.Mod(prod, bn256.Order)
 }
 
 // CompareG1 比较两个 G1 群元素在字节序列上的相等性，如果完全相同则返回 true
@@ -54,30 +365,45 @@ func CompareG1(p1, p2 *bn256.G1) bool {
 	return bytes.Equal(b1, b2)
 }
 
-// RandomZq 在 Zq* 的有限域内取一个随机数
+// RandomZqFrom 从随机数来源 r 中在 Zq* 的有限域内取一个随机数
+func RandomZqFrom(r io.Reader) (*big.Int, error) {
+	k, err := rand.Int(r, bn256.Order)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRandomSource, err)
+	}
+	return k, nil
+}
+
+// RandomZq 在 Zq* 的有限域内取一个随机数，随机数来源为 crypto/rand.Reader
 func RandomZq() *big.Int {
-	k, err := rand.Int(rand.Reader, bn256.Order)
+	k, err := RandomZqFrom(rand.Reader)
 	if err != nil {
-		fmt.Println("在 Zq* 的有限域内取随机数失败：", err)
-		return nil
+		panic(fmt.Sprintf("在 Zq* 的有限域内取随机数失败：%v", err))
 	}
 	return k
 }
 
 // NewSigner 用于系统中生成 Signer (sk_i, pk_i)
-func NewSigner() *Signer {
+// 可通过 WithRandom 指定随机数来源，读取失败时返回错误
+func NewSigner(opts ...Option) (*Signer, error) {
+	cfg := NewConfig(opts...)
+
 	// 1. 随机生成私钥标量
-	privateKey := RandomZq()
+	privateKey, err := RandomZqFrom(cfg.Random)
+	if err != nil {
+		return nil, err
+	}
 
 	// 2. 通过基点做标量乘法得到公钥
 	pub := new(bn256.G1).ScalarBaseMult(privateKey)
 	return &Signer{
 		PrivateKey: privateKey,
 		PublicKey:  pub,
-	}
+	}, nil
 }
 
 // HashToZq 将任意若干字节切片拼接做 SHA256，然后结果映射到 Z_q
+// 这是 TranscriptLegacy 使用的旧版编码，新签名请使用 HashTranscript
 func HashToZq(args ...interface{}) *big.Int {
 	// 初始化拼接的字节数组
 	var concatenated []byte
@@ -119,3 +445,138 @@ func HashToZq(args ...interface{}) *big.Int {
 
 	return hashMod
 }
+
+// HashTranscript 按转录编码版本 version 计算哈希并映射到 Z_q，tag 为哈希用途的域分离标签
+func HashTranscript(version TranscriptVersion, tag string, args ...interface{}) *big.Int {
+	switch version {
+	case TranscriptLegacy:
+		return HashToZq(args...)
+	case TranscriptV1:
+		return HashToZqV1(tag, args...)
+	default:
+		return HashToZqV2(tag, args...)
+	}
+}
+
+// HashToZqV1 使用 v1 转录编码计算哈希并映射到 Z_q
+// 编码以 HashDomain + tag 开头，每个参数带类型字节，变长数据带长度前缀，标量编码为定长 32 字节；
+// 对 SHA-512 的 64 字节输出取模，使结果在 Z_q 上近似均匀
+func HashToZqV1(tag string, args ...interface{}) *big.Int {
+	return hashToZq(HashDomain, tag, args)
+}
+
+// HashToZqV2 使用 v2 转录编码计算哈希并映射到 Z_q，参数编码与 v1 相同，仅域分离前缀不同
+func HashToZqV2(tag string, args ...interface{}) *big.Int {
+	return hashToZq(HashDomainV2, tag, args)
+}
+
+// hashToZq 以 domain + tag 为域分离标签写入各参数，对 SHA-512 的输出取模
+func hashToZq(domain, tag string, args []interface{}) *big.Int {
+	buf := appendTranscript(appendBytes(nil, []byte(domain+tag)), args...)
+	hash := sha512.Sum512(buf)
+	hashInt := new(big.Int).SetBytes(hash[:])
+	return hashInt.Mod(hashInt, bn256.Order)
+}
+
+// appendTranscript 依次写入各参数：每个参数带类型字节，变长数据带长度前缀，标量编码为定长 32 字节
+func appendTranscript(buf []byte, args ...interface{}) []byte {
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case []byte:
+			buf = append(buf, 0x01)
+			buf = appendBytes(buf, v)
+		case *bn256.G1:
+			buf = append(buf, 0x02)
+			buf = append(buf, v.Marshal()...)
+		case []*bn256.G1:
+			buf = append(buf, 0x03)
+			buf = binary.BigEndian.AppendUint64(buf, uint64(len(v)))
+			for _, p := range v {
+				buf = append(buf, p.Marshal()...)
+			}
+		case *big.Int:
+			buf = append(buf, 0x04)
+			buf = appendScalar(buf, v)
+		case []*big.Int:
+			buf = append(buf, 0x05)
+			buf = binary.BigEndian.AppendUint64(buf, uint64(len(v)))
+			for _, bi := range v {
+				buf = appendScalar(buf, bi)
+			}
+		default:
+			panic(fmt.Sprintf("不支持的类型: %T", v))
+		}
+	}
+	return buf
+}
+
+// RingDigest 计算公钥环的定长摘要，即对 HashDomainV2 + "ring" 与转录编码的 PKList 做 SHA-256
+func RingDigest(PKList []*bn256.G1) []byte {
+	return ringDigest(appendTranscript(nil, PKList))
+}
+
+// ringDigest 由公钥环的转录编码计算环摘要
+func ringDigest(encoding []byte) []byte {
+	digest := sha256.Sum256(append(appendBytes(nil, []byte(HashDomainV2+"ring")), encoding...))
+	return digest[:]
+}
+
+// transcript 一次签名或验证中复用的哈希上下文
+// v2 编码预先把消息与公钥环压缩为定长摘要，之后每个哈希只需写入摘要；旧版与 v1 编码仍按原样拼接
+type transcript struct {
+	version TranscriptVersion
+	Message []byte
+	PKList  []*bn256.G1
+	context []byte
+	// scope、tag 可链接签名的范围与标签，由 bindTag 设置
+	scope []byte
+	tag   *bn256.G1
+}
+
+// newTranscript 构造哈希上下文，v2 编码下只在这里遍历一次消息，公钥环的摘要取自 rc
+func newTranscript(version TranscriptVersion, Message []byte, rc *RingContext) *transcript {
+	t := &transcript{version: version, Message: Message, PKList: rc.pkList}
+	if version == TranscriptV2 {
+		buf := appendTranscript(appendBytes(nil, []byte(HashDomainV2+"context")), Message, rc.digest)
+		digest := sha256.Sum256(buf)
+		t.context = digest[:]
+	}
+	return t
+}
+
+// Hi 计算 U 对应的 H_i
+func (t *transcript) Hi(U *bn256.G1) *big.Int {
+	if t.version == TranscriptV2 {
+		return HashToZqV2(TagHi, t.context, U)
+	}
+	return HashTranscript(t.version, TagHi, U, t.Message, t.PKList)
+}
+
+// bindTag 令之后的 HiLink 绑定可链接签名的范围与标签
+func (t *transcript) bindTag(scope []byte, tag *bn256.G1) {
+	t.scope, t.tag = scope, tag
+}
+
+// HiLink 计算可链接签名中 (U, W) 对应的 H_i = H(context, scope, tag, U, W)，只用于 v2 编码
+func (t *transcript) HiLink(U, W *bn256.G1) *big.Int {
+	return HashToZqV2(TagHiLink, t.context, t.scope, t.tag, U, W)
+}
+
+// hiAt 计算签名中第 i 个成员的 H_i，可链接签名使用 HiLink
+func (t *transcript) hiAt(SignerResult *Sigma, i int) *big.Int {
+	if SignerResult.Tag != nil {
+		return t.HiLink(SignerResult.UI[i], SignerResult.WI[i])
+	}
+	return t.Hi(SignerResult.UI[i])
+}
+
+// appendBytes 以 8 字节大端长度前缀写入变长字节串
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// appendScalar 将标量对 Order 取模后编码为定长 32 字节写入
+func appendScalar(buf []byte, k *big.Int) []byte {
+	return append(buf, new(big.Int).Mod(k, bn256.Order).FillBytes(make([]byte, 32))...)
+}
@@ -0,0 +1,87 @@
+package DRBG
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+// MaxBytesPerRead 单次 Read 可生成的最大字节数（SP 800-90A 规定单次请求不超过 2^19 比特）
+const MaxBytesPerRead = 1 << 16
+
+// ErrRequestTooLarge 单次读取的字节数超过 MaxBytesPerRead
+var ErrRequestTooLarge = errors.New("单次读取的字节数过大")
+
+// HmacDRBG 基于 HMAC-SHA256 的确定性随机比特生成器（NIST SP 800-90A），实现 io.Reader
+// 相同的种子总是产生相同的输出序列，不可并发使用
+type HmacDRBG struct {
+	k []byte
+	v []byte
+}
+
+// New 以 entropy || nonce || personalization 为种子材料实例化 HMAC-DRBG
+func New(entropy, nonce, personalization []byte) *HmacDRBG {
+	d := &HmacDRBG{
+		k: make([]byte, sha256.Size),
+		v: make([]byte, sha256.Size),
+	}
+	// K = 0x00...00，V = 0x01...01
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+
+	seed := make([]byte, 0, len(entropy)+len(nonce)+len(personalization))
+	seed = append(seed, entropy...)
+	seed = append(seed, nonce...)
+	seed = append(seed, personalization...)
+	d.update(seed)
+	return d
+}
+
+// Reseed 向内部状态混入新的熵
+func (d *HmacDRBG) Reseed(entropy, additional []byte) {
+	seed := make([]byte, 0, len(entropy)+len(additional))
+	seed = append(seed, entropy...)
+	seed = append(seed, additional...)
+	d.update(seed)
+}
+
+// Read 生成 len(p) 字节的伪随机输出，每次调用结束后更新内部状态
+func (d *HmacDRBG) Read(p []byte) (n int, err error) {
+	if len(p) > MaxBytesPerRead {
+		return 0, ErrRequestTooLarge
+	}
+
+	// 1. 反复计算 V = HMAC(K, V) 并输出 V
+	for n < len(p) {
+		d.v = d.hmac(d.v)
+		n += copy(p[n:], d.v)
+	}
+
+	// 2. 更新内部状态，保证前向安全
+	d.update(nil)
+	return
+}
+
+// update 为 SP 800-90A 中的 HMAC_DRBG_Update
+func (d *HmacDRBG) update(data []byte) {
+	// 1. K = HMAC(K, V || 0x00 || data)，V = HMAC(K, V)
+	d.k = d.hmac(d.v, []byte{0x00}, data)
+	d.v = d.hmac(d.v)
+	if len(data) == 0 {
+		return
+	}
+
+	// 2. K = HMAC(K, V || 0x01 || data)，V = HMAC(K, V)
+	d.k = d.hmac(d.v, []byte{0x01}, data)
+	d.v = d.hmac(d.v)
+}
+
+// hmac 以当前 K 为密钥计算若干字节切片拼接后的 HMAC-SHA256
+func (d *HmacDRBG) hmac(parts ...[]byte) []byte {
+	mac := hmac.New(sha256.New, d.k)
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	return mac.Sum(nil)
+}
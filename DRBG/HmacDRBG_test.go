@@ -0,0 +1,42 @@
+package DRBG
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// 测试 HMAC-DRBG 的已知答案与确定性
+func TestHmacDRBG(t *testing.T) {
+	d := New([]byte("entropy"), []byte("nonce"), []byte("personalization"))
+
+	want := []string{
+		"cd488e81766ac7182374e96090b2fd44eb07e708008bc3912c2b6a8135d7484141c13eff11733b35",
+		"738a849f24da510efbd24d579fd4fbd4",
+	}
+	for i, w := range want {
+		out := make([]byte, len(w)/2)
+		if _, err := d.Read(out); err != nil {
+			t.Fatalf("第 %d 次读取失败: %v", i, err)
+		}
+		if hex.EncodeToString(out) != w {
+			t.Errorf("第 %d 次输出为 %x，期望 %s", i, out, w)
+		}
+	}
+
+	// 相同种子输出相同，不同种子输出不同
+	a, b, c := make([]byte, 64), make([]byte, 64), make([]byte, 64)
+	New([]byte("k"), []byte("n"), nil).Read(a)
+	New([]byte("k"), []byte("n"), nil).Read(b)
+	New([]byte("k"), []byte("m"), nil).Read(c)
+	if !bytes.Equal(a, b) {
+		t.Errorf("相同种子的输出不一致")
+	}
+	if bytes.Equal(a, c) {
+		t.Errorf("不同种子的输出相同")
+	}
+
+	if _, err := d.Read(make([]byte, MaxBytesPerRead+1)); err != ErrRequestTooLarge {
+		t.Errorf("期望错误 %v，实际为 %v", ErrRequestTooLarge, err)
+	}
+}
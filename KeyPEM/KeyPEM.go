@@ -0,0 +1,198 @@
+package KeyPEM
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// -------------------- 全局参数 --------------------
+
+const (
+	// PrivateKeyType 加密私钥 PEM 块的类型
+	PrivateKeyType = "RING SIGNATURE PRIVATE KEY"
+	// PublicKeyType 公钥 PEM 块的类型
+	PublicKeyType = "RING SIGNATURE PUBLIC KEY"
+
+	// DefaultIterations PBKDF2-HMAC-SHA256 的默认迭代次数
+	DefaultIterations = 600000
+	// MaxIterations 解密时接受的最大迭代次数，防止恶意文件消耗过多计算资源
+	MaxIterations = 10000000
+
+	kdfName    = "PBKDF2-HMAC-SHA256"
+	cipherName = "AES-256-GCM"
+	saltSize   = 16
+	keySize    = 32
+)
+
+// PEM 头部字段名
+const (
+	headerScheme     = "Scheme"
+	headerCurve      = "Curve"
+	headerKDF        = "KDF"
+	headerIterations = "Iterations"
+	headerSalt       = "Salt"
+	headerCipher     = "Cipher"
+	headerNonce      = "Nonce"
+)
+
+// -------------------- 错误定义 --------------------
+
+var (
+	// ErrInvalidPEM PEM 数据缺失或格式错误
+	ErrInvalidPEM = errors.New("PEM 格式错误")
+	// ErrSchemeMismatch PEM 头部中的方案或曲线与期望不符
+	ErrSchemeMismatch = errors.New("PEM 中的方案或曲线不匹配")
+	// ErrDecryption 口令错误或密文被篡改
+	ErrDecryption = errors.New("私钥解密失败")
+)
+
+// -------------------- 私钥 --------------------
+
+// EncryptPrivateKey 使用口令加密私钥 sk 并编码为 PEM
+// 密钥由 PBKDF2-HMAC-SHA256 从口令派生，使用 AES-256-GCM 加密，PEM 头部整体作为附加认证数据
+func EncryptPrivateKey(scheme, curve string, sk, passphrase []byte, iterations int, rand io.Reader) ([]byte, error) {
+	if iterations < 1 || iterations > MaxIterations {
+		return nil, fmt.Errorf("迭代次数 %d 超出范围 [1, %d]", iterations, MaxIterations)
+	}
+
+	// 1. 生成随机盐与 nonce
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return nil, fmt.Errorf("生成盐失败: %w", err)
+	}
+	aead, err := newAEAD(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, fmt.Errorf("生成 nonce 失败: %w", err)
+	}
+
+	// 2. 以头部为附加认证数据加密私钥
+	headers := map[string]string{
+		headerScheme:     scheme,
+		headerCurve:      curve,
+		headerKDF:        kdfName,
+		headerIterations: strconv.Itoa(iterations),
+		headerSalt:       hex.EncodeToString(salt),
+		headerCipher:     cipherName,
+		headerNonce:      hex.EncodeToString(nonce),
+	}
+	block := &pem.Block{
+		Type:    PrivateKeyType,
+		Headers: headers,
+		Bytes:   aead.Seal(nil, nonce, sk, additionalData(headers)),
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// DecryptPrivateKey 解析 PEM 编码的加密私钥，校验方案与曲线后使用口令解密
+func DecryptPrivateKey(data []byte, scheme, curve string, passphrase []byte) ([]byte, error) {
+	block, err := decodeBlock(data, PrivateKeyType, scheme, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. 解析 KDF 与加密参数
+	h := block.Headers
+	if h[headerKDF] != kdfName || h[headerCipher] != cipherName {
+		return nil, fmt.Errorf("%w: 不支持的 KDF 或加密算法", ErrInvalidPEM)
+	}
+	iterations, err := strconv.Atoi(h[headerIterations])
+	if err != nil || iterations < 1 || iterations > MaxIterations {
+		return nil, fmt.Errorf("%w: 迭代次数非法", ErrInvalidPEM)
+	}
+	salt, err := hex.DecodeString(h[headerSalt])
+	if err != nil || len(salt) != saltSize {
+		return nil, fmt.Errorf("%w: 盐非法", ErrInvalidPEM)
+	}
+	nonce, err := hex.DecodeString(h[headerNonce])
+	if err != nil {
+		return nil, fmt.Errorf("%w: nonce 非法", ErrInvalidPEM)
+	}
+
+	// 2. 派生密钥并解密
+	aead, err := newAEAD(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: nonce 长度非法", ErrInvalidPEM)
+	}
+	sk, err := aead.Open(nil, nonce, block.Bytes, additionalData(h))
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return sk, nil
+}
+
+// -------------------- 公钥 --------------------
+
+// EncodePublicKey 将公钥 pk 编码为不加密的 PEM
+func EncodePublicKey(scheme, curve string, pk []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    PublicKeyType,
+		Headers: map[string]string{headerScheme: scheme, headerCurve: curve},
+		Bytes:   pk,
+	})
+}
+
+// DecodePublicKey 解析 PEM 编码的公钥，校验方案与曲线后返回公钥字节
+func DecodePublicKey(data []byte, scheme, curve string) ([]byte, error) {
+	block, err := decodeBlock(data, PublicKeyType, scheme, curve)
+	if err != nil {
+		return nil, err
+	}
+	return block.Bytes, nil
+}
+
+// -------------------- 工具函数 --------------------
+
+// decodeBlock 解码第一个 PEM 块并校验类型、方案与曲线
+func decodeBlock(data []byte, typ, scheme, curve string) (*pem.Block, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: 未找到 PEM 块", ErrInvalidPEM)
+	}
+	if block.Type != typ {
+		return nil, fmt.Errorf("%w: 类型为 %q，期望 %q", ErrInvalidPEM, block.Type, typ)
+	}
+	if block.Headers[headerScheme] != scheme || block.Headers[headerCurve] != curve {
+		return nil, fmt.Errorf("%w: %s/%s", ErrSchemeMismatch, block.Headers[headerScheme], block.Headers[headerCurve])
+	}
+	return block, nil
+}
+
+// newAEAD 由口令派生 AES-256 密钥并构造 GCM
+func newAEAD(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData 将头部按固定顺序编码为 GCM 的附加认证数据，防止头部被替换
+func additionalData(headers map[string]string) []byte {
+	var ad []byte
+	for _, k := range []string{headerScheme, headerCurve, headerKDF, headerIterations, headerSalt, headerCipher, headerNonce} {
+		ad = append(ad, k...)
+		ad = append(ad, ':')
+		ad = append(ad, headers[k]...)
+		ad = append(ad, '\n')
+	}
+	return ad
+}
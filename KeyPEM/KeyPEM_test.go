@@ -0,0 +1,71 @@
+package KeyPEM
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// 测试私钥的加密、解密与篡改检测
+func TestPrivateKey(t *testing.T) {
+	sk := bytes.Repeat([]byte{0x5a}, 32)
+	passphrase := []byte("正确的口令")
+
+	data, err := EncryptPrivateKey("brfl-bn254", "BN254", sk, passphrase, 1000, rand.Reader)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if bytes.Contains(data, sk) {
+		t.Fatalf("PEM 中包含明文私钥")
+	}
+
+	got, err := DecryptPrivateKey(data, "brfl-bn254", "BN254", passphrase)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if !bytes.Equal(got, sk) {
+		t.Errorf("解密结果与原私钥不一致")
+	}
+
+	// 篡改头部中的方案名称后，即使调用方按篡改后的方案解析也无法通过认证
+	tampered := bytes.Replace(data, []byte("brfl-bn254"), []byte("rscp-bn254"), 1)
+	cases := []struct {
+		name       string
+		data       []byte
+		scheme     string
+		passphrase []byte
+		want       error
+	}{
+		{"口令错误", data, "brfl-bn254", []byte("错误的口令"), ErrDecryption},
+		{"方案不符", data, "rscp-bn254", passphrase, ErrSchemeMismatch},
+		{"头部被篡改", tampered, "rscp-bn254", passphrase, ErrDecryption},
+		{"不是 PEM", []byte("garbage"), "brfl-bn254", passphrase, ErrInvalidPEM},
+	}
+	for _, c := range cases {
+		if _, err := DecryptPrivateKey(c.data, c.scheme, "BN254", c.passphrase); !errors.Is(err, c.want) {
+			t.Errorf("%s: 期望错误 %v，实际为 %v", c.name, c.want, err)
+		}
+	}
+
+	if _, err := EncryptPrivateKey("brfl-bn254", "BN254", sk, passphrase, 0, rand.Reader); err == nil {
+		t.Errorf("迭代次数为 0 时应返回错误")
+	}
+}
+
+// 测试公钥的编码与解析
+func TestPublicKey(t *testing.T) {
+	pk := []byte("公钥字节")
+	data := EncodePublicKey("rscp-bls12381", "BLS12-381", pk)
+
+	got, err := DecodePublicKey(data, "rscp-bls12381", "BLS12-381")
+	if err != nil || !bytes.Equal(got, pk) {
+		t.Fatalf("解析公钥失败: %v", err)
+	}
+	if _, err := DecodePublicKey(data, "rscp-bls12381", "BN254"); !errors.Is(err, ErrSchemeMismatch) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrSchemeMismatch, err)
+	}
+	if _, err := DecryptPrivateKey(data, "rscp-bls12381", "BLS12-381", nil); !errors.Is(err, ErrInvalidPEM) {
+		t.Errorf("公钥块被当作私钥解析: %v", err)
+	}
+}
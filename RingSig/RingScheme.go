@@ -0,0 +1,136 @@
+package RingSig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	blsBRFL "BRFL/BLS/BRFL"
+	blsRSCP "BRFL/BLS/RSCP"
+	bnBRFL "BRFL/BN/BRFL"
+	bnRSCP "BRFL/BN/RSCP"
+)
+
+// -------------------- 方案名称 --------------------
+
+const (
+	// BRFLBN254 BN254 曲线上的 BRFL 方案（BN/BRFL）
+	BRFLBN254 = bnBRFL.SchemeName
+	// BRFLBLS12381 BLS12-381 曲线上的 BRFL 方案（BLS/BRFL）
+	BRFLBLS12381 = blsBRFL.SchemeName
+	// RSCPBN254 BN254 曲线上的 RSCP 方案（BN/RSCP）
+	RSCPBN254 = bnRSCP.SchemeName
+	// RSCPBLS12381 BLS12-381 曲线上的 RSCP 方案（BLS/RSCP）
+	RSCPBLS12381 = blsRSCP.SchemeName
+)
+
+// -------------------- 错误定义 --------------------
+
+var (
+	// ErrUnknownScheme 注册表中没有该名称的方案
+	ErrUnknownScheme = errors.New("未知的环签名方案")
+	// ErrSchemeMismatch 密钥或签名不属于当前方案
+	ErrSchemeMismatch = errors.New("密钥或签名与方案不匹配")
+)
+
+// -------------------- 统一接口 --------------------
+
+// PublicKey 环成员的公钥
+type PublicKey interface {
+	// Scheme 返回公钥所属方案的名称
+	Scheme() string
+	// Bytes 返回公钥的二进制编码，与签名编码中的点相同，可由 Scheme.ParsePublicKey 解析
+	Bytes() []byte
+}
+
+// PrivateKey 签名者的私钥
+type PrivateKey interface {
+	// Scheme 返回私钥所属方案的名称
+	Scheme() string
+	// Public 返回对应的公钥
+	Public() PublicKey
+}
+
+// Signature 环签名
+type Signature interface {
+	// Scheme 返回签名所属方案的名称
+	Scheme() string
+	// MarshalBinary 返回签名的二进制编码，格式见各实现包的 RingEncoding.go
+	MarshalBinary() ([]byte, error)
+}
+
+// Scheme 一种具体的环签名方案
+type Scheme interface {
+	// Name 返回方案在注册表中的名称
+	Name() string
+	// GenerateKey 生成密钥对，rand 为 nil 时使用 crypto/rand.Reader
+	GenerateKey(rand io.Reader) (PrivateKey, error)
+	// Sign 以 key 对 message 签名，key 的公钥必须在 ring 中；rand 为 nil 时使用 crypto/rand.Reader
+	Sign(message []byte, ring []PublicKey, key PrivateKey, rand io.Reader) (Signature, error)
+	// Verify 验证签名，合法时返回 nil，否则返回拒绝的原因
+	Verify(message []byte, ring []PublicKey, sig Signature) error
+	// ParseSignature 从二进制编码解析签名
+	ParseSignature(data []byte) (Signature, error)
+	// ParsePublicKey 解析公钥：PEM 编码，或 PublicKey.Bytes 返回的二进制编码
+	ParsePublicKey(data []byte) (PublicKey, error)
+	// ParsePrivateKey 解析私钥：以 passphrase 加密的 PEM 编码，或定长 32 字节的大端序标量（此时忽略 passphrase）
+	ParsePrivateKey(data, passphrase []byte) (PrivateKey, error)
+}
+
+// isPEM 判断 data 是否为 PEM 编码，否则按二进制编码解析
+func isPEM(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN "))
+}
+
+// -------------------- 注册表 --------------------
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Scheme)
+)
+
+// Register 将方案注册到全局注册表，名称重复时 panic
+func Register(s Scheme) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[s.Name()]; ok {
+		panic(fmt.Sprintf("环签名方案重复注册: %s", s.Name()))
+	}
+	registry[s.Name()] = s
+}
+
+// Lookup 按名称查找已注册的方案
+func Lookup(name string) (Scheme, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	s, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownScheme, name)
+	}
+	return s, nil
+}
+
+// Names 返回所有已注册方案的名称，按字典序排列
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(brflBNScheme{})
+	Register(brflBLSScheme{})
+	Register(rscpBNScheme{})
+	Register(rscpBLSScheme{})
+}
@@ -0,0 +1,193 @@
+package RingSig
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	blsBRFL "BRFL/BLS/BRFL"
+	blsRSCP "BRFL/BLS/RSCP"
+	bnBRFL "BRFL/BN/BRFL"
+	bnRSCP "BRFL/BN/RSCP"
+	"BRFL/KeyPEM"
+)
+
+var MessageTrue = []byte("这是用来正确签名的信息。")
+var MessageFalse = []byte("这是用来错误验证的信息。")
+
+// newRing 使用方案 s 生成 n 个私钥及对应的公钥环
+func newRing(t *testing.T, s Scheme, n int) ([]PrivateKey, []PublicKey) {
+	t.Helper()
+	keys := make([]PrivateKey, n)
+	ring := make([]PublicKey, n)
+	for i := range keys {
+		key, err := s.GenerateKey(nil)
+		if err != nil {
+			t.Fatalf("%s: 生成密钥失败: %v", s.Name(), err)
+		}
+		keys[i] = key
+		ring[i] = key.Public()
+	}
+	return keys, ring
+}
+
+// 测试通过注册表按名称选择方案并签名、验证
+func TestRegistry(t *testing.T) {
+	want := []string{BRFLBLS12381, BRFLBN254, RSCPBLS12381, RSCPBN254}
+	names := Names()
+	if len(names) != len(want) {
+		t.Fatalf("注册的方案为 %v，期望 %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("注册的方案为 %v，期望 %v", names, want)
+		}
+	}
+
+	if _, err := Lookup("unknown"); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("期望错误 %v，实际为 %v", ErrUnknownScheme, err)
+	}
+
+	for _, name := range names {
+		s, err := Lookup(name)
+		if err != nil {
+			t.Fatalf("查找方案 %s 失败: %v", name, err)
+		}
+		keys, ring := newRing(t, s, 4)
+
+		sig, err := s.Sign(MessageTrue, ring, keys[1], nil)
+		if err != nil {
+			t.Fatalf("%s: 签名失败: %v", name, err)
+		}
+		if sig.Scheme() != name {
+			t.Errorf("%s: 签名所属方案为 %s", name, sig.Scheme())
+		}
+		if err := s.Verify(MessageTrue, ring, sig); err != nil {
+			t.Errorf("%s: 合法签名验证失败: %v", name, err)
+		}
+		if err := s.Verify(MessageFalse, ring, sig); err == nil {
+			t.Errorf("%s: 错误消息通过了验证", name)
+		}
+
+		// 经过二进制编码往返后仍可验证
+		data, err := sig.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: 编码失败: %v", name, err)
+		}
+		parsed, err := s.ParseSignature(data)
+		if err != nil {
+			t.Fatalf("%s: 解码失败: %v", name, err)
+		}
+		if err := s.Verify(MessageTrue, ring, parsed); err != nil {
+			t.Errorf("%s: 解码后的签名验证失败: %v", name, err)
+		}
+	}
+}
+
+// 测试不同方案的密钥与签名不能混用
+func TestSchemeMismatch(t *testing.T) {
+	brfl, _ := Lookup(BRFLBN254)
+	rscp, _ := Lookup(RSCPBN254)
+	brflKeys, brflRing := newRing(t, brfl, 3)
+	rscpKeys, rscpRing := newRing(t, rscp, 3)
+
+	if _, err := brfl.Sign(MessageTrue, rscpRing, brflKeys[0], nil); !errors.Is(err, ErrSchemeMismatch) {
+		t.Errorf("混用公钥环: 期望错误 %v，实际为 %v", ErrSchemeMismatch, err)
+	}
+	if _, err := brfl.Sign(MessageTrue, brflRing, rscpKeys[0], nil); !errors.Is(err, ErrSchemeMismatch) {
+		t.Errorf("混用私钥: 期望错误 %v，实际为 %v", ErrSchemeMismatch, err)
+	}
+
+	sig, err := rscp.Sign(MessageTrue, rscpRing, rscpKeys[0], nil)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := brfl.Verify(MessageTrue, brflRing, sig); !errors.Is(err, ErrSchemeMismatch) {
+		t.Errorf("混用签名: 期望错误 %v，实际为 %v", ErrSchemeMismatch, err)
+	}
+}
+
+// privateKeyBytes 返回私钥的定长 32 字节编码，即 ParsePrivateKey 接受的二进制格式
+func privateKeyBytes(key PrivateKey) []byte {
+	switch k := key.(type) {
+	case *bnPrivateKey:
+		return k.sk.FillBytes(make([]byte, 32))
+	case *blsPrivateKey:
+		return k.sk.FillBytes(make([]byte, 32))
+	}
+	return nil
+}
+
+// 测试从二进制与 PEM 编码导入的密钥可以通过统一接口签名与验证
+func TestParseKeys(t *testing.T) {
+	curves := map[string]string{
+		BRFLBN254:    bnBRFL.CurveName,
+		BRFLBLS12381: blsBRFL.CurveName,
+		RSCPBN254:    bnRSCP.CurveName,
+		RSCPBLS12381: blsRSCP.CurveName,
+	}
+	passphrase := []byte("正确的口令")
+
+	for _, name := range Names() {
+		s, err := Lookup(name)
+		if err != nil {
+			t.Fatalf("查找方案 %s 失败: %v", name, err)
+		}
+		keys, ring := newRing(t, s, 3)
+
+		// 公钥交替使用二进制与 PEM 编码
+		parsed := make([]PublicKey, len(ring))
+		for i, pk := range ring {
+			data := pk.Bytes()
+			if i%2 == 1 {
+				data = KeyPEM.EncodePublicKey(name, curves[name], data)
+			}
+			if parsed[i], err = s.ParsePublicKey(data); err != nil {
+				t.Fatalf("%s: 解析公钥 %d 失败: %v", name, i, err)
+			}
+			if !bytes.Equal(parsed[i].Bytes(), pk.Bytes()) {
+				t.Errorf("%s: 解析出的公钥 %d 与原公钥不同", name, i)
+			}
+		}
+
+		// 私钥分别使用二进制编码与加密的 PEM 编码
+		sk := privateKeyBytes(keys[1])
+		enc, err := KeyPEM.EncryptPrivateKey(name, curves[name], sk, passphrase, 1, rand.Reader)
+		if err != nil {
+			t.Fatalf("%s: 加密私钥失败: %v", name, err)
+		}
+		for _, data := range [][]byte{sk, enc} {
+			key, err := s.ParsePrivateKey(data, passphrase)
+			if err != nil {
+				t.Fatalf("%s: 解析私钥失败: %v", name, err)
+			}
+			sig, err := s.Sign(MessageTrue, parsed, key, nil)
+			if err != nil {
+				t.Fatalf("%s: 签名失败: %v", name, err)
+			}
+			if err := s.Verify(MessageTrue, ring, sig); err != nil {
+				t.Errorf("%s: 导入私钥的签名验证失败: %v", name, err)
+			}
+		}
+
+		if _, err := s.ParsePrivateKey(enc, []byte("错误的口令")); !errors.Is(err, KeyPEM.ErrDecryption) {
+			t.Errorf("%s: 错误口令: 期望错误 %v，实际为 %v", name, KeyPEM.ErrDecryption, err)
+		}
+		if _, err := s.ParsePrivateKey(sk[1:], nil); err == nil {
+			t.Errorf("%s: 长度错误的私钥解析成功", name)
+		}
+		if _, err := s.ParsePublicKey(ring[0].Bytes()[1:]); err == nil {
+			t.Errorf("%s: 长度错误的公钥解析成功", name)
+		}
+	}
+
+	// 其他方案的 PEM 被拒绝
+	brfl, _ := Lookup(BRFLBN254)
+	_, ring := newRing(t, brfl, 1)
+	data := KeyPEM.EncodePublicKey(BRFLBN254, bnBRFL.CurveName, ring[0].Bytes())
+	rscp, _ := Lookup(RSCPBN254)
+	if _, err := rscp.ParsePublicKey(data); !errors.Is(err, KeyPEM.ErrSchemeMismatch) {
+		t.Errorf("混用方案: 期望错误 %v，实际为 %v", KeyPEM.ErrSchemeMismatch, err)
+	}
+}
@@ -0,0 +1,249 @@
+package RingSig
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	blsBRFL "BRFL/BLS/BRFL"
+	blsRSCP "BRFL/BLS/RSCP"
+	bls "github.com/kilic/bls12-381"
+)
+
+// -------------------- BLS12-381 密钥 --------------------
+
+// blsPublicKey BLS12-381 G1 上的公钥，scheme 为所属方案名称
+type blsPublicKey struct {
+	scheme string
+	point  *bls.PointG1
+}
+
+func (k *blsPublicKey) Scheme() string { return k.scheme }
+
+// Bytes 返回 48 字节的压缩编码，与 BLS/BRFL、BLS/RSCP 中的点编码相同
+// ToCompressed 会把参数原地转换为仿射坐标，公钥可能被多个协程共享，这里对副本编码
+func (k *blsPublicKey) Bytes() []byte { return bls.NewG1().ToCompressed(new(bls.PointG1).Set(k.point)) }
+
+// blsPrivateKey BLS12-381 上的私钥 sk 及公钥 pk = sk * G
+type blsPrivateKey struct {
+	scheme string
+	sk     *big.Int
+	pk     *bls.PointG1
+}
+
+func (k *blsPrivateKey) Scheme() string    { return k.scheme }
+func (k *blsPrivateKey) Public() PublicKey { return &blsPublicKey{scheme: k.scheme, point: k.pk} }
+
+// blsRing 将统一接口的公钥环转换为 BLS12-381 G1 点列表，并校验每个公钥所属的方案
+func blsRing(scheme string, ring []PublicKey) ([]*bls.PointG1, error) {
+	PKList := make([]*bls.PointG1, len(ring))
+	for i, pk := range ring {
+		k, ok := pk.(*blsPublicKey)
+		if !ok || k.scheme != scheme {
+			return nil, fmt.Errorf("%w: 公钥下标 %d", ErrSchemeMismatch, i)
+		}
+		PKList[i] = k.point
+	}
+	return PKList, nil
+}
+
+// blsKey 将统一接口的私钥转换为 BLS12-381 私钥，并校验所属的方案
+func blsKey(scheme string, key PrivateKey) (*blsPrivateKey, error) {
+	k, ok := key.(*blsPrivateKey)
+	if !ok || k.scheme != scheme {
+		return nil, fmt.Errorf("%w: 私钥", ErrSchemeMismatch)
+	}
+	return k, nil
+}
+
+// -------------------- brfl-bls12381 --------------------
+
+// brflBLSSignature BLS/BRFL 的签名
+type brflBLSSignature struct {
+	sigma *blsBRFL.Sigma
+}
+
+func (s *brflBLSSignature) Scheme() string                 { return BRFLBLS12381 }
+func (s *brflBLSSignature) MarshalBinary() ([]byte, error) { return s.sigma.MarshalBinary() }
+
+// brflBLSScheme 对 BLS/BRFL 的适配
+type brflBLSScheme struct{}
+
+func (brflBLSScheme) Name() string { return BRFLBLS12381 }
+
+func (brflBLSScheme) GenerateKey(rand io.Reader) (PrivateKey, error) {
+	var opts []blsBRFL.Option
+	if rand != nil {
+		opts = append(opts, blsBRFL.WithRandom(rand))
+	}
+	signer, err := blsBRFL.NewSigner(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &blsPrivateKey{scheme: BRFLBLS12381, sk: signer.PrivateKey, pk: signer.PublicKey}, nil
+}
+
+func (brflBLSScheme) Sign(message []byte, ring []PublicKey, key PrivateKey, rand io.Reader) (Signature, error) {
+	PKList, err := blsRing(BRFLBLS12381, ring)
+	if err != nil {
+		return nil, err
+	}
+	k, err := blsKey(BRFLBLS12381, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []blsBRFL.Option
+	if rand != nil {
+		opts = append(opts, blsBRFL.WithRandom(rand))
+	}
+	sigma, err := blsBRFL.Sign(message, PKList, &blsBRFL.Signer{PrivateKey: k.sk, PublicKey: k.pk}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &brflBLSSignature{sigma: sigma}, nil
+}
+
+func (brflBLSScheme) Verify(message []byte, ring []PublicKey, sig Signature) error {
+	PKList, err := blsRing(BRFLBLS12381, ring)
+	if err != nil {
+		return err
+	}
+	s, ok := sig.(*brflBLSSignature)
+	if !ok {
+		return fmt.Errorf("%w: 签名", ErrSchemeMismatch)
+	}
+	return blsBRFL.VerifyDetailed(message, PKList, s.sigma)
+}
+
+func (brflBLSScheme) ParseSignature(data []byte) (Signature, error) {
+	sigma := new(blsBRFL.Sigma)
+	if err := sigma.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &brflBLSSignature{sigma: sigma}, nil
+}
+
+func (brflBLSScheme) ParsePublicKey(data []byte) (PublicKey, error) {
+	var pk *bls.PointG1
+	var err error
+	if isPEM(data) {
+		pk, err = blsBRFL.ParsePublicKeyPEM(data)
+	} else {
+		pk, err = blsBRFL.ParsePublicKey(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &blsPublicKey{scheme: BRFLBLS12381, point: pk}, nil
+}
+
+func (brflBLSScheme) ParsePrivateKey(data, passphrase []byte) (PrivateKey, error) {
+	var signer *blsBRFL.Signer
+	var err error
+	if isPEM(data) {
+		signer, err = blsBRFL.ParsePrivateKeyPEM(data, passphrase)
+	} else {
+		signer, err = blsBRFL.ParsePrivateKey(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &blsPrivateKey{scheme: BRFLBLS12381, sk: signer.PrivateKey, pk: signer.PublicKey}, nil
+}
+
+// -------------------- rscp-bls12381 --------------------
+
+// rscpBLSSignature BLS/RSCP 的签名
+type rscpBLSSignature struct {
+	sigma *blsRSCP.Sigma
+}
+
+func (s *rscpBLSSignature) Scheme() string                 { return RSCPBLS12381 }
+func (s *rscpBLSSignature) MarshalBinary() ([]byte, error) { return s.sigma.MarshalBinary() }
+
+// rscpBLSScheme 对 BLS/RSCP 的适配
+type rscpBLSScheme struct{}
+
+func (rscpBLSScheme) Name() string { return RSCPBLS12381 }
+
+func (rscpBLSScheme) GenerateKey(rand io.Reader) (PrivateKey, error) {
+	var opts []blsRSCP.Option
+	if rand != nil {
+		opts = append(opts, blsRSCP.WithRandom(rand))
+	}
+	signer, err := blsRSCP.NewSigner(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &blsPrivateKey{scheme: RSCPBLS12381, sk: signer.PrivateKey, pk: signer.PublicKey}, nil
+}
+
+func (rscpBLSScheme) Sign(message []byte, ring []PublicKey, key PrivateKey, rand io.Reader) (Signature, error) {
+	PKList, err := blsRing(RSCPBLS12381, ring)
+	if err != nil {
+		return nil, err
+	}
+	k, err := blsKey(RSCPBLS12381, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []blsRSCP.Option
+	if rand != nil {
+		opts = append(opts, blsRSCP.WithRandom(rand))
+	}
+	sigma, err := blsRSCP.Sign(message, PKList, &blsRSCP.Signer{PrivateKey: k.sk, PublicKey: k.pk}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &rscpBLSSignature{sigma: sigma}, nil
+}
+
+func (rscpBLSScheme) Verify(message []byte, ring []PublicKey, sig Signature) error {
+	PKList, err := blsRing(RSCPBLS12381, ring)
+	if err != nil {
+		return err
+	}
+	s, ok := sig.(*rscpBLSSignature)
+	if !ok {
+		return fmt.Errorf("%w: 签名", ErrSchemeMismatch)
+	}
+	return blsRSCP.VerifyDetailed(message, PKList, s.sigma)
+}
+
+func (rscpBLSScheme) ParseSignature(data []byte) (Signature, error) {
+	sigma := new(blsRSCP.Sigma)
+	if err := sigma.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &rscpBLSSignature{sigma: sigma}, nil
+}
+
+func (rscpBLSScheme) ParsePublicKey(data []byte) (PublicKey, error) {
+	var pk *bls.PointG1
+	var err error
+	if isPEM(data) {
+		pk, err = blsRSCP.ParsePublicKeyPEM(data)
+	} else {
+		pk, err = blsRSCP.ParsePublicKey(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &blsPublicKey{scheme: RSCPBLS12381, point: pk}, nil
+}
+
+func (rscpBLSScheme) ParsePrivateKey(data, passphrase []byte) (PrivateKey, error) {
+	var signer *blsRSCP.Signer
+	var err error
+	if isPEM(data) {
+		signer, err = blsRSCP.ParsePrivateKeyPEM(data, passphrase)
+	} else {
+		signer, err = blsRSCP.ParsePrivateKey(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &blsPrivateKey{scheme: RSCPBLS12381, sk: signer.PrivateKey, pk: signer.PublicKey}, nil
+}
@@ -0,0 +1,246 @@
+package RingSig
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	bn256 "BRFL/BN/BN256"
+	bnBRFL "BRFL/BN/BRFL"
+	bnRSCP "BRFL/BN/RSCP"
+)
+
+// -------------------- BN254 密钥 --------------------
+
+// bnPublicKey BN254 G1 上的公钥，scheme 为所属方案名称
+type bnPublicKey struct {
+	scheme string
+	point  *bn256.G1
+}
+
+func (k *bnPublicKey) Scheme() string { return k.scheme }
+func (k *bnPublicKey) Bytes() []byte  { return k.point.Marshal() }
+
+// bnPrivateKey BN254 上的私钥 sk 及公钥 pk = sk * G
+type bnPrivateKey struct {
+	scheme string
+	sk     *big.Int
+	pk     *bn256.G1
+}
+
+func (k *bnPrivateKey) Scheme() string    { return k.scheme }
+func (k *bnPrivateKey) Public() PublicKey { return &bnPublicKey{scheme: k.scheme, point: k.pk} }
+
+// bnRing 将统一接口的公钥环转换为 BN254 G1 点列表，并校验每个公钥所属的方案
+func bnRing(scheme string, ring []PublicKey) ([]*bn256.G1, error) {
+	PKList := make([]*bn256.G1, len(ring))
+	for i, pk := range ring {
+		k, ok := pk.(*bnPublicKey)
+		if !ok || k.scheme != scheme {
+			return nil, fmt.Errorf("%w: 公钥下标 %d", ErrSchemeMismatch, i)
+		}
+		PKList[i] = k.point
+	}
+	return PKList, nil
+}
+
+// bnKey 将统一接口的私钥转换为 BN254 私钥，并校验所属的方案
+func bnKey(scheme string, key PrivateKey) (*bnPrivateKey, error) {
+	k, ok := key.(*bnPrivateKey)
+	if !ok || k.scheme != scheme {
+		return nil, fmt.Errorf("%w: 私钥", ErrSchemeMismatch)
+	}
+	return k, nil
+}
+
+// -------------------- brfl-bn254 --------------------
+
+// brflBNSignature BN/BRFL 的签名
+type brflBNSignature struct {
+	sigma *bnBRFL.Sigma
+}
+
+func (s *brflBNSignature) Scheme() string                 { return BRFLBN254 }
+func (s *brflBNSignature) MarshalBinary() ([]byte, error) { return s.sigma.MarshalBinary() }
+
+// brflBNScheme 对 BN/BRFL 的适配
+type brflBNScheme struct{}
+
+func (brflBNScheme) Name() string { return BRFLBN254 }
+
+func (brflBNScheme) GenerateKey(rand io.Reader) (PrivateKey, error) {
+	var opts []bnBRFL.Option
+	if rand != nil {
+		opts = append(opts, bnBRFL.WithRandom(rand))
+	}
+	signer, err := bnBRFL.NewSigner(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &bnPrivateKey{scheme: BRFLBN254, sk: signer.PrivateKey, pk: signer.PublicKey}, nil
+}
+
+func (brflBNScheme) Sign(message []byte, ring []PublicKey, key PrivateKey, rand io.Reader) (Signature, error) {
+	PKList, err := bnRing(BRFLBN254, ring)
+	if err != nil {
+		return nil, err
+	}
+	k, err := bnKey(BRFLBN254, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []bnBRFL.Option
+	if rand != nil {
+		opts = append(opts, bnBRFL.WithRandom(rand))
+	}
+	sigma, err := bnBRFL.Sign(message, PKList, &bnBRFL.Signer{PrivateKey: k.sk, PublicKey: k.pk}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &brflBNSignature{sigma: sigma}, nil
+}
+
+func (brflBNScheme) Verify(message []byte, ring []PublicKey, sig Signature) error {
+	PKList, err := bnRing(BRFLBN254, ring)
+	if err != nil {
+		return err
+	}
+	s, ok := sig.(*brflBNSignature)
+	if !ok {
+		return fmt.Errorf("%w: 签名", ErrSchemeMismatch)
+	}
+	return bnBRFL.VerifyDetailed(message, PKList, s.sigma)
+}
+
+func (brflBNScheme) ParseSignature(data []byte) (Signature, error) {
+	sigma := new(bnBRFL.Sigma)
+	if err := sigma.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &brflBNSignature{sigma: sigma}, nil
+}
+
+func (brflBNScheme) ParsePublicKey(data []byte) (PublicKey, error) {
+	var pk *bn256.G1
+	var err error
+	if isPEM(data) {
+		pk, err = bnBRFL.ParsePublicKeyPEM(data)
+	} else {
+		pk, err = bnBRFL.ParsePublicKey(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bnPublicKey{scheme: BRFLBN254, point: pk}, nil
+}
+
+func (brflBNScheme) ParsePrivateKey(data, passphrase []byte) (PrivateKey, error) {
+	var signer *bnBRFL.Signer
+	var err error
+	if isPEM(data) {
+		signer, err = bnBRFL.ParsePrivateKeyPEM(data, passphrase)
+	} else {
+		signer, err = bnBRFL.ParsePrivateKey(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bnPrivateKey{scheme: BRFLBN254, sk: signer.PrivateKey, pk: signer.PublicKey}, nil
+}
+
+// -------------------- rscp-bn254 --------------------
+
+// rscpBNSignature BN/RSCP 的签名
+type rscpBNSignature struct {
+	sigma *bnRSCP.Sigma
+}
+
+func (s *rscpBNSignature) Scheme() string                 { return RSCPBN254 }
+func (s *rscpBNSignature) MarshalBinary() ([]byte, error) { return s.sigma.MarshalBinary() }
+
+// rscpBNScheme 对 BN/RSCP 的适配
+type rscpBNScheme struct{}
+
+func (rscpBNScheme) Name() string { return RSCPBN254 }
+
+func (rscpBNScheme) GenerateKey(rand io.Reader) (PrivateKey, error) {
+	var opts []bnRSCP.Option
+	if rand != nil {
+		opts = append(opts, bnRSCP.WithRandom(rand))
+	}
+	signer, err := bnRSCP.NewSigner(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &bnPrivateKey{scheme: RSCPBN254, sk: signer.PrivateKey, pk: signer.PublicKey}, nil
+}
+
+func (rscpBNScheme) Sign(message []byte, ring []PublicKey, key PrivateKey, rand io.Reader) (Signature, error) {
+	PKList, err := bnRing(RSCPBN254, ring)
+	if err != nil {
+		return nil, err
+	}
+	k, err := bnKey(RSCPBN254, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []bnRSCP.Option
+	if rand != nil {
+		opts = append(opts, bnRSCP.WithRandom(rand))
+	}
+	sigma, err := bnRSCP.Sign(message, PKList, &bnRSCP.Signer{PrivateKey: k.sk, PublicKey: k.pk}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &rscpBNSignature{sigma: sigma}, nil
+}
+
+func (rscpBNScheme) Verify(message []byte, ring []PublicKey, sig Signature) error {
+	PKList, err := bnRing(RSCPBN254, ring)
+	if err != nil {
+		return err
+	}
+	s, ok := sig.(*rscpBNSignature)
+	if !ok {
+		return fmt.Errorf("%w: 签名", ErrSchemeMismatch)
+	}
+	return bnRSCP.VerifyDetailed(message, PKList, s.sigma)
+}
+
+func (rscpBNScheme) ParseSignature(data []byte) (Signature, error) {
+	sigma := new(bnRSCP.Sigma)
+	if err := sigma.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &rscpBNSignature{sigma: sigma}, nil
+}
+
+func (rscpBNScheme) ParsePublicKey(data []byte) (PublicKey, error) {
+	var pk *bn256.G1
+	var err error
+	if isPEM(data) {
+		pk, err = bnRSCP.ParsePublicKeyPEM(data)
+	} else {
+		pk, err = bnRSCP.ParsePublicKey(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bnPublicKey{scheme: RSCPBN254, point: pk}, nil
+}
+
+func (rscpBNScheme) ParsePrivateKey(data, passphrase []byte) (PrivateKey, error) {
+	var signer *bnRSCP.Signer
+	var err error
+	if isPEM(data) {
+		signer, err = bnRSCP.ParsePrivateKeyPEM(data, passphrase)
+	} else {
+		signer, err = bnRSCP.ParsePrivateKey(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bnPrivateKey{scheme: RSCPBN254, sk: signer.PrivateKey, pk: signer.PublicKey}, nil
+}
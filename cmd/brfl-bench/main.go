@@ -0,0 +1,290 @@
+// brfl-bench 对比 BN254、BLS12-381 上 BRFL 与 RSCP 四种方案的签名时间、验证时间与签名大小
+//
+// 用法：
+//
+//	brfl-bench -sizes 2,8,32,128 -runs 20 -format csv -o result.csv
+//
+// 每种方案、每个环大小分别重复签名与验证 runs 次，输出平均值、中位数、p95 耗时，
+// 每次操作的内存分配次数与字节数，以及签名编码后的字节数
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"BRFL/RingSig"
+)
+
+// benchMessage 基准测试中签名的消息
+var benchMessage = []byte("brfl-bench")
+
+// Result 一种方案在一个环大小下某个操作的统计结果，耗时单位为纳秒
+type Result struct {
+	Scheme      string  `json:"scheme"`
+	Op          string  `json:"op"`
+	RingSize    int     `json:"ring_size"`
+	Runs        int     `json:"runs"`
+	MeanNs      float64 `json:"mean_ns"`
+	MedianNs    float64 `json:"median_ns"`
+	P95Ns       float64 `json:"p95_ns"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+	SigBytes    int     `json:"sig_bytes"`
+}
+
+// csvHeader CSV 输出的表头，顺序与 Result.record 一致
+var csvHeader = []string{"scheme", "op", "ring_size", "runs", "mean_ns", "median_ns", "p95_ns", "allocs_per_op", "bytes_per_op", "sig_bytes"}
+
+// record 返回 Result 对应的一行 CSV
+func (r Result) record() []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	return []string{
+		r.Scheme, r.Op, strconv.Itoa(r.RingSize), strconv.Itoa(r.Runs),
+		f(r.MeanNs), f(r.MedianNs), f(r.P95Ns), f(r.AllocsPerOp), f(r.BytesPerOp),
+		strconv.Itoa(r.SigBytes),
+	}
+}
+
+// sample 一次 measure 的原始数据
+type sample struct {
+	durations []time.Duration
+	allocs    uint64
+	bytes     uint64
+}
+
+// measure 重复执行 fn runs 次，记录每次的耗时以及总的内存分配次数与字节数
+func measure(runs int, fn func(i int) error) (sample, error) {
+	s := sample{durations: make([]time.Duration, runs)}
+	var before, after runtime.MemStats
+	for i := 0; i < runs; i++ {
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		err := fn(i)
+		s.durations[i] = time.Since(start)
+		runtime.ReadMemStats(&after)
+		if err != nil {
+			return s, err
+		}
+		s.allocs += after.Mallocs - before.Mallocs
+		s.bytes += after.TotalAlloc - before.TotalAlloc
+	}
+	return s, nil
+}
+
+// summarize 计算耗时的平均值、中位数与 p95（最近秩法），durations 不能为空
+func summarize(durations []time.Duration) (mean, median, p95 float64) {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	n := len(sorted)
+
+	var total float64
+	for _, d := range sorted {
+		total += float64(d)
+	}
+	mean = total / float64(n)
+	if n%2 == 1 {
+		median = float64(sorted[n/2])
+	} else {
+		median = (float64(sorted[n/2-1]) + float64(sorted[n/2])) / 2
+	}
+	// 最近秩：第 ceil(0.95 n) 个值
+	p95 = float64(sorted[(95*n+99)/100-1])
+	return mean, median, p95
+}
+
+// result 由 sample 生成 Result
+func result(scheme, op string, ringSize, sigBytes int, s sample) Result {
+	mean, median, p95 := summarize(s.durations)
+	runs := len(s.durations)
+	return Result{
+		Scheme:      scheme,
+		Op:          op,
+		RingSize:    ringSize,
+		Runs:        runs,
+		MeanNs:      mean,
+		MedianNs:    median,
+		P95Ns:       p95,
+		AllocsPerOp: float64(s.allocs) / float64(runs),
+		BytesPerOp:  float64(s.bytes) / float64(runs),
+		SigBytes:    sigBytes,
+	}
+}
+
+// benchScheme 对方案 s 在环大小 n 下测量签名与验证，预热 warmup 次后各重复 runs 次
+func benchScheme(s RingSig.Scheme, n, runs, warmup int) ([]Result, error) {
+	keys := make([]RingSig.PrivateKey, n)
+	ring := make([]RingSig.PublicKey, n)
+	for i := range keys {
+		key, err := s.GenerateKey(nil)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+		ring[i] = key.Public()
+	}
+
+	sigs := make([]RingSig.Signature, runs)
+	sign := func(i int) error {
+		sig, err := s.Sign(benchMessage, ring, keys[i%n], nil)
+		if err != nil {
+			return err
+		}
+		sigs[i%runs] = sig
+		return nil
+	}
+	verify := func(i int) error {
+		return s.Verify(benchMessage, ring, sigs[i%runs])
+	}
+
+	if _, err := measure(warmup, sign); err != nil {
+		return nil, err
+	}
+	signed, err := measure(runs, sign)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := measure(warmup, verify); err != nil {
+		return nil, err
+	}
+	verified, err := measure(runs, verify)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := sigs[0].MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return []Result{
+		result(s.Name(), "sign", n, len(encoded), signed),
+		result(s.Name(), "verify", n, len(encoded), verified),
+	}, nil
+}
+
+// writeResults 按 format（csv 或 json）把结果写入 w
+func writeResults(w io.Writer, format string, results []Result) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, r := range results {
+			if err := cw.Write(r.record()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	default:
+		return fmt.Errorf("未知的输出格式: %s", format)
+	}
+}
+
+// parseSizes 解析以逗号分隔的环大小列表
+func parseSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("非法的环大小: %q", field)
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
+
+// parseSchemes 解析以逗号分隔的方案名称，为空时返回全部已注册方案
+func parseSchemes(s string) ([]RingSig.Scheme, error) {
+	names := RingSig.Names()
+	if s != "" {
+		names = strings.Split(s, ",")
+	}
+	schemes := make([]RingSig.Scheme, len(names))
+	for i, name := range names {
+		scheme, err := RingSig.Lookup(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		schemes[i] = scheme
+	}
+	return schemes, nil
+}
+
+// run 解析命令行参数并执行基准测试，结果写入 -o 指定的文件或 stdout，进度写入 stderr
+func run(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("brfl-bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	schemesFlag := fs.String("schemes", "", "以逗号分隔的方案名称，默认为全部："+strings.Join(RingSig.Names(), ","))
+	sizesFlag := fs.String("sizes", "2,4,8,16,32,64,128", "以逗号分隔的环大小")
+	runs := fs.Int("runs", 10, "每个环大小下签名与验证各重复的次数")
+	warmup := fs.Int("warmup", 1, "正式测量前的预热次数")
+	format := fs.String("format", "csv", "输出格式：csv 或 json")
+	output := fs.String("o", "", "输出文件，默认为 stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *runs < 1 || *warmup < 0 {
+		return errors.New("runs 至少为 1，warmup 不能为负数")
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("未知的输出格式: %s", *format)
+	}
+	sizes, err := parseSizes(*sizesFlag)
+	if err != nil {
+		return err
+	}
+	schemes, err := parseSchemes(*schemesFlag)
+	if err != nil {
+		return err
+	}
+
+	var results []Result
+	for _, s := range schemes {
+		for _, n := range sizes {
+			fmt.Fprintf(stderr, "%s n=%d\n", s.Name(), n)
+			r, err := benchScheme(s, n, *runs, *warmup)
+			if err != nil {
+				return fmt.Errorf("%s n=%d: %w", s.Name(), n, err)
+			}
+			results = append(results, r...)
+		}
+	}
+
+	if *output == "" {
+		return writeResults(stdout, *format, results)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeResults(f, *format, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "brfl-bench:", err)
+		}
+		os.Exit(2)
+	}
+}
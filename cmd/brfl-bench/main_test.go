@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"BRFL/RingSig"
+)
+
+// 测试平均值、中位数与 p95 的计算
+func TestSummarize(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i))
+	}
+	mean, median, p95 := summarize(durations)
+	if mean != 50.5 || median != 50.5 || p95 != 95 {
+		t.Errorf("mean=%v median=%v p95=%v", mean, median, p95)
+	}
+
+	mean, median, p95 = summarize([]time.Duration{3, 1, 2})
+	if mean != 2 || median != 2 || p95 != 3 {
+		t.Errorf("mean=%v median=%v p95=%v", mean, median, p95)
+	}
+	if durations[0] != 100 {
+		t.Errorf("summarize 修改了输入")
+	}
+}
+
+// 测试命令行参数的解析与校验
+func TestParseFlags(t *testing.T) {
+	if sizes, err := parseSizes("2, 8,32"); err != nil || len(sizes) != 3 || sizes[1] != 8 {
+		t.Errorf("sizes=%v err=%v", sizes, err)
+	}
+	for _, s := range []string{"", "0", "2,x"} {
+		if _, err := parseSizes(s); err == nil {
+			t.Errorf("%q: 期望返回错误", s)
+		}
+	}
+	if schemes, err := parseSchemes(""); err != nil || len(schemes) != len(RingSig.Names()) {
+		t.Errorf("默认应选择全部方案: %v", err)
+	}
+	if _, err := parseSchemes("no-such-scheme"); err == nil {
+		t.Errorf("未知方案应返回错误")
+	}
+	for _, args := range [][]string{{"-runs", "0"}, {"-format", "xml"}, {"-sizes", "a"}} {
+		if err := run(args, io.Discard, io.Discard); err == nil {
+			t.Errorf("%v: 期望返回错误", args)
+		}
+	}
+}
+
+// 测试 CSV 与 JSON 输出覆盖全部方案与环大小，签名大小随环大小增长
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-sizes", "2,3", "-runs", "2", "-format", "csv"}, &out, io.Discard); err != nil {
+		t.Fatalf("运行失败: %v", err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("解析 CSV 失败: %v", err)
+	}
+	if want := 1 + len(RingSig.Names())*2*2; len(records) != want {
+		t.Fatalf("CSV 共 %d 行，期望 %d 行", len(records), want)
+	}
+	sigBytes := make(map[string]int)
+	for _, record := range records[1:] {
+		size, _ := strconv.Atoi(record[9])
+		if size <= 0 {
+			t.Errorf("签名大小非法: %v", record)
+		}
+		key := record[0] + "/" + record[1]
+		if prev, ok := sigBytes[key]; ok && size <= prev {
+			t.Errorf("%s: 签名大小未随环大小增长", key)
+		}
+		sigBytes[key] = size
+	}
+
+	out.Reset()
+	if err := run([]string{"-schemes", RingSig.RSCPBN254, "-sizes", "2", "-runs", "1", "-warmup", "0", "-format", "json"}, &out, io.Discard); err != nil {
+		t.Fatalf("运行失败: %v", err)
+	}
+	var results []Result
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("解析 JSON 失败: %v", err)
+	}
+	if len(results) != 2 || results[0].Op != "sign" || results[1].Op != "verify" || results[0].Runs != 1 {
+		t.Errorf("JSON 结果为 %+v", results)
+	}
+}